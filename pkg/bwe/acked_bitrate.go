@@ -0,0 +1,76 @@
+// Package bwe implements Google Congestion Control (GCC) receiver-side
+// bandwidth estimation for WebRTC.
+package bwe
+
+import "time"
+
+// AckedBitrateConfig configures the acknowledged bitrate estimator.
+type AckedBitrateConfig struct {
+	// WindowSize is the duration of the sliding window over which acknowledged
+	// bytes are accumulated.
+	// Default: 500ms
+	WindowSize time.Duration
+}
+
+// DefaultAckedBitrateConfig returns the default acknowledged bitrate configuration.
+func DefaultAckedBitrateConfig() AckedBitrateConfig {
+	return AckedBitrateConfig{
+		WindowSize: 500 * time.Millisecond,
+	}
+}
+
+// AckedBitrateEstimator measures the rate at which the remote end acknowledges
+// receiving packets, using the arrival times reported in transport-cc feedback.
+//
+// On the sender side this replaces the measured incoming rate used by the
+// receiver: it is the throughput the network actually delivered, and is the
+// input to the multiplicative decrease in RateController.
+type AckedBitrateEstimator struct {
+	rate        *RateStats
+	lastArrival time.Time
+	bitrate     int64
+	hasBitrate  bool
+}
+
+// NewAckedBitrateEstimator creates a new acknowledged bitrate estimator.
+func NewAckedBitrateEstimator(config AckedBitrateConfig) *AckedBitrateEstimator {
+	if config.WindowSize <= 0 {
+		config.WindowSize = 500 * time.Millisecond
+	}
+	return &AckedBitrateEstimator{
+		rate: NewRateStats(RateStatsConfig{WindowSize: config.WindowSize}),
+	}
+}
+
+// OnPacketAcked records an acknowledged packet of the given size that arrived
+// at the remote end at arrivalTime (in the remote clock domain).
+//
+// Packets must be reported in non-decreasing arrival order; out-of-order
+// arrivals are accounted at the latest arrival time seen.
+func (a *AckedBitrateEstimator) OnPacketAcked(size int, arrivalTime time.Time) {
+	if arrivalTime.Before(a.lastArrival) {
+		arrivalTime = a.lastArrival
+	}
+	a.lastArrival = arrivalTime
+
+	a.rate.Update(int64(size), arrivalTime)
+	if rate, ok := a.rate.Rate(arrivalTime); ok {
+		a.bitrate = rate
+		a.hasBitrate = true
+	}
+}
+
+// Bitrate returns the acknowledged bitrate in bits per second.
+// Returns (rate, true) once enough packets have been acknowledged,
+// (0, false) before that.
+func (a *AckedBitrateEstimator) Bitrate() (int64, bool) {
+	return a.bitrate, a.hasBitrate
+}
+
+// Reset clears all state.
+func (a *AckedBitrateEstimator) Reset() {
+	a.rate.Reset()
+	a.lastArrival = time.Time{}
+	a.bitrate = 0
+	a.hasBitrate = false
+}
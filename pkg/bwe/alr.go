@@ -0,0 +1,164 @@
+// Package bwe implements Google Congestion Control (GCC) receiver-side
+// bandwidth estimation for WebRTC.
+package bwe
+
+import (
+	"time"
+)
+
+// ALRDetectorConfig configures application-limited region detection.
+type ALRDetectorConfig struct {
+	// Enabled turns ALR detection on. When disabled, the estimate follows
+	// the incoming rate down when the sender is application-limited.
+	// Default: true
+	Enabled bool
+
+	// StartRatio is the fraction of the last-known-good capacity below which
+	// the send rate counts as application-limited.
+	// Default: 0.65
+	StartRatio float64
+
+	// StopRatio is the fraction of the last-known-good capacity above which
+	// the sender is using the link again and ALR ends. Must be above
+	// StartRatio to provide hysteresis.
+	// Default: 0.8
+	StopRatio float64
+
+	// StartDuration is how long the send rate must stay below
+	// StartRatio before ALR is entered.
+	// Default: 500ms
+	StartDuration time.Duration
+
+	// SendRateWindow is the window over which the send rate is measured.
+	// It is shorter than the incoming rate window so that a drop in the send
+	// rate is seen before the ratio constraint drags the estimate down.
+	// Default: 250ms
+	SendRateWindow time.Duration
+}
+
+// DefaultALRDetectorConfig returns the default ALR detector configuration.
+func DefaultALRDetectorConfig() ALRDetectorConfig {
+	return ALRDetectorConfig{
+		Enabled:        true,
+		StartRatio:     0.65,
+		StopRatio:      0.8,
+		StartDuration:  500 * time.Millisecond,
+		SendRateWindow: 250 * time.Millisecond,
+	}
+}
+
+// ALRDetector detects when the sender is application-limited: it sends well
+// below the estimated capacity because it has nothing more to send (e.g. a
+// static screen share), not because the link is congested.
+//
+// Detection uses the rate the sender transmits at, measured from send
+// timestamps, rather than the receive rate. When the link itself slows down
+// the receive rate drops but the send rate does not, so a throughput drop is
+// not mistaken for an application-limited sender.
+//
+// Without ALR detection the 1.5x incoming rate constraint in RateController
+// collapses the estimate toward the trickle rate, and the sender is starved
+// as soon as its content becomes busy again. While in ALR the estimate is
+// held at the last-known-good capacity instead.
+//
+// The capacity reference is frozen as soon as the send rate first drops
+// below StartRatio, so the estimate clamping that happens during the
+// StartDuration window does not lower it.
+type ALRDetector struct {
+	config ALRDetectorConfig
+
+	capacity   int64     // Last-known-good estimate while the link was in use
+	belowSince time.Time // When the send rate first dropped below StartRatio
+	inALR      bool
+	startTime  time.Time // When the current ALR period began
+}
+
+// NewALRDetector creates a new ALR detector.
+// A non-positive StartRatio defaults to 0.65, and a StopRatio not above it to
+// 0.8 (or StartRatio, if higher). StartDuration defaults to 500ms and
+// SendRateWindow to 250ms.
+func NewALRDetector(config ALRDetectorConfig) *ALRDetector {
+	defaults := DefaultALRDetectorConfig()
+	if config.StartRatio <= 0 {
+		config.StartRatio = defaults.StartRatio
+	}
+	if config.StopRatio <= config.StartRatio {
+		config.StopRatio = max(defaults.StopRatio, config.StartRatio)
+	}
+	if config.StartDuration <= 0 {
+		config.StartDuration = defaults.StartDuration
+	}
+	if config.SendRateWindow <= 0 {
+		config.SendRateWindow = defaults.SendRateWindow
+	}
+
+	return &ALRDetector{config: config}
+}
+
+// Update feeds the measured send rate and the current estimate, both in
+// bits per second, and returns whether the sender is application-limited.
+// Call it before the rate controller processes the same measurement.
+func (d *ALRDetector) Update(sendRate, estimate int64, now time.Time) bool {
+	// Track the capacity only while the link is in use
+	if !d.inALR && d.belowSince.IsZero() {
+		d.capacity = estimate
+	}
+
+	if d.inALR {
+		if float64(sendRate) >= d.config.StopRatio*float64(d.capacity) {
+			d.inALR = false
+			d.startTime = time.Time{}
+			d.belowSince = time.Time{}
+		}
+		return d.inALR
+	}
+
+	if float64(sendRate) < d.config.StartRatio*float64(d.capacity) {
+		if d.belowSince.IsZero() {
+			d.belowSince = now
+		}
+		if now.Sub(d.belowSince) >= d.config.StartDuration {
+			d.inALR = true
+			d.startTime = now
+		}
+	} else {
+		d.belowSince = time.Time{}
+	}
+
+	return d.inALR
+}
+
+// InALR returns whether the sender is currently application-limited.
+func (d *ALRDetector) InALR() bool {
+	return d.inALR
+}
+
+// Capacity returns the last-known-good capacity in bits per second.
+// While in ALR this is the rate the estimate is held at.
+func (d *ALRDetector) Capacity() int64 {
+	return d.capacity
+}
+
+// StartTime returns when the current ALR period began.
+// Returns the zero time if not in ALR.
+func (d *ALRDetector) StartTime() time.Time {
+	return d.startTime
+}
+
+// Reset leaves ALR and forgets the capacity reference. Call this when the
+// link is congested, since the last-known-good capacity is no longer valid.
+func (d *ALRDetector) Reset() {
+	d.capacity = 0
+	d.belowSince = time.Time{}
+	d.inALR = false
+	d.startTime = time.Time{}
+}
+
+// newSendRateStats creates the send rate tracker that feeds an ALRDetector.
+func newSendRateStats(config ALRDetectorConfig) *RateStats {
+	window := config.SendRateWindow
+	if window <= 0 {
+		window = DefaultALRDetectorConfig().SendRateWindow
+	}
+	return NewRateStats(RateStatsConfig{WindowSize: window})
+}
@@ -0,0 +1,171 @@
+package bwe
+
+import (
+	"testing"
+	"time"
+
+	"github.com/thesyncim/bwe/pkg/bwe/clock"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestALRDetector_EntersAfterSustainedLowRate(t *testing.T) {
+	d := NewALRDetector(DefaultALRDetectorConfig())
+	now := time.Unix(0, 0)
+
+	// Sender uses the link
+	assert.False(t, d.Update(1_800_000, 2_000_000, now))
+	assert.Equal(t, int64(2_000_000), d.Capacity())
+
+	// Send rate drops; the estimate is clamped meanwhile but the
+	// capacity reference stays frozen
+	assert.False(t, d.Update(200_000, 2_000_000, now.Add(100*time.Millisecond)))
+	assert.False(t, d.Update(200_000, 1_500_000, now.Add(200*time.Millisecond)))
+	assert.False(t, d.Update(200_000, 300_000, now.Add(400*time.Millisecond)))
+	assert.Equal(t, int64(2_000_000), d.Capacity())
+
+	assert.True(t, d.Update(200_000, 300_000, now.Add(600*time.Millisecond)),
+		"sustained low send rate should enter ALR")
+	assert.True(t, d.InALR())
+	assert.Equal(t, now.Add(600*time.Millisecond), d.StartTime())
+	assert.Equal(t, int64(2_000_000), d.Capacity())
+}
+
+func TestALRDetector_BriefDipIgnored(t *testing.T) {
+	d := NewALRDetector(DefaultALRDetectorConfig())
+	now := time.Unix(0, 0)
+
+	d.Update(1_800_000, 2_000_000, now)
+	d.Update(200_000, 2_000_000, now.Add(100*time.Millisecond))
+	d.Update(1_800_000, 2_000_000, now.Add(300*time.Millisecond)) // Recovered before StartDuration
+	assert.False(t, d.Update(200_000, 2_000_000, now.Add(700*time.Millisecond)),
+		"the window restarts after the rate recovers")
+}
+
+func TestALRDetector_ExitHysteresis(t *testing.T) {
+	d := NewALRDetector(DefaultALRDetectorConfig())
+	now := time.Unix(0, 0)
+
+	d.Update(2_000_000, 2_000_000, now)
+	d.Update(100_000, 2_000_000, now.Add(100*time.Millisecond))
+	require.True(t, d.Update(100_000, 2_000_000, now.Add(time.Second)))
+
+	// Between StartRatio and StopRatio: stays in ALR
+	assert.True(t, d.Update(1_400_000, 2_000_000, now.Add(2*time.Second)))
+
+	// Above StopRatio: leaves ALR
+	assert.False(t, d.Update(1_700_000, 2_000_000, now.Add(3*time.Second)))
+	assert.True(t, d.StartTime().IsZero())
+}
+
+func TestALRDetector_Reset(t *testing.T) {
+	d := NewALRDetector(DefaultALRDetectorConfig())
+	now := time.Unix(0, 0)
+
+	d.Update(2_000_000, 2_000_000, now)
+	d.Update(100_000, 2_000_000, now.Add(100*time.Millisecond))
+	require.True(t, d.Update(100_000, 2_000_000, now.Add(time.Second)))
+
+	d.Reset()
+	assert.False(t, d.InALR())
+	assert.Equal(t, int64(0), d.Capacity())
+
+	// Capacity is re-learned from the current estimate
+	d.Update(100_000, 150_000, now.Add(2*time.Second))
+	assert.Equal(t, int64(150_000), d.Capacity())
+}
+
+func TestNewALRDetector_AppliesDefaults(t *testing.T) {
+	d := NewALRDetector(ALRDetectorConfig{})
+	assert.Equal(t, DefaultALRDetectorConfig().StartRatio, d.config.StartRatio)
+	assert.Equal(t, DefaultALRDetectorConfig().StopRatio, d.config.StopRatio)
+	assert.Equal(t, DefaultALRDetectorConfig().StartDuration, d.config.StartDuration)
+}
+
+// sendMedia feeds packets of the given size every 20ms for duration,
+// arriving exactly as sent. Returns the next send time.
+func sendMedia(e *BandwidthEstimator, clock *clock.Virtual, sendTime uint32, size int, d time.Duration) uint32 {
+	for elapsed := time.Duration(0); elapsed < d; elapsed += 20 * time.Millisecond {
+		e.OnPacket(PacketInfo{ArrivalTime: clock.Now(), SendTime: sendTime, Size: size, SSRC: 0x1234})
+		sendTime = (sendTime + DurationToAbsSendTime(20*time.Millisecond)) % AbsSendTimeMax
+		clock.Advance(20 * time.Millisecond)
+	}
+	return sendTime
+}
+
+func TestBandwidthEstimator_ALRHoldsEstimate(t *testing.T) {
+	clock := clock.NewVirtual(time.Time{})
+	e := NewBandwidthEstimator(DefaultBandwidthEstimatorConfig(), clock)
+
+	// 2 Mbps of busy content: 5000 bytes every 20ms
+	sendTime := sendMedia(e, clock, 0, 5000, 30*time.Second)
+	busy := e.GetEstimate()
+	require.Greater(t, busy, int64(1_500_000))
+	assert.False(t, e.IsApplicationLimited())
+
+	// Static screen share: 100 kbps trickle
+	sendTime = sendMedia(e, clock, sendTime, 250, 10*time.Second)
+	assert.True(t, e.IsApplicationLimited(), "trickle traffic should be detected as ALR")
+	assert.False(t, e.GetALRStartTime().IsZero())
+	assert.InDelta(t, busy, e.GetEstimate(), float64(busy)*0.1,
+		"estimate should hold the last-known-good capacity, not collapse or grow")
+
+	// Content becomes busy again and the sender uses the held estimate
+	sendMedia(e, clock, sendTime, int(busy/8/50), 2*time.Second)
+	assert.False(t, e.IsApplicationLimited())
+	assert.True(t, e.GetALRStartTime().IsZero())
+}
+
+func TestBandwidthEstimator_OveruseEndsALR(t *testing.T) {
+	clock := clock.NewVirtual(time.Time{})
+	e := NewBandwidthEstimator(DefaultBandwidthEstimatorConfig(), clock)
+
+	sendTime := sendMedia(e, clock, 0, 5000, 30*time.Second)
+	sendTime = sendMedia(e, clock, sendTime, 250, 10*time.Second)
+	require.True(t, e.rateController.ApplicationLimited())
+
+	// The trickle starts queuing: arrivals spread out while send times do not
+	overused := false
+	for i := 0; i < 200 && !overused; i++ {
+		e.OnPacket(PacketInfo{ArrivalTime: clock.Now(), SendTime: sendTime, Size: 250, SSRC: 0x1234})
+		sendTime = (sendTime + DurationToAbsSendTime(20*time.Millisecond)) % AbsSendTimeMax
+		clock.Advance(60 * time.Millisecond)
+		overused = e.GetCongestionState() == BwOverusing
+	}
+	require.True(t, overused)
+
+	assert.False(t, e.IsApplicationLimited())
+	assert.False(t, e.rateController.ApplicationLimited(), "overuse should end the application-limited hold")
+}
+
+func TestBandwidthEstimator_ALRDisabledCollapses(t *testing.T) {
+	clock := clock.NewVirtual(time.Time{})
+	config := DefaultBandwidthEstimatorConfig()
+	config.ALRConfig.Enabled = false
+	e := NewBandwidthEstimator(config, clock)
+
+	sendTime := sendMedia(e, clock, 0, 5000, 30*time.Second)
+	sendMedia(e, clock, sendTime, 250, 10*time.Second)
+
+	assert.False(t, e.IsApplicationLimited())
+	assert.Less(t, e.GetEstimate(), int64(200_000),
+		"without ALR the estimate follows the trickle rate down")
+}
+
+func TestBandwidthEstimator_ThroughputDropIsNotALR(t *testing.T) {
+	clock := clock.NewVirtual(time.Time{})
+	e := NewBandwidthEstimator(DefaultBandwidthEstimatorConfig(), clock)
+
+	sendTime := sendMedia(e, clock, 0, 5000, 10*time.Second)
+
+	// Sender keeps sending at 2 Mbps but the link delivers far less:
+	// arrivals spread out while send times do not
+	for i := 0; i < 200; i++ {
+		e.OnPacket(PacketInfo{ArrivalTime: clock.Now(), SendTime: sendTime, Size: 5000, SSRC: 0x1234})
+		sendTime = (sendTime + DurationToAbsSendTime(20*time.Millisecond)) % AbsSendTimeMax
+		clock.Advance(50 * time.Millisecond)
+	}
+
+	assert.False(t, e.IsApplicationLimited(), "a slower link must not be mistaken for ALR")
+}
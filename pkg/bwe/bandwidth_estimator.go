@@ -6,7 +6,7 @@ import (
 	"sync"
 	"time"
 
-	"github.com/thesyncim/bwe/pkg/bwe/internal"
+	"github.com/thesyncim/bwe/pkg/bwe/clock"
 )
 
 // BandwidthEstimatorConfig configures the complete bandwidth estimator.
@@ -14,90 +14,229 @@ type BandwidthEstimatorConfig struct {
 	// DelayConfig configures the delay-based detector.
 	DelayConfig DelayEstimatorConfig
 
+	// PerStreamConfig optionally runs a separate delay-based detector per
+	// SSRC or sender clock domain, combined with a worst-of policy.
+	PerStreamConfig PerStreamConfig
+
 	// RateStatsConfig configures incoming rate measurement.
 	RateStatsConfig RateStatsConfig
 
 	// RateControllerConfig configures the AIMD rate controller.
 	RateControllerConfig RateControllerConfig
+
+	// LossControllerConfig configures the loss-based controller.
+	LossControllerConfig LossControllerConfig
+
+	// ProbeConfig configures probe cluster detection for fast ramp-up.
+	ProbeConfig ProbeConfig
+
+	// ALRConfig configures application-limited region detection.
+	ALRConfig ALRDetectorConfig
+
+	// PacketPolicyConfig selects how padding, RTX and FEC packets are used.
+	PacketPolicyConfig PacketPolicyConfig
+
+	// SilenceConfig configures handling of mute, silence and long gaps.
+	SilenceConfig SilenceConfig
+
+	// SSRCConfig configures SSRC expiry and the cap on tracked SSRCs.
+	SSRCConfig SSRCConfig
 }
 
 // DefaultBandwidthEstimatorConfig returns default configuration.
 func DefaultBandwidthEstimatorConfig() BandwidthEstimatorConfig {
 	return BandwidthEstimatorConfig{
 		DelayConfig:          DefaultDelayEstimatorConfig(),
+		PerStreamConfig:      DefaultPerStreamConfig(),
 		RateStatsConfig:      DefaultRateStatsConfig(),
 		RateControllerConfig: DefaultRateControllerConfig(),
+		LossControllerConfig: DefaultLossControllerConfig(),
+		ProbeConfig:          DefaultProbeConfig(),
+		ALRConfig:            DefaultALRDetectorConfig(),
+		PacketPolicyConfig:   DefaultPacketPolicyConfig(),
+		SilenceConfig:        DefaultSilenceConfig(),
+		SSRCConfig:           DefaultSSRCConfig(),
 	}
 }
 
 // BandwidthEstimator is the main entry point for bandwidth estimation.
 // It combines:
-//   - DelayEstimator for congestion signal detection
+//   - DelayEstimator for congestion signal detection, shared or per stream
 //   - RateStats for incoming bitrate measurement
 //   - RateController for AIMD-based bandwidth estimation
+//   - LossController for the loss-based bound on the estimate
+//   - ProbeDetector for probe-based fast ramp-up
+//   - ALRDetector to hold the estimate while the sender is application-limited
+//   - silence handling to restart the delay pipeline after gaps
+//
+// The final estimate is the minimum of the delay-based and loss-based
+// estimates, as described in draft-ietf-rmcat-gcc.
 //
 // This is a standalone core library with NO Pion dependencies.
 // BandwidthEstimator is safe for concurrent use from multiple goroutines.
 type BandwidthEstimator struct {
 	config         BandwidthEstimatorConfig
-	clock          internal.Clock
-	delayEstimator *DelayEstimator
+	clock          clock.Clock
+	delayEstimator delayDetector
 	rateStats      *RateStats
 	rateController *RateController
+	lossController *LossController
+	probeDetector  *ProbeDetector
+	alrDetector    *ALRDetector
+	sendRateStats  *RateStats // Sender's transmit rate, for ALR detection
 
 	// Mutex protects concurrent access to state fields below.
 	// Required when multiple streams call OnPacket concurrently.
 	mu sync.Mutex
 
 	// Current state (protected by mu)
-	estimate int64
-	ssrcs    map[uint32]struct{} // Track seen SSRCs
-
-	// REMB scheduling (optional, set via SetREMBScheduler)
+	estimate      int64
+	delayEstimate int64
+	lossEstimate  int64 // 0 when no loss-based bound is active
+	activeBound   EstimateBound
+	ssrcs         map[uint32]ssrcActivity // Live SSRCs and when they last sent
+	ssrcConfig    SSRCConfig
+	lastSweep     time.Time
+
+	// Silence detection (protected by mu)
+	silenceConfig SilenceConfig
+	silence       silenceState
+
+	// REMB scheduling (optional, set via SetREMBScheduler), and the REMBs
+	// built so far (protected by mu)
 	rembScheduler *REMBScheduler
+	rembSent      int64
+	rembHistory   []REMBEvent
+
+	// Pipeline events (optional, set via SetObserver), and the states last
+	// reported (protected by mu)
+	observer      Observer
+	lastUsage     BandwidthUsage
+	lastRateState RateControlState
 
 	// Track last packet time for REMB scheduling convenience
 	lastPacketTime time.Time
+
+	// Unwrapped abs-send-time timeline for the send rate (protected by mu)
+	lastSendTime uint32
+	sendTimeline time.Duration
+	hasSendTime  bool
 }
 
+// sendTimelineOrigin anchors the unwrapped abs-send-time timeline.
+// Only differences along the timeline matter.
+var sendTimelineOrigin = time.Unix(0, 0)
+
 // NewBandwidthEstimator creates a new bandwidth estimator.
-// If clock is nil, a default MonotonicClock is used.
-func NewBandwidthEstimator(config BandwidthEstimatorConfig, clock internal.Clock) *BandwidthEstimator {
-	if clock == nil {
-		clock = internal.MonotonicClock{}
+// If clk is nil, clock.Monotonic is used.
+func NewBandwidthEstimator(config BandwidthEstimatorConfig, clk clock.Clock) *BandwidthEstimator {
+	if clk == nil {
+		clk = clock.Monotonic{}
+	}
+
+	var delayEstimator delayDetector
+	if config.PerStreamConfig.Enabled {
+		delayEstimator = NewPerStreamDelayEstimator(config.DelayConfig, config.PerStreamConfig, clk)
+	} else {
+		delayEstimator = NewDelayEstimator(config.DelayConfig, clk)
 	}
 
 	return &BandwidthEstimator{
 		config:         config,
-		clock:          clock,
-		delayEstimator: NewDelayEstimator(config.DelayConfig, clock),
+		clock:          clk,
+		delayEstimator: delayEstimator,
 		rateStats:      NewRateStats(config.RateStatsConfig),
 		rateController: NewRateController(config.RateControllerConfig),
+		lossController: NewLossController(config.LossControllerConfig),
+		probeDetector:  NewProbeDetector(config.ProbeConfig),
+		alrDetector:    NewALRDetector(config.ALRConfig),
+		sendRateStats:  newSendRateStats(config.ALRConfig),
 		estimate:       config.RateControllerConfig.InitialBitrate,
-		ssrcs:          make(map[uint32]struct{}),
+		delayEstimate:  config.RateControllerConfig.InitialBitrate,
+		ssrcs:          make(map[uint32]ssrcActivity),
+		silenceConfig:  config.SilenceConfig.withDefaults(),
+		ssrcConfig:     config.SSRCConfig.withDefaults(),
+		lastUsage:      BwNormal,
+		lastRateState:  RateHold,
 	}
 }
 
 // OnPacket processes a received packet and updates the bandwidth estimate.
 // This is the main entry point - call this for every received RTP packet.
+// The packet's Class selects its PacketPolicy: ignored packets only count
+// toward loss tracking, and rate-only packets skip delay measurement and
+// probe detection.
 //
 // Parameters:
-//   - pkt: Packet information (arrival time, send time, size, SSRC)
+//   - pkt: Packet information (arrival time, send time, size, SSRC, sequence number)
 //
 // Returns the current bandwidth estimate in bits per second.
 // This method is safe for concurrent calls from multiple goroutines.
 func (e *BandwidthEstimator) OnPacket(pkt PacketInfo) int64 {
 	e.mu.Lock()
-	defer e.mu.Unlock()
+	estimate := e.onPacket(pkt)
+	cb, events := e.takeSilenceEvents()
+	e.mu.Unlock()
+
+	for _, event := range events {
+		cb(event)
+	}
+	return estimate
+}
+
+// onPacket implements OnPacket. Must be called with mu held.
+func (e *BandwidthEstimator) onPacket(pkt PacketInfo) int64 {
+	// Track sequence-number gaps for the loss-based controller. Every
+	// packet counts, so ignored padding on a media SSRC does not read as loss.
+	if e.config.LossControllerConfig.Enabled {
+		e.lossController.OnPacket(pkt.SSRC, pkt.SequenceNumber, pkt.ArrivalTime)
+	}
+
+	policy := e.config.PacketPolicyConfig.Policy(pkt.Class)
+	if policy == PolicyIgnore {
+		return e.estimate
+	}
+
+	// Restart the delay pipeline after a gap
+	if e.silenceConfig.Enabled {
+		e.onSilencePacket(pkt)
+	}
+
+	// Expire SSRCs at most once per second
+	if pkt.ArrivalTime.Sub(e.lastSweep) >= streamSweepInterval {
+		e.sweep(pkt.ArrivalTime)
+	}
 
 	// Track SSRC
-	e.ssrcs[pkt.SSRC] = struct{}{}
+	e.trackSSRC(pkt.SSRC, pkt.Size, pkt.ArrivalTime)
 
 	// Update incoming rate measurement
 	e.rateStats.Update(int64(pkt.Size), pkt.ArrivalTime)
 
+	// Measure the sender's transmit rate on the send-time timeline
+	if e.config.ALRConfig.Enabled {
+		e.updateSendRate(pkt)
+	}
+
 	// Get congestion signal from delay estimator
-	signal := e.delayEstimator.OnPacket(pkt)
+	var signal BandwidthUsage
+	if policy == PolicyRateOnly {
+		signal = e.delayEstimator.State()
+	} else {
+		signal = e.delayEstimator.OnPacket(pkt)
+	}
+	e.observeUsage(signal, pkt.ArrivalTime)
+
+	// A successful probe above the current estimate jumps straight to the
+	// probed capacity instead of waiting for AIMD to ramp up
+	if e.config.ProbeConfig.Enabled && policy != PolicyRateOnly {
+		if result, ok := e.probeDetector.OnPacket(pkt); ok && result.Success &&
+			result.Estimate > e.delayEstimate && signal != BwOverusing {
+			e.delayEstimate = e.rateController.SetEstimate(result.Estimate, pkt.ArrivalTime)
+			e.observeRateState(pkt.ArrivalTime)
+			e.updateEstimate(pkt.ArrivalTime)
+		}
+	}
 
 	// Get measured incoming rate
 	incomingRate, ok := e.rateStats.Rate(pkt.ArrivalTime)
@@ -107,8 +246,17 @@ func (e *BandwidthEstimator) OnPacket(pkt PacketInfo) int64 {
 		return e.estimate
 	}
 
+	// Hold the estimate while the sender is application-limited
+	if e.config.ALRConfig.Enabled {
+		e.updateALR(signal, pkt.ArrivalTime)
+	}
+
 	// Update rate controller with signal and incoming rate
-	e.estimate = e.rateController.Update(signal, incomingRate, pkt.ArrivalTime)
+	e.delayEstimate = e.rateController.Update(signal, incomingRate, pkt.ArrivalTime)
+	e.observeRateState(pkt.ArrivalTime)
+
+	// Combine with the loss-based bound: final estimate is the minimum
+	e.updateEstimate(pkt.ArrivalTime)
 
 	// Track last packet time for REMB scheduling
 	e.lastPacketTime = pkt.ArrivalTime
@@ -116,6 +264,93 @@ func (e *BandwidthEstimator) OnPacket(pkt PacketInfo) int64 {
 	return e.estimate
 }
 
+// updateSendRate advances the unwrapped send-time timeline and records the
+// packet in the send rate. Reordered packets do not move the timeline back.
+// Must be called with mu held.
+func (e *BandwidthEstimator) updateSendRate(pkt PacketInfo) {
+	if e.hasSendTime {
+		if delta := UnwrapAbsSendTimeDuration(e.lastSendTime, pkt.SendTime); delta > 0 {
+			e.sendTimeline += delta
+			e.lastSendTime = pkt.SendTime
+		}
+	} else {
+		e.lastSendTime = pkt.SendTime
+		e.hasSendTime = true
+	}
+	e.sendRateStats.Update(int64(pkt.Size), sendTimelineOrigin.Add(e.sendTimeline))
+}
+
+// updateALR runs the ALR detector and passes its state to the rate controller.
+// Overuse invalidates the last-known-good capacity, so it ends ALR.
+// Must be called with mu held.
+func (e *BandwidthEstimator) updateALR(signal BandwidthUsage, now time.Time) {
+	if signal == BwOverusing {
+		e.resetSendRate()
+	}
+	sendRate, ok := e.sendRateStats.Rate(sendTimelineOrigin.Add(e.sendTimeline))
+	if !ok {
+		return
+	}
+	limited := e.alrDetector.Update(sendRate, e.delayEstimate, now)
+	e.rateController.SetApplicationLimited(limited, e.alrDetector.Capacity())
+}
+
+// sweep runs the periodic idle checks and removes expired SSRCs.
+// Must be called with mu held.
+func (e *BandwidthEstimator) sweep(now time.Time) {
+	if e.silenceConfig.Enabled {
+		e.checkIdle(now)
+	}
+	e.pruneSSRCs(now)
+	e.lastSweep = now
+}
+
+// resetSendRate clears the ALR detector and the send-time timeline, and
+// takes the rate controller out of the application-limited region.
+// Must be called with mu held.
+func (e *BandwidthEstimator) resetSendRate() {
+	e.alrDetector.Reset()
+	e.rateController.SetApplicationLimited(false, 0)
+	e.sendRateStats.Reset()
+	e.lastSendTime = 0
+	e.sendTimeline = 0
+	e.hasSendTime = false
+}
+
+// updateEstimate combines the delay-based estimate with the loss-based bound.
+// Must be called with mu held.
+func (e *BandwidthEstimator) updateEstimate(now time.Time) {
+	old := e.estimate
+	e.combineEstimate(now)
+	e.observeEstimate(old, now)
+}
+
+// combineEstimate sets the estimate to the minimum of the delay-based
+// estimate and the loss-based bound. Must be called with mu held.
+func (e *BandwidthEstimator) combineEstimate(now time.Time) {
+	e.estimate = e.delayEstimate
+	e.activeBound = BoundDelay
+
+	if !e.config.LossControllerConfig.Enabled {
+		return
+	}
+
+	lossEstimate, ok := e.lossController.Update(e.delayEstimate, e.rateController.config.MaxBitrate, now)
+	if !ok {
+		e.lossEstimate = 0
+		return
+	}
+	e.lossEstimate = lossEstimate
+
+	if lossEstimate < e.estimate {
+		e.estimate = lossEstimate
+		e.activeBound = BoundLoss
+	}
+	if e.estimate < e.rateController.config.MinBitrate {
+		e.estimate = e.rateController.config.MinBitrate
+	}
+}
+
 // GetEstimate returns the current bandwidth estimate in bits per second.
 // Call this at any time to get the latest estimate without processing a packet.
 func (e *BandwidthEstimator) GetEstimate() int64 {
@@ -124,16 +359,44 @@ func (e *BandwidthEstimator) GetEstimate() int64 {
 	return e.estimate
 }
 
-// GetSSRCs returns the list of SSRCs seen so far.
+// GetDelayBasedEstimate returns the delay-based AIMD estimate in bits per second,
+// before the loss-based bound is applied.
+func (e *BandwidthEstimator) GetDelayBasedEstimate() int64 {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.delayEstimate
+}
+
+// GetLossBasedEstimate returns the loss-based bound in bits per second.
+// Returns (bound, true) if a bound is active, (0, false) otherwise.
+func (e *BandwidthEstimator) GetLossBasedEstimate() (int64, bool) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.lossEstimate, e.lossEstimate > 0
+}
+
+// GetLossFraction returns the fraction of packets lost over the loss window.
+// Returns (fraction, true) if enough packets have been seen, (0, false) otherwise.
+func (e *BandwidthEstimator) GetLossFraction() (float64, bool) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.lossController.FractionLost(e.clock.Now())
+}
+
+// GetActiveBound returns which controller currently limits the estimate.
+func (e *BandwidthEstimator) GetActiveBound() EstimateBound {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.activeBound
+}
+
+// GetSSRCs returns the live SSRCs in ascending order: those that sent a
+// packet within SSRCConfig.Timeout and were not removed.
 // This is useful for building REMB packets.
 func (e *BandwidthEstimator) GetSSRCs() []uint32 {
 	e.mu.Lock()
 	defer e.mu.Unlock()
-	result := make([]uint32, 0, len(e.ssrcs))
-	for ssrc := range e.ssrcs {
-		result = append(result, ssrc)
-	}
-	return result
+	return e.sortedSSRCs()
 }
 
 // GetCongestionState returns the current congestion state.
@@ -143,6 +406,91 @@ func (e *BandwidthEstimator) GetCongestionState() BandwidthUsage {
 	return e.delayEstimator.State()
 }
 
+// GetDelayFilterOutput returns the most recent delay filter output in milliseconds.
+func (e *BandwidthEstimator) GetDelayFilterOutput() float64 {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.delayEstimator.FilterOutput()
+}
+
+// GetThreshold returns the current adaptive overuse threshold in milliseconds.
+func (e *BandwidthEstimator) GetThreshold() float64 {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.delayEstimator.Threshold()
+}
+
+// GetInterArrivalStats returns the reordering and clock-jump counters of the
+// delay-based estimator's packet grouping.
+func (e *BandwidthEstimator) GetInterArrivalStats() InterArrivalStats {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.delayEstimator.InterArrivalStats()
+}
+
+// IsApplicationLimited returns whether the sender is currently
+// application-limited, in which case the estimate is held at the
+// last-known-good capacity.
+func (e *BandwidthEstimator) IsApplicationLimited() bool {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.alrDetector.InALR()
+}
+
+// GetALRStartTime returns when the current application-limited period began.
+// Returns the zero time if the sender is not application-limited.
+func (e *BandwidthEstimator) GetALRStartTime() time.Time {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.alrDetector.StartTime()
+}
+
+// GetLinkCapacity returns the estimated bottleneck capacity in bits per second,
+// learned from the incoming rate at each overuse.
+// Returns (capacity, true) if an estimate exists, (0, false) otherwise.
+func (e *BandwidthEstimator) GetLinkCapacity() (int64, bool) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.rateController.LinkCapacity()
+}
+
+// GetKalmanCapacity returns the link capacity estimated by the two-state
+// Kalman filter, in bits per second, for comparison with the AIMD estimate.
+// Returns (capacity, true) only when the delay filter is FilterKalmanCapacity.
+func (e *BandwidthEstimator) GetKalmanCapacity() (int64, bool) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.delayEstimator.KalmanCapacity()
+}
+
+// GetClockSkewPPM returns the estimated skew between the sender's send-time
+// clock and the local clock, in parts per million, which the delay-based
+// estimator removes before filtering.
+// Returns (skew, true) once enough history exists, (0, false) otherwise or
+// when drift compensation is disabled.
+func (e *BandwidthEstimator) GetClockSkewPPM() (float64, bool) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.delayEstimator.ClockSkewPPM()
+}
+
+// UpdateRTT feeds a round-trip time measurement to the rate controller,
+// which uses it to size additive increases and pace decreases.
+// Non-positive values are ignored.
+func (e *BandwidthEstimator) UpdateRTT(rtt time.Duration) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.rateController.UpdateRTT(rtt)
+}
+
+// GetRTT returns the round-trip time used by the rate controller.
+// Until UpdateRTT is called this is the 200ms default.
+func (e *BandwidthEstimator) GetRTT() time.Duration {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.rateController.RTT()
+}
+
 // GetRateControlState returns the current AIMD rate control state.
 func (e *BandwidthEstimator) GetRateControlState() RateControlState {
 	e.mu.Lock()
@@ -150,6 +498,14 @@ func (e *BandwidthEstimator) GetRateControlState() RateControlState {
 	return e.rateController.State()
 }
 
+// GetProbeResults returns the results of the most recent probe clusters,
+// oldest first. Failed probes are included with Success set to false.
+func (e *BandwidthEstimator) GetProbeResults() []ProbeResult {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.probeDetector.Results()
+}
+
 // GetIncomingRate returns the measured incoming bitrate in bits per second.
 // Returns (rate, true) if available, (0, false) otherwise.
 func (e *BandwidthEstimator) GetIncomingRate() (int64, bool) {
@@ -166,9 +522,19 @@ func (e *BandwidthEstimator) Reset() {
 	e.delayEstimator.Reset()
 	e.rateStats.Reset()
 	e.rateController.Reset()
+	e.lossController.Reset()
+	e.probeDetector.Reset()
+	e.alrDetector.Reset()
 	e.estimate = e.config.RateControllerConfig.InitialBitrate
-	e.ssrcs = make(map[uint32]struct{})
+	e.delayEstimate = e.config.RateControllerConfig.InitialBitrate
+	e.lossEstimate = 0
+	e.activeBound = BoundDelay
+	e.ssrcs = make(map[uint32]ssrcActivity)
+	e.lastSweep = time.Time{}
 	e.lastPacketTime = time.Time{}
+	e.silence = silenceState{callback: e.silence.callback}
+	e.lastUsage = BwNormal
+	e.lastRateState = e.rateController.State()
 	// Note: We don't reset the REMB scheduler here, as it's externally provided.
 	// The caller can reset it separately if needed.
 }
@@ -188,8 +554,9 @@ func (e *BandwidthEstimator) SetREMBScheduler(scheduler *REMBScheduler) {
 //
 // This should be called after OnPacket() or periodically.
 //
-// Multi-SSRC: The REMB packet includes ALL SSRCs seen by this estimator,
+// Multi-SSRC: The REMB packet includes all live SSRCs in ascending order,
 // as receiver-side estimation produces ONE estimate for the entire session.
+// SSRCs silent for SSRCConfig.Timeout at now are left out.
 func (e *BandwidthEstimator) MaybeBuildREMB(now time.Time) ([]byte, bool, error) {
 	e.mu.Lock()
 	defer e.mu.Unlock()
@@ -198,17 +565,21 @@ func (e *BandwidthEstimator) MaybeBuildREMB(now time.Time) ([]byte, bool, error)
 		return nil, false, nil // No scheduler, no REMB
 	}
 
+	e.pruneSSRCs(now)
 	if len(e.ssrcs) == 0 {
-		return nil, false, nil // No SSRCs seen yet
+		return nil, false, nil // No live SSRCs
 	}
 
-	// Build SSRCs slice while holding lock
-	ssrcs := make([]uint32, 0, len(e.ssrcs))
-	for ssrc := range e.ssrcs {
-		ssrcs = append(ssrcs, ssrc)
+	ssrcs := e.sortedSSRCs()
+	data, shouldSend, err := e.rembScheduler.MaybeSendREMB(e.estimate, ssrcs, now)
+	if shouldSend {
+		event := REMBEvent{Time: now, Bitrate: e.estimate, SSRCs: ssrcs}
+		e.recordREMB(event)
+		if e.observer != nil {
+			e.observer.OnREMB(event)
+		}
 	}
-
-	return e.rembScheduler.MaybeSendREMB(e.estimate, ssrcs, now)
+	return data, shouldSend, err
 }
 
 // GetLastPacketTime returns the arrival time of the last processed packet.
@@ -7,7 +7,7 @@ import (
 	"testing"
 	"time"
 
-	"github.com/thesyncim/bwe/pkg/bwe/internal"
+	"github.com/thesyncim/bwe/pkg/bwe/clock"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -20,7 +20,7 @@ import (
 func TestBandwidthEstimator_InitialEstimate(t *testing.T) {
 	// Test: Returns initial bitrate on first call
 	config := DefaultBandwidthEstimatorConfig()
-	clock := internal.NewMockClock(time.Time{})
+	clock := clock.NewVirtual(time.Time{})
 	estimator := NewBandwidthEstimator(config, clock)
 
 	// Before any packets, GetEstimate should return initial bitrate
@@ -43,7 +43,7 @@ func TestBandwidthEstimator_InitialEstimate(t *testing.T) {
 func TestBandwidthEstimator_NormalTraffic(t *testing.T) {
 	// Test: Stable traffic maintains/increases estimate
 	config := DefaultBandwidthEstimatorConfig()
-	clock := internal.NewMockClock(time.Time{})
+	clock := clock.NewVirtual(time.Time{})
 	estimator := NewBandwidthEstimator(config, clock)
 
 	initialEstimate := config.RateControllerConfig.InitialBitrate
@@ -77,7 +77,7 @@ func TestBandwidthEstimator_NormalTraffic(t *testing.T) {
 func TestBandwidthEstimator_Congestion(t *testing.T) {
 	// Test: Congestion decreases estimate
 	config := DefaultBandwidthEstimatorConfig()
-	clock := internal.NewMockClock(time.Time{})
+	clock := clock.NewVirtual(time.Time{})
 	estimator := NewBandwidthEstimator(config, clock)
 
 	sendTime := uint32(0)
@@ -114,10 +114,70 @@ func TestBandwidthEstimator_Congestion(t *testing.T) {
 		"persistent congestion should result in Overusing state")
 }
 
+func TestBandwidthEstimator_GetLinkCapacity(t *testing.T) {
+	config := DefaultBandwidthEstimatorConfig()
+	clock := clock.NewVirtual(time.Time{})
+	estimator := NewBandwidthEstimator(config, clock)
+
+	_, ok := estimator.GetLinkCapacity()
+	assert.False(t, ok, "no capacity before congestion")
+
+	sendTime := uint32(0)
+	for i := 0; i < 100; i++ {
+		estimator.OnPacket(PacketInfo{
+			ArrivalTime: clock.Now(),
+			SendTime:    sendTime,
+			Size:        1200,
+			SSRC:        0x12345678,
+		})
+		sendTime += uint32(20 * 262)
+		clock.Advance(70 * time.Millisecond) // Queue builds 50ms per packet
+	}
+
+	capacity, ok := estimator.GetLinkCapacity()
+	require.True(t, ok, "overuse should produce a capacity estimate")
+	incoming, _ := estimator.GetIncomingRate()
+	assert.InDelta(t, incoming, capacity, float64(incoming)*0.2,
+		"capacity should track the incoming rate at overuse")
+
+	estimator.Reset()
+	_, ok = estimator.GetLinkCapacity()
+	assert.False(t, ok)
+}
+
+func TestBandwidthEstimator_GetKalmanCapacity(t *testing.T) {
+	clock := clock.NewVirtual(time.Time{})
+	estimator := NewBandwidthEstimator(DefaultBandwidthEstimatorConfig(), clock)
+	_, ok := estimator.GetKalmanCapacity()
+	assert.False(t, ok, "the default Kalman filter does not estimate capacity")
+
+	config := DefaultBandwidthEstimatorConfig()
+	config.DelayConfig.FilterType = FilterKalmanCapacity
+	estimator = NewBandwidthEstimator(config, clock)
+	sendMedia(estimator, clock, 0, 1200, time.Second)
+
+	capacity, ok := estimator.GetKalmanCapacity()
+	require.True(t, ok)
+	assert.Greater(t, capacity, int64(0))
+}
+
+func TestBandwidthEstimator_UpdateRTT(t *testing.T) {
+	clock := clock.NewVirtual(time.Time{})
+	estimator := NewBandwidthEstimator(DefaultBandwidthEstimatorConfig(), clock)
+
+	assert.Equal(t, 200*time.Millisecond, estimator.GetRTT())
+
+	estimator.UpdateRTT(35 * time.Millisecond)
+	assert.Equal(t, 35*time.Millisecond, estimator.GetRTT())
+
+	estimator.UpdateRTT(0)
+	assert.Equal(t, 35*time.Millisecond, estimator.GetRTT(), "zero RTT is ignored")
+}
+
 func TestBandwidthEstimator_TracksSSRCs(t *testing.T) {
 	// Test: Multiple SSRCs tracked correctly
 	config := DefaultBandwidthEstimatorConfig()
-	clock := internal.NewMockClock(time.Time{})
+	clock := clock.NewVirtual(time.Time{})
 	estimator := NewBandwidthEstimator(config, clock)
 
 	// Initially no SSRCs
@@ -153,7 +213,7 @@ func TestBandwidthEstimator_TracksSSRCs(t *testing.T) {
 func TestBandwidthEstimator_DuplicateSSRC(t *testing.T) {
 	// Test: Same SSRC not duplicated
 	config := DefaultBandwidthEstimatorConfig()
-	clock := internal.NewMockClock(time.Time{})
+	clock := clock.NewVirtual(time.Time{})
 	estimator := NewBandwidthEstimator(config, clock)
 
 	// Add multiple packets from same SSRC
@@ -176,7 +236,7 @@ func TestBandwidthEstimator_DuplicateSSRC(t *testing.T) {
 func TestBandwidthEstimator_GetCongestionState(t *testing.T) {
 	// Test: Exposes delay detector state
 	config := DefaultBandwidthEstimatorConfig()
-	clock := internal.NewMockClock(time.Time{})
+	clock := clock.NewVirtual(time.Time{})
 	estimator := NewBandwidthEstimator(config, clock)
 
 	// Initial state should be Normal
@@ -190,7 +250,7 @@ func TestBandwidthEstimator_GetCongestionState(t *testing.T) {
 func TestBandwidthEstimator_GetRateControlState(t *testing.T) {
 	// Test: Exposes rate control state
 	config := DefaultBandwidthEstimatorConfig()
-	clock := internal.NewMockClock(time.Time{})
+	clock := clock.NewVirtual(time.Time{})
 	estimator := NewBandwidthEstimator(config, clock)
 
 	// Initial state should be Hold
@@ -201,7 +261,7 @@ func TestBandwidthEstimator_GetRateControlState(t *testing.T) {
 func TestBandwidthEstimator_GetIncomingRate(t *testing.T) {
 	// Test: Exposes measured rate
 	config := DefaultBandwidthEstimatorConfig()
-	clock := internal.NewMockClock(time.Time{})
+	clock := clock.NewVirtual(time.Time{})
 	estimator := NewBandwidthEstimator(config, clock)
 
 	// Initially no rate available
@@ -235,7 +295,7 @@ func TestBandwidthEstimator_GetIncomingRate(t *testing.T) {
 func TestBandwidthEstimator_Reset(t *testing.T) {
 	// Test: Reset clears all state
 	config := DefaultBandwidthEstimatorConfig()
-	clock := internal.NewMockClock(time.Time{})
+	clock := clock.NewVirtual(time.Time{})
 	estimator := NewBandwidthEstimator(config, clock)
 
 	// Add some packets with congestion
@@ -291,7 +351,7 @@ func TestBandwidthEstimator_NoPionDependency(t *testing.T) {
 func TestBandwidthEstimator_StableNetwork(t *testing.T) {
 	// Integration test: Simulating stable traffic over longer period
 	config := DefaultBandwidthEstimatorConfig()
-	clock := internal.NewMockClock(time.Time{})
+	clock := clock.NewVirtual(time.Time{})
 	estimator := NewBandwidthEstimator(config, clock)
 
 	initialEstimate := config.RateControllerConfig.InitialBitrate
@@ -366,7 +426,7 @@ func TestBandwidthEstimator_DefaultConfig(t *testing.T) {
 func TestBandwidthEstimator_RecoveryFromCongestion(t *testing.T) {
 	// Test: Recovery after congestion clears
 	config := DefaultBandwidthEstimatorConfig()
-	clock := internal.NewMockClock(time.Time{})
+	clock := clock.NewVirtual(time.Time{})
 	estimator := NewBandwidthEstimator(config, clock)
 
 	sendTime := uint32(0)
@@ -413,7 +473,7 @@ func TestBandwidthEstimator_RecoveryFromCongestion(t *testing.T) {
 func TestBandwidthEstimator_MultipleSSRCsSameEstimate(t *testing.T) {
 	// Test: Multiple SSRCs contribute to same bandwidth estimate
 	config := DefaultBandwidthEstimatorConfig()
-	clock := internal.NewMockClock(time.Time{})
+	clock := clock.NewVirtual(time.Time{})
 	estimator := NewBandwidthEstimator(config, clock)
 
 	sendTime := uint32(0)
@@ -453,7 +513,7 @@ func TestBandwidthEstimator_MultiSSRC_Aggregation(t *testing.T) {
 	// Test: Multiple SSRCs feed single estimate
 	// Video SSRC: 1 Mbps (125 bytes/ms)
 	// Audio SSRC: 50 kbps (~6 bytes/ms)
-	clock := internal.NewMockClock(time.Now())
+	clock := clock.NewVirtual(time.Now())
 	e := NewBandwidthEstimator(DefaultBandwidthEstimatorConfig(), clock)
 
 	videoSSRC := uint32(0x11111111)
@@ -504,7 +564,7 @@ func TestBandwidthEstimator_MultiSSRC_Aggregation(t *testing.T) {
 
 func TestBandwidthEstimator_MultiSSRC_CongestionAffectsAll(t *testing.T) {
 	// Test: Congestion via one SSRC affects total estimate
-	clock := internal.NewMockClock(time.Now())
+	clock := clock.NewVirtual(time.Now())
 	e := NewBandwidthEstimator(DefaultBandwidthEstimatorConfig(), clock)
 
 	videoSSRC := uint32(0x11111111)
@@ -574,7 +634,7 @@ func TestBandwidthEstimator_MultiSSRC_CongestionAffectsAll(t *testing.T) {
 
 func TestBandwidthEstimator_REMBIntegration_Basic(t *testing.T) {
 	// Test: MaybeBuildREMB returns packet at interval
-	clock := internal.NewMockClock(time.Now())
+	clock := clock.NewVirtual(time.Now())
 	e := NewBandwidthEstimator(DefaultBandwidthEstimatorConfig(), clock)
 
 	// Attach REMB scheduler with 1 second interval
@@ -613,7 +673,7 @@ func TestBandwidthEstimator_REMBIntegration_Basic(t *testing.T) {
 
 func TestBandwidthEstimator_REMBIntegration_ImmediateDecrease(t *testing.T) {
 	// Test: REMB sent immediately on significant decrease
-	clock := internal.NewMockClock(time.Now())
+	clock := clock.NewVirtual(time.Now())
 	e := NewBandwidthEstimator(DefaultBandwidthEstimatorConfig(), clock)
 
 	// Custom scheduler with 10 second interval (so regular send is rare)
@@ -676,7 +736,7 @@ func TestBandwidthEstimator_REMBIntegration_ImmediateDecrease(t *testing.T) {
 
 func TestBandwidthEstimator_REMBIntegration_IncludesAllSSRCs(t *testing.T) {
 	// Test: REMB contains all seen SSRCs
-	clock := internal.NewMockClock(time.Now())
+	clock := clock.NewVirtual(time.Now())
 	e := NewBandwidthEstimator(DefaultBandwidthEstimatorConfig(), clock)
 
 	scheduler := NewREMBScheduler(DefaultREMBSchedulerConfig())
@@ -719,7 +779,7 @@ func TestBandwidthEstimator_REMBIntegration_IncludesAllSSRCs(t *testing.T) {
 
 func TestBandwidthEstimator_NoSchedulerNoREMB(t *testing.T) {
 	// Test: Without scheduler, MaybeBuildREMB returns false
-	clock := internal.NewMockClock(time.Now())
+	clock := clock.NewVirtual(time.Now())
 	e := NewBandwidthEstimator(DefaultBandwidthEstimatorConfig(), clock)
 
 	// Feed some packets
@@ -747,7 +807,7 @@ func TestBandwidthEstimator_NoSchedulerNoREMB(t *testing.T) {
 func TestBandwidthEstimator_FullPipeline_StableNetwork(t *testing.T) {
 	// Integration test: 30 seconds of stable ~2 Mbps traffic
 	// 2 SSRCs (video + audio)
-	clock := internal.NewMockClock(time.Now())
+	clock := clock.NewVirtual(time.Now())
 	e := NewBandwidthEstimator(DefaultBandwidthEstimatorConfig(), clock)
 
 	scheduler := NewREMBScheduler(DefaultREMBSchedulerConfig())
@@ -817,7 +877,7 @@ func TestBandwidthEstimator_FullPipeline_CongestionEvent(t *testing.T) {
 	// 5s stable at ~2 Mbps
 	// 2s congestion (increasing delay)
 	// 5s recovery
-	clock := internal.NewMockClock(time.Now())
+	clock := clock.NewVirtual(time.Now())
 	e := NewBandwidthEstimator(DefaultBandwidthEstimatorConfig(), clock)
 
 	// Use short REMB interval for this test
@@ -968,7 +1028,7 @@ func TestPhase2_RequirementsVerification(t *testing.T) {
 			},
 		}
 
-		clock := internal.NewMockClock(time.Now())
+		clock := clock.NewVirtual(time.Now())
 		e := NewBandwidthEstimator(config, clock)
 
 		// Verify initial bitrate is custom
@@ -980,7 +1040,7 @@ func TestPhase2_RequirementsVerification(t *testing.T) {
 	// CORE-02, CORE-03, CORE-04: OnPacket API and multi-SSRC
 	// =========================================================================
 	t.Run("CORE-02_03_04_PacketAPIAndMultiSSRC", func(t *testing.T) {
-		clock := internal.NewMockClock(time.Now())
+		clock := clock.NewVirtual(time.Now())
 		e := NewBandwidthEstimator(DefaultBandwidthEstimatorConfig(), clock)
 
 		videoSSRC := uint32(0xAAAAAAAA)
@@ -1032,7 +1092,7 @@ func TestPhase2_RequirementsVerification(t *testing.T) {
 	// RATE-01, RATE-02, RATE-03: AIMD rate controller
 	// =========================================================================
 	t.Run("RATE-01_02_03_AIMDController", func(t *testing.T) {
-		clock := internal.NewMockClock(time.Now())
+		clock := clock.NewVirtual(time.Now())
 		config := DefaultBandwidthEstimatorConfig()
 		e := NewBandwidthEstimator(config, clock)
 
@@ -1096,7 +1156,7 @@ func TestPhase2_RequirementsVerification(t *testing.T) {
 	// REMB-01, REMB-02, REMB-03, REMB-04: REMB packet generation
 	// =========================================================================
 	t.Run("REMB-01_02_03_04_REMBPackets", func(t *testing.T) {
-		clock := internal.NewMockClock(time.Now())
+		clock := clock.NewVirtual(time.Now())
 		e := NewBandwidthEstimator(DefaultBandwidthEstimatorConfig(), clock)
 
 		// REMB-03: Configurable REMB interval
@@ -1199,7 +1259,7 @@ func TestPhase2_RequirementsVerification(t *testing.T) {
 	// Final verification: All components working together
 	// =========================================================================
 	t.Run("FullIntegration", func(t *testing.T) {
-		clock := internal.NewMockClock(time.Now())
+		clock := clock.NewVirtual(time.Now())
 		e := NewBandwidthEstimator(DefaultBandwidthEstimatorConfig(), clock)
 		scheduler := NewREMBScheduler(DefaultREMBSchedulerConfig())
 		e.SetREMBScheduler(scheduler)
@@ -1256,7 +1316,7 @@ func TestPhase2_RequirementsVerification(t *testing.T) {
 
 func BenchmarkBandwidthEstimator_OnPacket(b *testing.B) {
 	config := DefaultBandwidthEstimatorConfig()
-	clock := internal.NewMockClock(time.Time{})
+	clock := clock.NewVirtual(time.Time{})
 	estimator := NewBandwidthEstimator(config, clock)
 
 	// Pre-generate packets
@@ -1272,7 +1332,7 @@ func BenchmarkBandwidthEstimator_OnPacket(b *testing.B) {
 	}
 
 	// Reset clock for benchmark
-	clock = internal.NewMockClock(time.Time{})
+	clock.Set(time.Unix(1000000000, 0))
 	estimator = NewBandwidthEstimator(config, clock)
 
 	b.ResetTimer()
@@ -57,7 +57,7 @@ import (
 	"testing"
 	"time"
 
-	"github.com/thesyncim/bwe/pkg/bwe/internal"
+	"github.com/thesyncim/bwe/pkg/bwe/clock"
 )
 
 // benchResult is a package-level variable to prevent compiler optimizations
@@ -78,7 +78,7 @@ func BenchmarkBandwidthEstimator_OnPacket_ZeroAlloc(b *testing.B) {
 	b.ReportAllocs()
 
 	config := DefaultBandwidthEstimatorConfig()
-	clock := internal.NewMockClock(time.Now())
+	clock := clock.NewVirtual(time.Now())
 	estimator := NewBandwidthEstimator(config, clock)
 
 	// Warmup: process enough packets to initialize internal state
@@ -126,7 +126,7 @@ func BenchmarkDelayEstimator_OnPacket_ZeroAlloc(b *testing.B) {
 	b.ReportAllocs()
 
 	config := DefaultDelayEstimatorConfig()
-	clock := internal.NewMockClock(time.Now())
+	clock := clock.NewVirtual(time.Now())
 	estimator := NewDelayEstimator(config, clock)
 
 	// Warmup
@@ -167,7 +167,7 @@ func BenchmarkDelayEstimator_Kalman_ZeroAlloc(b *testing.B) {
 
 	config := DefaultDelayEstimatorConfig()
 	config.FilterType = FilterKalman
-	clock := internal.NewMockClock(time.Now())
+	clock := clock.NewVirtual(time.Now())
 	estimator := NewDelayEstimator(config, clock)
 
 	// Warmup
@@ -208,7 +208,7 @@ func BenchmarkDelayEstimator_Trendline_ZeroAlloc(b *testing.B) {
 
 	config := DefaultDelayEstimatorConfig()
 	config.FilterType = FilterTrendline
-	clock := internal.NewMockClock(time.Now())
+	clock := clock.NewVirtual(time.Now())
 	estimator := NewDelayEstimator(config, clock)
 
 	// Warmup
@@ -353,7 +353,7 @@ func BenchmarkOveruseDetector_Detect_ZeroAlloc(b *testing.B) {
 	b.ReportAllocs()
 
 	config := DefaultOveruseConfig()
-	clock := internal.NewMockClock(time.Now())
+	clock := clock.NewVirtual(time.Now())
 	detector := NewOveruseDetector(config, clock)
 
 	// Warmup
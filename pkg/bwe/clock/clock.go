@@ -0,0 +1,92 @@
+// Package clock provides the time source used by the bwe packages.
+//
+// Everything that reads the time or waits for it goes through a Clock, so
+// the estimator and the interceptors can run against Monotonic, the system
+// clock, or against Virtual, a deterministic clock that only moves when the
+// caller advances it.
+package clock
+
+import "time"
+
+// Clock is a source of time and of timers and tickers driven by it.
+type Clock interface {
+	// Now returns the current time. Implementations must return
+	// monotonically increasing time values.
+	Now() time.Time
+
+	// NewTicker returns a Ticker that delivers the time on its channel
+	// every d. Panics if d is not positive.
+	NewTicker(d time.Duration) Ticker
+
+	// NewTimer returns a Timer that delivers the time on its channel once,
+	// after d.
+	NewTimer(d time.Duration) Timer
+}
+
+// Ticker delivers ticks at intervals, like time.Ticker.
+type Ticker interface {
+	// C returns the channel on which the ticks are delivered. The channel
+	// holds one tick; see the implementations for what happens while it
+	// is full.
+	C() <-chan time.Time
+
+	// Reset stops the ticker and resets its period to d. The next tick
+	// arrives after d.
+	Reset(d time.Duration)
+
+	// Stop turns the ticker off. No more ticks are sent after Stop.
+	Stop()
+}
+
+// Timer delivers a single event, like time.Timer.
+type Timer interface {
+	// C returns the channel on which the time is delivered.
+	C() <-chan time.Time
+
+	// Reset changes the timer to expire after d. It returns true if the
+	// timer had been active.
+	Reset(d time.Duration) bool
+
+	// Stop prevents the timer from firing. It returns true if the timer
+	// had been active.
+	Stop() bool
+}
+
+// Monotonic is a Clock backed by the system's monotonic clock and the time
+// package's timers. In Go, time.Now() includes monotonic clock readings,
+// making it safe for measuring elapsed time without wall-clock adjustments.
+type Monotonic struct{}
+
+// Now returns the current system time with monotonic clock reading.
+func (Monotonic) Now() time.Time {
+	return time.Now()
+}
+
+// NewTicker returns a Ticker backed by time.NewTicker. Ticks are dropped
+// while its channel is full.
+func (Monotonic) NewTicker(d time.Duration) Ticker {
+	return systemTicker{time.NewTicker(d)}
+}
+
+// NewTimer returns a Timer backed by time.NewTimer.
+func (Monotonic) NewTimer(d time.Duration) Timer {
+	return systemTimer{time.NewTimer(d)}
+}
+
+// systemTicker adapts time.Ticker to Ticker.
+type systemTicker struct {
+	t *time.Ticker
+}
+
+func (s systemTicker) C() <-chan time.Time   { return s.t.C }
+func (s systemTicker) Reset(d time.Duration) { s.t.Reset(d) }
+func (s systemTicker) Stop()                 { s.t.Stop() }
+
+// systemTimer adapts time.Timer to Timer.
+type systemTimer struct {
+	t *time.Timer
+}
+
+func (s systemTimer) C() <-chan time.Time        { return s.t.C }
+func (s systemTimer) Reset(d time.Duration) bool { return s.t.Reset(d) }
+func (s systemTimer) Stop() bool                 { return s.t.Stop() }
@@ -0,0 +1,244 @@
+package clock
+
+import (
+	"sync"
+	"time"
+)
+
+// Virtual is a deterministic Clock for tests and simulations. Its time only
+// moves when Advance or Set is called; timers and tickers that come due on
+// the way fire in deadline order, each seeing Now at its own deadline.
+// Deadline ties fire in the order the timers were created.
+//
+// Ticks go to a channel with room for one value. Unlike time.Ticker, a tick
+// that finds the channel full replaces the pending one, so a receiver that
+// falls behind, or a single long Advance, delivers the latest time rather
+// than a stale one. Advance in steps of the tick period, letting the
+// receiver catch up, to deliver every tick.
+//
+// Virtual is safe for concurrent use.
+type Virtual struct {
+	mu      sync.Mutex
+	added   *sync.Cond // Signalled when a timer or ticker is scheduled
+	now     time.Time
+	waiters []*waiter // Scheduled timers and tickers
+	seq     uint64
+}
+
+// waiter is a scheduled timer or ticker.
+type waiter struct {
+	c        chan time.Time
+	deadline time.Time
+	period   time.Duration // Zero for timers
+	seq      uint64        // Creation order, for deadline ties
+}
+
+// NewVirtual creates a new Virtual clock initialized to the given time.
+// If t is zero, it initializes to a reasonable default start time.
+func NewVirtual(t time.Time) *Virtual {
+	if t.IsZero() {
+		// Start at a reasonable time to avoid edge cases with zero time
+		t = time.Unix(1000000000, 0) // 2001-09-09
+	}
+	v := &Virtual{now: t}
+	v.added = sync.NewCond(&v.mu)
+	return v
+}
+
+// Now returns the virtual clock's current time.
+func (v *Virtual) Now() time.Time {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	return v.now
+}
+
+// Advance moves the clock forward by the given duration, firing the timers
+// and tickers that come due.
+// Panics if d is negative to maintain monotonicity.
+func (v *Virtual) Advance(d time.Duration) {
+	if d < 0 {
+		panic("clock: Virtual.Advance duration must be non-negative")
+	}
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	v.advanceTo(v.now.Add(d))
+}
+
+// Set sets the clock to the given time. Moving forward fires the timers and
+// tickers that come due, as Advance does; moving back fires nothing.
+func (v *Virtual) Set(t time.Time) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	if t.After(v.now) {
+		v.advanceTo(t)
+		return
+	}
+	v.now = t
+}
+
+// BlockUntil blocks until at least n timers and tickers are scheduled. Use
+// it to wait for a goroutine to create its ticker before advancing the
+// clock past the first tick.
+func (v *Virtual) BlockUntil(n int) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	for len(v.waiters) < n {
+		v.added.Wait()
+	}
+}
+
+// Pending returns the number of scheduled timers and tickers.
+func (v *Virtual) Pending() int {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	return len(v.waiters)
+}
+
+// NewTicker returns a Ticker driven by the virtual clock.
+func (v *Virtual) NewTicker(d time.Duration) Ticker {
+	if d <= 0 {
+		panic("clock: non-positive interval for NewTicker")
+	}
+	return &virtualTicker{v: v, w: v.schedule(d, d)}
+}
+
+// NewTimer returns a Timer driven by the virtual clock. A timer with d <= 0
+// fires on the next Advance or Set.
+func (v *Virtual) NewTimer(d time.Duration) Timer {
+	return &virtualTimer{v: v, w: v.schedule(max(d, 0), 0)}
+}
+
+// schedule creates a waiter due after d.
+func (v *Virtual) schedule(d, period time.Duration) *waiter {
+	w := &waiter{c: make(chan time.Time, 1), period: period}
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	v.add(w, d)
+	return w
+}
+
+// add schedules w after d. Must be called with mu held.
+func (v *Virtual) add(w *waiter, d time.Duration) {
+	v.seq++
+	w.deadline = v.now.Add(d)
+	w.seq = v.seq
+	v.waiters = append(v.waiters, w)
+	v.added.Broadcast()
+}
+
+// remove unschedules w and reports whether it was scheduled.
+// Must be called with mu held.
+func (v *Virtual) remove(w *waiter) bool {
+	for idx, other := range v.waiters {
+		if other == w {
+			v.waiters = append(v.waiters[:idx], v.waiters[idx+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// advanceTo fires every waiter due by t in deadline order and then sets the
+// time to t. Must be called with mu held.
+func (v *Virtual) advanceTo(t time.Time) {
+	for {
+		next := v.next()
+		if next == nil || next.deadline.After(t) {
+			break
+		}
+		v.now = next.deadline
+		deliver(next.c, v.now)
+		if next.period > 0 {
+			next.deadline = next.deadline.Add(next.period)
+		} else {
+			v.remove(next)
+		}
+	}
+	v.now = t
+}
+
+// deliver sends t on c, replacing a pending value the receiver has not
+// taken yet.
+func deliver(c chan time.Time, t time.Time) {
+	for {
+		select {
+		case c <- t:
+			return
+		default:
+		}
+		select {
+		case <-c: // Receiver is behind; drop the stale value
+		default:
+		}
+	}
+}
+
+// next returns the waiter with the earliest deadline, or nil.
+// Must be called with mu held.
+func (v *Virtual) next() *waiter {
+	var next *waiter
+	for _, w := range v.waiters {
+		if next == nil || w.deadline.Before(next.deadline) ||
+			(w.deadline.Equal(next.deadline) && w.seq < next.seq) {
+			next = w
+		}
+	}
+	return next
+}
+
+// virtualTicker is a Ticker driven by a Virtual clock.
+type virtualTicker struct {
+	v *Virtual
+	w *waiter
+}
+
+// C returns the channel on which the ticks are delivered.
+func (t *virtualTicker) C() <-chan time.Time {
+	return t.w.c
+}
+
+// Reset stops the ticker and resets its period to d.
+func (t *virtualTicker) Reset(d time.Duration) {
+	if d <= 0 {
+		panic("clock: non-positive interval for Ticker.Reset")
+	}
+	t.v.mu.Lock()
+	defer t.v.mu.Unlock()
+	t.v.remove(t.w)
+	t.w.period = d
+	t.v.add(t.w, d)
+}
+
+// Stop turns the ticker off.
+func (t *virtualTicker) Stop() {
+	t.v.mu.Lock()
+	defer t.v.mu.Unlock()
+	t.v.remove(t.w)
+}
+
+// virtualTimer is a Timer driven by a Virtual clock.
+type virtualTimer struct {
+	v *Virtual
+	w *waiter
+}
+
+// C returns the channel on which the time is delivered.
+func (t *virtualTimer) C() <-chan time.Time {
+	return t.w.c
+}
+
+// Reset changes the timer to expire after d.
+func (t *virtualTimer) Reset(d time.Duration) bool {
+	t.v.mu.Lock()
+	defer t.v.mu.Unlock()
+	active := t.v.remove(t.w)
+	t.v.add(t.w, max(d, 0))
+	return active
+}
+
+// Stop prevents the timer from firing.
+func (t *virtualTimer) Stop() bool {
+	t.v.mu.Lock()
+	defer t.v.mu.Unlock()
+	return t.v.remove(t.w)
+}
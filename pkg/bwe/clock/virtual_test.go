@@ -0,0 +1,193 @@
+package clock
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// receive returns the value waiting on c, failing if there is none.
+func receive(t *testing.T, c <-chan time.Time) time.Time {
+	t.Helper()
+	select {
+	case v := <-c:
+		return v
+	default:
+		require.Fail(t, "expected a value on the channel")
+		return time.Time{}
+	}
+}
+
+// assertEmpty fails if a value is waiting on c.
+func assertEmpty(t *testing.T, c <-chan time.Time) {
+	t.Helper()
+	select {
+	case v := <-c:
+		assert.Fail(t, "unexpected value on the channel", "got %v", v)
+	default:
+	}
+}
+
+func TestVirtual_DefaultStart(t *testing.T) {
+	v := NewVirtual(time.Time{})
+	assert.Equal(t, time.Unix(1000000000, 0), v.Now())
+
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	assert.Equal(t, start, NewVirtual(start).Now())
+}
+
+func TestVirtual_AdvanceAndSet(t *testing.T) {
+	v := NewVirtual(time.Time{})
+	start := v.Now()
+
+	v.Advance(1500 * time.Millisecond)
+	assert.Equal(t, start.Add(1500*time.Millisecond), v.Now())
+
+	v.Set(start)
+	assert.Equal(t, start, v.Now())
+
+	assert.Panics(t, func() { v.Advance(-time.Millisecond) })
+}
+
+func TestVirtual_TickerFiresAtDeadlines(t *testing.T) {
+	v := NewVirtual(time.Time{})
+	start := v.Now()
+	ticker := v.NewTicker(100 * time.Millisecond)
+	defer ticker.Stop()
+
+	v.Advance(99 * time.Millisecond)
+	assertEmpty(t, ticker.C())
+
+	for n := 1; n <= 3; n++ {
+		v.Advance(100 * time.Millisecond)
+		// Each tick carries its own deadline, not the time after Advance
+		assert.Equal(t, start.Add(time.Duration(n)*100*time.Millisecond), receive(t, ticker.C()))
+	}
+}
+
+func TestVirtual_TickerKeepsLatestWhileFull(t *testing.T) {
+	v := NewVirtual(time.Time{})
+	start := v.Now()
+	ticker := v.NewTicker(100 * time.Millisecond)
+	defer ticker.Stop()
+
+	v.Advance(time.Second)
+	assert.Equal(t, start.Add(time.Second), receive(t, ticker.C()))
+	assertEmpty(t, ticker.C())
+
+	// The ticker keeps its schedule after replacing ticks
+	v.Advance(100 * time.Millisecond)
+	assert.Equal(t, start.Add(1100*time.Millisecond), receive(t, ticker.C()))
+}
+
+func TestVirtual_TickerResetAndStop(t *testing.T) {
+	v := NewVirtual(time.Time{})
+	ticker := v.NewTicker(100 * time.Millisecond)
+
+	v.Advance(50 * time.Millisecond)
+	ticker.Reset(time.Second)
+	v.Advance(500 * time.Millisecond)
+	assertEmpty(t, ticker.C())
+	v.Advance(500 * time.Millisecond)
+	receive(t, ticker.C())
+
+	ticker.Stop()
+	assert.Equal(t, 0, v.Pending())
+	v.Advance(5 * time.Second)
+	assertEmpty(t, ticker.C())
+
+	assert.Panics(t, func() { v.NewTicker(0) })
+}
+
+func TestVirtual_Timer(t *testing.T) {
+	v := NewVirtual(time.Time{})
+	start := v.Now()
+	timer := v.NewTimer(time.Second)
+
+	v.Advance(2 * time.Second)
+	assert.Equal(t, start.Add(time.Second), receive(t, timer.C()))
+	assert.Equal(t, 0, v.Pending(), "a timer fires once")
+	assert.False(t, timer.Stop(), "Stop after firing")
+
+	assert.False(t, timer.Reset(time.Second), "Reset after firing")
+	assert.True(t, timer.Stop())
+	v.Advance(2 * time.Second)
+	assertEmpty(t, timer.C())
+}
+
+func TestVirtual_AdvanceFiresEachDeadline(t *testing.T) {
+	v := NewVirtual(time.Time{})
+	start := v.Now()
+
+	slow := v.NewTimer(300 * time.Millisecond)
+	fast := v.NewTicker(100 * time.Millisecond)
+	defer fast.Stop()
+	mid := v.NewTimer(200 * time.Millisecond)
+
+	// One Advance past every deadline fires each timer at its own time;
+	// the ticker's channel holds its latest tick
+	v.Advance(time.Second)
+	assert.Equal(t, start.Add(time.Second), receive(t, fast.C()))
+	assert.Equal(t, start.Add(200*time.Millisecond), receive(t, mid.C()))
+	assert.Equal(t, start.Add(300*time.Millisecond), receive(t, slow.C()))
+	assert.Equal(t, start.Add(time.Second), v.Now())
+	assert.Equal(t, 1, v.Pending(), "only the ticker stays scheduled")
+}
+
+func TestVirtual_SetForwardFiresTimers(t *testing.T) {
+	v := NewVirtual(time.Time{})
+	start := v.Now()
+	timer := v.NewTimer(time.Second)
+
+	v.Set(start.Add(-time.Second))
+	assertEmpty(t, timer.C())
+
+	v.Set(start.Add(time.Second))
+	assert.Equal(t, start.Add(time.Second), receive(t, timer.C()))
+}
+
+func TestVirtual_BlockUntil(t *testing.T) {
+	v := NewVirtual(time.Time{})
+	ticks := make(chan time.Time, 1)
+
+	go func() {
+		ticker := v.NewTicker(time.Second)
+		defer ticker.Stop()
+		ticks <- <-ticker.C()
+	}()
+
+	// Without BlockUntil the Advance could run before the ticker exists
+	v.BlockUntil(1)
+	v.Advance(time.Second)
+
+	select {
+	case tick := <-ticks:
+		assert.Equal(t, v.Now(), tick)
+	case <-time.After(time.Second):
+		require.Fail(t, "goroutine did not receive the tick")
+	}
+}
+
+func TestMonotonic(t *testing.T) {
+	var c Clock = Monotonic{}
+
+	before := time.Now()
+	assert.False(t, c.Now().Before(before))
+
+	timer := c.NewTimer(time.Millisecond)
+	select {
+	case <-timer.C():
+	case <-time.After(time.Second):
+		require.Fail(t, "timer did not fire")
+	}
+
+	ticker := c.NewTicker(time.Millisecond)
+	defer ticker.Stop()
+	select {
+	case <-ticker.C():
+	case <-time.After(time.Second):
+		require.Fail(t, "ticker did not fire")
+	}
+}
@@ -0,0 +1,209 @@
+package bwe
+
+import (
+	"math"
+	"time"
+)
+
+// ClockDriftConfig configures sender/receiver clock drift estimation.
+type ClockDriftConfig struct {
+	// Enabled turns drift estimation and compensation on. When disabled,
+	// delay samples reach the filter with any clock skew left in them.
+	// A queue that grows slower than MaxSkewPPM looks like skew, so
+	// compensation can hide slow congestion; enable it for long calls with
+	// senders whose clocks are known to drift.
+	// Default: false
+	Enabled bool
+
+	// BucketDuration is the arrival-time span over which the minimum
+	// one-way offset is taken. Queuing only adds delay, so the per-bucket
+	// minimum tracks the clock offset rather than the queue.
+	// Default: 1s
+	BucketDuration time.Duration
+
+	// Window is how much history the linear fit covers.
+	// Default: 10 minutes
+	Window time.Duration
+
+	// MinWindow is the history needed before the skew is used.
+	// Default: 60s
+	MinWindow time.Duration
+
+	// MaxSkewPPM bounds the estimated skew. Oscillators drift by tens of
+	// ppm; anything steeper is a growing queue, not a clock.
+	// Default: 500
+	MaxSkewPPM float64
+}
+
+// DefaultClockDriftConfig returns the default drift estimation configuration.
+func DefaultClockDriftConfig() ClockDriftConfig {
+	return ClockDriftConfig{
+		Enabled:        false,
+		BucketDuration: time.Second,
+		Window:         10 * time.Minute,
+		MinWindow:      60 * time.Second,
+		MaxSkewPPM:     500,
+	}
+}
+
+// driftBucket holds the lowest arrival-minus-send offset seen in one bucket.
+type driftBucket struct {
+	index   int64
+	arrival float64 // Seconds since origin, at the minimum
+	offset  float64 // Arrival minus send time in seconds, relative to the first packet
+}
+
+// ClockDriftEstimator estimates the skew between the sender's send-time
+// clock and the local arrival clock. Over a long horizon the one-way
+// offset (arrival time minus send time) grows linearly with the skew; the
+// estimator fits a line to the per-bucket minimum offsets over Window.
+//
+// A skew s makes every receive delta (1+s) times the send delta, so the
+// delay variation carries a bias of s * sendDelta, which Correction returns.
+type ClockDriftEstimator struct {
+	config ClockDriftConfig
+
+	// Unwrapped send timeline
+	origin     time.Time
+	lastStamp  sendStamp
+	sendOffset time.Duration
+	lastOffset time.Duration
+	started    bool
+
+	buckets []driftBucket
+	skew    float64
+	hasSkew bool
+}
+
+// NewClockDriftEstimator creates a new ClockDriftEstimator.
+// Unset durations default to 1s buckets over a 10 minute Window with a 60s
+// MinWindow, and MaxSkewPPM defaults to 500.
+func NewClockDriftEstimator(config ClockDriftConfig) *ClockDriftEstimator {
+	defaults := DefaultClockDriftConfig()
+	if config.BucketDuration <= 0 {
+		config.BucketDuration = defaults.BucketDuration
+	}
+	if config.Window <= 0 {
+		config.Window = defaults.Window
+	}
+	if config.MinWindow <= 0 {
+		config.MinWindow = defaults.MinWindow
+	}
+	if config.MaxSkewPPM <= 0 {
+		config.MaxSkewPPM = defaults.MaxSkewPPM
+	}
+	return &ClockDriftEstimator{config: config}
+}
+
+// Update records the arrival and send time of a packet. Packets should be
+// fed in arrival order; one per packet group is enough.
+func (d *ClockDriftEstimator) Update(pkt PacketInfo) {
+	stamp := stampOf(pkt)
+	if !d.started {
+		d.origin = pkt.ArrivalTime
+		d.lastStamp = stamp
+		d.started = true
+	}
+
+	if delta := d.lastStamp.until(stamp); delta > 0 {
+		d.sendOffset += delta
+		d.lastStamp = stamp
+	}
+	offset := pkt.ArrivalTime.Sub(d.origin) - d.sendOffset
+
+	// A clock jump or stream restart makes the history meaningless
+	if jump := offset - d.lastOffset; jump >= DefaultArrivalTimeOffsetThreshold || jump <= -DefaultArrivalTimeOffsetThreshold {
+		d.Reset()
+		d.Update(pkt)
+		return
+	}
+	d.lastOffset = offset
+
+	arrival := pkt.ArrivalTime.Sub(d.origin)
+	index := int64(arrival / d.config.BucketDuration)
+	sample := driftBucket{index: index, arrival: arrival.Seconds(), offset: offset.Seconds()}
+
+	n := len(d.buckets)
+	if n > 0 && d.buckets[n-1].index == index {
+		if sample.offset < d.buckets[n-1].offset {
+			d.buckets[n-1] = sample
+		}
+		return
+	}
+
+	// New bucket: the previous one is complete, refit
+	d.buckets = append(d.buckets, sample)
+	oldest := index - int64(d.config.Window/d.config.BucketDuration)
+	drop := 0
+	for drop < len(d.buckets) && d.buckets[drop].index < oldest {
+		drop++
+	}
+	if drop > 0 {
+		d.buckets = append(d.buckets[:0], d.buckets[drop:]...)
+	}
+	d.fit()
+}
+
+// fit computes the least-squares slope of the completed buckets' minimum
+// offsets against arrival time.
+func (d *ClockDriftEstimator) fit() {
+	complete := d.buckets[:len(d.buckets)-1]
+	if len(complete) < 2 {
+		return
+	}
+	span := complete[len(complete)-1].arrival - complete[0].arrival
+	if span < d.config.MinWindow.Seconds() {
+		return
+	}
+
+	var sumX, sumY float64
+	for _, b := range complete {
+		sumX += b.arrival
+		sumY += b.offset
+	}
+	n := float64(len(complete))
+	meanX, meanY := sumX/n, sumY/n
+
+	var num, den float64
+	for _, b := range complete {
+		dx := b.arrival - meanX
+		num += dx * (b.offset - meanY)
+		den += dx * dx
+	}
+	if den == 0 {
+		return
+	}
+
+	limit := d.config.MaxSkewPPM * 1e-6
+	d.skew = math.Max(-limit, math.Min(limit, num/den))
+	d.hasSkew = true
+}
+
+// Correction returns the part of a receive delta that is due to clock
+// skew, for a send delta. Subtract it from the delay variation.
+// Returns 0 until a skew estimate exists.
+func (d *ClockDriftEstimator) Correction(sendDelta time.Duration) time.Duration {
+	if !d.hasSkew {
+		return 0
+	}
+	return time.Duration(d.skew * float64(sendDelta))
+}
+
+// SkewPPM returns the estimated skew in parts per million. Positive means
+// the arrival clock runs fast relative to the sender's clock.
+// Returns (skew, true) once MinWindow of history exists, (0, false) before.
+func (d *ClockDriftEstimator) SkewPPM() (float64, bool) {
+	return d.skew * 1e6, d.hasSkew
+}
+
+// Reset clears the drift estimator state.
+func (d *ClockDriftEstimator) Reset() {
+	d.origin = time.Time{}
+	d.lastStamp = sendStamp{}
+	d.sendOffset = 0
+	d.lastOffset = 0
+	d.started = false
+	d.buckets = d.buckets[:0]
+	d.skew = 0
+	d.hasSkew = false
+}
@@ -0,0 +1,192 @@
+package bwe
+
+import (
+	"math/rand"
+	"testing"
+	"time"
+
+	"github.com/thesyncim/bwe/pkg/bwe/clock"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// skewedPacket returns a packet sent at send whose arrival clock runs
+// ppm faster than the sender's, plus jitter of queuing delay.
+func skewedPacket(start time.Time, send time.Duration, ppm float64, jitter time.Duration) PacketInfo {
+	return PacketInfo{
+		ArrivalTime: start.Add(time.Duration(float64(send)*(1+ppm*1e-6)) + jitter),
+		SendTime:    DurationToAbsSendTime(send),
+		Size:        1200,
+		SSRC:        1,
+	}
+}
+
+func TestClockDriftEstimator_EstimatesSkew(t *testing.T) {
+	for _, ppm := range []float64{-200, 50, 200} {
+		d := NewClockDriftEstimator(DefaultClockDriftConfig())
+		start := time.Unix(0, 0)
+		rng := rand.New(rand.NewSource(1))
+
+		for send := time.Duration(0); send < 5*time.Minute; send += 20 * time.Millisecond {
+			if send == 30*time.Second {
+				_, ok := d.SkewPPM()
+				assert.False(t, ok, "no estimate before MinWindow")
+			}
+			d.Update(skewedPacket(start, send, ppm, time.Duration(rng.Int63n(int64(5*time.Millisecond)))))
+		}
+
+		skew, ok := d.SkewPPM()
+		require.True(t, ok)
+		assert.InDelta(t, ppm, skew, 2, "skew estimate")
+		assert.InDelta(t, ppm*1e-6*float64(100*time.Millisecond), float64(d.Correction(100*time.Millisecond)), float64(time.Microsecond))
+	}
+}
+
+func TestClockDriftEstimator_IgnoresQueuingEpisode(t *testing.T) {
+	d := NewClockDriftEstimator(DefaultClockDriftConfig())
+	start := time.Unix(0, 0)
+
+	for send := time.Duration(0); send < 5*time.Minute; send += 20 * time.Millisecond {
+		// A queue builds to 200ms between minute 2 and 3, then drains
+		var queue time.Duration
+		if send > 2*time.Minute && send < 3*time.Minute {
+			queue = (send - 2*time.Minute) / 300
+		}
+		d.Update(skewedPacket(start, send, 100, queue))
+	}
+
+	skew, ok := d.SkewPPM()
+	require.True(t, ok)
+	assert.InDelta(t, 100, skew, 60, "a transient queue should barely move the estimate")
+}
+
+func TestClockDriftEstimator_ClampsAndResets(t *testing.T) {
+	d := NewClockDriftEstimator(DefaultClockDriftConfig())
+	start := time.Unix(0, 0)
+
+	// A steadily growing queue is not a clock: the skew is bounded
+	var send time.Duration
+	for ; send < 2*time.Minute; send += 20 * time.Millisecond {
+		d.Update(skewedPacket(start, send, 5000, 0))
+	}
+	skew, ok := d.SkewPPM()
+	require.True(t, ok)
+	assert.Equal(t, 500.0, skew)
+
+	// A clock jump discards the history
+	d.Update(skewedPacket(start.Add(time.Hour), send, 0, 0))
+	_, ok = d.SkewPPM()
+	assert.False(t, ok)
+	assert.Zero(t, d.Correction(time.Second))
+}
+
+// TestClockDrift_QueueRampTriggersOveruse grows a queue at 300ppm, 0.3ms per
+// second, from minute 5 to 15, sending one group a second with the detector
+// threshold lowered as in TestClockDrift_24HourStability. With the default
+// config the ramp reads as overuse until it ends. Compensation, once opted
+// in, fits the ramp as a 300ppm skew and stops signalling it.
+func TestClockDrift_QueueRampTriggersOveruse(t *testing.T) {
+	assert.False(t, DefaultClockDriftConfig().Enabled, "drift compensation is opt-in")
+
+	for _, compensate := range []bool{false, true} {
+		start := time.Unix(0, 0)
+		clock := clock.NewVirtual(start)
+		config := DefaultBandwidthEstimatorConfig()
+		config.DelayConfig.OveruseConfig.InitialThreshold = 0.2
+		config.DelayConfig.OveruseConfig.MinThreshold = 0.2
+		config.DelayConfig.DriftConfig.Enabled = compensate
+		e := NewBandwidthEstimator(config, clock)
+		rng := rand.New(rand.NewSource(1))
+
+		var early, late int
+		for send := time.Duration(0); send < 15*time.Minute; send += time.Second {
+			var queue time.Duration
+			if send > 5*time.Minute {
+				queue = time.Duration(float64(send-5*time.Minute) * 300e-6)
+			}
+			pkt := skewedPacket(start, send, 0, queue+time.Duration(rng.Int63n(int64(200*time.Microsecond))))
+			clock.Set(pkt.ArrivalTime)
+			e.OnPacket(pkt)
+
+			if e.GetCongestionState() == BwOverusing {
+				if send < 10*time.Minute {
+					early++
+				} else {
+					late++
+				}
+			}
+		}
+
+		assert.Positive(t, early, "compensate=%v: the ramp should be detected", compensate)
+		if !compensate {
+			assert.Positive(t, late, "the ramp should keep reading as overuse")
+			_, ok := e.GetClockSkewPPM()
+			assert.False(t, ok, "no skew is estimated by default")
+			continue
+		}
+		assert.Zero(t, late, "compensation absorbs a slow ramp")
+		skew, ok := e.GetClockSkewPPM()
+		require.True(t, ok)
+		assert.InDelta(t, 300, skew, 10, "the ramp is read as clock skew")
+	}
+}
+
+// TestClockDrift_24HourStability runs day-long calls whose sender clock is
+// 400ppm fast or slow, with jittered queuing, with and without drift
+// compensation. Skew adds ppm × send delta to each delay variation, 0.4ms
+// for groups sent every second, so the Kalman detector's threshold is
+// lowered to 0.2ms to let it through. Without compensation the skew reads as
+// overuse or underuse; with it, the skew is tracked and the estimate stays
+// steady with no congestion signal.
+func TestClockDrift_24HourStability(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping 24-hour drift test in short mode")
+	}
+
+	const interval = time.Second
+
+	for _, ppm := range []float64{-400, 400} {
+		for _, compensate := range []bool{false, true} {
+			start := time.Unix(0, 0)
+			clock := clock.NewVirtual(start)
+			config := DefaultBandwidthEstimatorConfig()
+			config.DelayConfig.OveruseConfig.InitialThreshold = 0.2
+			config.DelayConfig.OveruseConfig.MinThreshold = 0.2
+			config.DelayConfig.DriftConfig.Enabled = compensate
+			e := NewBandwidthEstimator(config, clock)
+			rng := rand.New(rand.NewSource(1))
+
+			var (
+				congested int
+				hourly    []int64
+			)
+			for send := time.Duration(0); send < 24*time.Hour; send += interval {
+				pkt := skewedPacket(start, send, ppm, time.Duration(rng.Int63n(int64(200*time.Microsecond))))
+				clock.Set(pkt.ArrivalTime)
+				e.OnPacket(pkt)
+
+				if send > 10*time.Minute && e.GetCongestionState() != BwNormal {
+					congested++
+				}
+				if send > 0 && send%time.Hour == 0 {
+					hourly = append(hourly, e.GetEstimate())
+					if compensate {
+						skew, ok := e.GetClockSkewPPM()
+						require.True(t, ok)
+						require.InDelta(t, ppm, skew, 5, "%vppm: skew estimate at %v", ppm, send)
+					}
+				}
+			}
+
+			if !compensate {
+				assert.Positive(t, congested, "%vppm: uncompensated drift should read as congestion", ppm)
+				continue
+			}
+			assert.Zero(t, congested, "%vppm: clock drift should not read as congestion", ppm)
+			for _, est := range hourly[1:] {
+				assert.InEpsilon(t, hourly[0], est, 0.01, "%vppm: estimate should stay steady for 24h", ppm)
+			}
+		}
+	}
+}
@@ -5,7 +5,7 @@ package bwe
 import (
 	"time"
 
-	"github.com/thesyncim/bwe/pkg/bwe/internal"
+	"github.com/thesyncim/bwe/pkg/bwe/clock"
 )
 
 // FilterType specifies which delay filter to use in the delay estimator.
@@ -19,6 +19,11 @@ const (
 	// FilterTrendline uses linear regression trendline estimation.
 	// This is the modern approach used in WebRTC reference implementations.
 	FilterTrendline
+
+	// FilterKalmanCapacity uses the two-state Kalman filter of the original
+	// GCC draft, which estimates the link capacity alongside the delay
+	// gradient from group size differences.
+	FilterKalmanCapacity
 )
 
 // DelayEstimatorConfig holds configuration for the delay-based bandwidth estimator.
@@ -30,35 +35,76 @@ type DelayEstimatorConfig struct {
 	// Packets arriving within this duration are considered part of the same burst.
 	BurstThreshold time.Duration
 
+	// GroupingMode selects how packets are grouped: by arrival time, by
+	// abs-send-time, by send time with libwebrtc's burst rule, or by video
+	// frame (RTP timestamp and marker bit).
+	// Default: GroupByArrival
+	GroupingMode GroupingMode
+
+	// MaxBurstDuration bounds how long a group may grow under the libwebrtc
+	// burst rule. Only used with GroupLibWebRTC.
+	// Default: 100ms
+	MaxBurstDuration time.Duration
+
+	// MaxFrameDuration bounds the send-time span of a frame group. Only
+	// used with GroupByFrame.
+	// Default: 500ms
+	MaxFrameDuration time.Duration
+
+	// ArrivalTimeOffsetThreshold is the delay variation between groups at
+	// which a clock is considered to have jumped and grouping restarts.
+	// Default: 3s
+	ArrivalTimeOffsetThreshold time.Duration
+
+	// ReorderedResetThreshold is the number of consecutive negative arrival
+	// deltas between groups after which grouping restarts.
+	// Default: 3
+	ReorderedResetThreshold int
+
 	// KalmanConfig is used if FilterType == FilterKalman.
 	KalmanConfig KalmanConfig
 
 	// TrendlineConfig is used if FilterType == FilterTrendline.
 	TrendlineConfig TrendlineConfig
 
+	// CapacityKalmanConfig is used if FilterType == FilterKalmanCapacity.
+	CapacityKalmanConfig CapacityKalmanConfig
+
 	// OveruseConfig configures the overuse detector behavior.
 	OveruseConfig OveruseConfig
+
+	// DriftConfig configures clock skew removal before the delay filter.
+	DriftConfig ClockDriftConfig
 }
 
 // DefaultDelayEstimatorConfig returns the default configuration for the delay estimator.
 // Uses Kalman filter (traditional approach) with standard burst threshold.
 func DefaultDelayEstimatorConfig() DelayEstimatorConfig {
 	return DelayEstimatorConfig{
-		FilterType:      FilterKalman, // Kalman is traditional, Trendline is modern
-		BurstThreshold:  5 * time.Millisecond,
-		KalmanConfig:    DefaultKalmanConfig(),
-		TrendlineConfig: DefaultTrendlineConfig(),
-		OveruseConfig:   DefaultOveruseConfig(),
+		FilterType:           FilterKalman, // Kalman is traditional, Trendline is modern
+		BurstThreshold:       5 * time.Millisecond,
+		GroupingMode:         GroupByArrival,
+		MaxBurstDuration:     DefaultMaxBurstDuration,
+		MaxFrameDuration:     DefaultMaxFrameDuration,
+		KalmanConfig:         DefaultKalmanConfig(),
+		TrendlineConfig:      DefaultTrendlineConfig(),
+		CapacityKalmanConfig: DefaultCapacityKalmanConfig(),
+		OveruseConfig:        DefaultOveruseConfig(),
+		DriftConfig:          DefaultClockDriftConfig(),
+
+		ArrivalTimeOffsetThreshold: DefaultArrivalTimeOffsetThreshold,
+		ReorderedResetThreshold:    DefaultReorderedResetThreshold,
 	}
 }
 
 // delayFilter is an internal interface abstracting Kalman and Trendline filters.
-// Both filters take delay variation samples and produce smoothed estimates.
+// All filters take delay variation samples and produce smoothed estimates.
 type delayFilter interface {
 	// Update processes a new delay variation sample and returns the filtered estimate.
 	// arrivalTime is the packet arrival time (used by trendline, ignored by Kalman).
 	// delayMs is the delay variation in milliseconds.
-	Update(arrivalTime time.Time, delayMs float64) float64
+	// sizeDelta is the group size difference in bytes (used by the two-state Kalman).
+	Update(arrivalTime time.Time, delayMs float64, sizeDelta int) float64
 
 	// Reset clears the filter state to initial conditions.
 	Reset()
@@ -72,7 +118,7 @@ type kalmanAdapter struct {
 
 // Update processes a delay sample through the Kalman filter.
 // The arrivalTime parameter is ignored by Kalman filtering.
-func (k *kalmanAdapter) Update(arrivalTime time.Time, delayMs float64) float64 {
+func (k *kalmanAdapter) Update(arrivalTime time.Time, delayMs float64, sizeDelta int) float64 {
 	return k.filter.Update(delayMs)
 }
 
@@ -88,7 +134,7 @@ type trendlineAdapter struct {
 }
 
 // Update processes a delay sample through the trendline estimator.
-func (t *trendlineAdapter) Update(arrivalTime time.Time, delayMs float64) float64 {
+func (t *trendlineAdapter) Update(arrivalTime time.Time, delayMs float64, sizeDelta int) float64 {
 	return t.estimator.Update(arrivalTime, delayMs)
 }
 
@@ -97,30 +143,75 @@ func (t *trendlineAdapter) Reset() {
 	t.estimator.Reset()
 }
 
+// capacityKalmanAdapter adapts CapacityKalmanFilter to the delayFilter interface.
+// The filter adapts its noise estimate only in the normal state, so the
+// adapter reads the current state from the overuse detector.
+type capacityKalmanAdapter struct {
+	filter   *CapacityKalmanFilter
+	detector *OveruseDetector
+}
+
+// Update processes a delay sample and group size difference through the
+// two-state Kalman filter.
+func (k *capacityKalmanAdapter) Update(arrivalTime time.Time, delayMs float64, sizeDelta int) float64 {
+	return k.filter.Update(delayMs, sizeDelta, k.detector.State())
+}
+
+// Reset clears the two-state Kalman filter state.
+func (k *capacityKalmanAdapter) Reset() {
+	k.filter.Reset()
+}
+
 // DelayEstimator orchestrates the complete delay-based bandwidth estimation pipeline.
 // It combines:
 //   - InterArrivalCalculator for burst grouping and delay variation measurement
-//   - Kalman or Trendline filter for noise reduction
+//   - Kalman, Trendline or two-state Kalman filter for noise reduction
 //   - OveruseDetector for congestion state detection
+//   - ClockDriftEstimator to remove sender/receiver clock skew (optional)
 //
 // The estimator processes packets via OnPacket() and produces BandwidthUsage signals.
 type DelayEstimator struct {
 	config       DelayEstimatorConfig
-	clock        internal.Clock
+	clock        clock.Clock
 	interarrival *InterArrivalCalculator
 	filter       delayFilter
 	detector     *OveruseDetector
+
+	// Set in libwebrtc-parity trendline mode, replacing filter and detector
+	parity *LibWebRTCTrendlineEstimator
+
+	// Clock skew estimator, nil if DriftConfig is disabled
+	drift *ClockDriftEstimator
+
+	// Most recent filter output in ms (for monitoring)
+	lastEstimate float64
+
+	// Packet groups completed, kept across Reset (for monitoring)
+	groups int64
+
+	// Receives group, filter and threshold events; nil if none
+	observer Observer
 }
 
 // NewDelayEstimator creates a new DelayEstimator with the given configuration.
-// If clock is nil, a default MonotonicClock is used.
-func NewDelayEstimator(config DelayEstimatorConfig, clock internal.Clock) *DelayEstimator {
-	if clock == nil {
-		clock = internal.MonotonicClock{}
+// If clk is nil, clock.Monotonic is used.
+func NewDelayEstimator(config DelayEstimatorConfig, clk clock.Clock) *DelayEstimator {
+	if clk == nil {
+		clk = clock.Monotonic{}
 	}
 
-	// Create the inter-arrival calculator with burst threshold
-	interarrival := NewInterArrivalCalculator(config.BurstThreshold)
+	// Create the inter-arrival calculator with the grouping configuration
+	interarrival := NewInterArrivalCalculatorWithConfig(InterArrivalConfig{
+		BurstThreshold:             config.BurstThreshold,
+		GroupingMode:               config.GroupingMode,
+		MaxBurstDuration:           config.MaxBurstDuration,
+		MaxFrameDuration:           config.MaxFrameDuration,
+		ArrivalTimeOffsetThreshold: config.ArrivalTimeOffsetThreshold,
+		ReorderedResetThreshold:    config.ReorderedResetThreshold,
+	})
+
+	// Create the overuse detector
+	detector := NewOveruseDetector(config.OveruseConfig, clk)
 
 	// Create the appropriate filter based on configuration
 	var filter delayFilter
@@ -129,22 +220,31 @@ func NewDelayEstimator(config DelayEstimatorConfig, clock internal.Clock) *Delay
 		filter = &trendlineAdapter{
 			estimator: NewTrendlineEstimator(config.TrendlineConfig),
 		}
+	case FilterKalmanCapacity:
+		filter = &capacityKalmanAdapter{
+			filter:   NewCapacityKalmanFilter(config.CapacityKalmanConfig),
+			detector: detector,
+		}
 	default: // FilterKalman
 		filter = &kalmanAdapter{
 			filter: NewKalmanFilter(config.KalmanConfig),
 		}
 	}
 
-	// Create the overuse detector
-	detector := NewOveruseDetector(config.OveruseConfig, clock)
-
-	return &DelayEstimator{
+	e := &DelayEstimator{
 		config:       config,
-		clock:        clock,
+		clock:        clk,
 		interarrival: interarrival,
 		filter:       filter,
 		detector:     detector,
 	}
+	if config.FilterType == FilterTrendline && config.TrendlineConfig.LibWebRTCParity {
+		e.parity = NewLibWebRTCTrendlineEstimator(config.TrendlineConfig)
+	}
+	if config.DriftConfig.Enabled {
+		e.drift = NewClockDriftEstimator(config.DriftConfig)
+	}
+	return e
 }
 
 // OnPacket processes a received packet and returns the current bandwidth usage state.
@@ -153,8 +253,9 @@ func NewDelayEstimator(config DelayEstimatorConfig, clock internal.Clock) *Delay
 // The pipeline:
 //  1. Groups packet into bursts using InterArrivalCalculator
 //  2. Computes delay variation between burst groups
-//  3. Filters the delay variation (Kalman or Trendline)
-//  4. Detects overuse/underuse via OveruseDetector
+//  3. Removes the clock skew estimated by ClockDriftEstimator
+//  4. Filters the delay variation (Kalman or Trendline)
+//  5. Detects overuse/underuse via OveruseDetector
 //
 // Returns the current BandwidthUsage state (Normal, Underusing, or Overusing).
 func (e *DelayEstimator) OnPacket(pkt PacketInfo) BandwidthUsage {
@@ -162,29 +263,131 @@ func (e *DelayEstimator) OnPacket(pkt PacketInfo) BandwidthUsage {
 	delayVariation, hasResult := e.interarrival.AddPacket(pkt)
 	if !hasResult {
 		// Still accumulating group, return current state
-		return e.detector.State()
+		return e.State()
+	}
+	e.groups++
+
+	if e.drift != nil {
+		e.drift.Update(pkt)
+		sendDelta := e.interarrival.PreviousGroup().lastSend().until(stampOf(pkt))
+		delayVariation -= e.drift.Correction(sendDelta)
 	}
 
+	if e.parity != nil {
+		return e.onGroupDeltaParity(pkt.ArrivalTime)
+	}
+
+	// The two-state Kalman filter models group sizes, so it is fed the
+	// delta between completed groups instead
+	sizeDelta := 0
+	if e.config.FilterType == FilterKalmanCapacity {
+		delta, ok := e.interarrival.LastGroupDelta()
+		if !ok {
+			return e.detector.State()
+		}
+		delayVariation, sizeDelta = e.compensate(delta).DelayVariation(), delta.SizeDelta
+	}
+	e.observeGroup(pkt.ArrivalTime, delayVariation)
+
 	// Convert delay variation to milliseconds for filter
 	delayMs := float64(delayVariation.Microseconds()) / 1000.0
 
-	// Feed to filter (Kalman or Trendline)
-	estimate := e.filter.Update(pkt.ArrivalTime, delayMs)
+	// Feed to filter (Kalman, Trendline or two-state Kalman)
+	estimate := e.filter.Update(pkt.ArrivalTime, delayMs, sizeDelta)
+	e.lastEstimate = estimate
 
 	// Feed estimate to overuse detector
-	return e.detector.Detect(estimate)
+	usage := e.detector.Detect(estimate)
+	e.observeDetection(pkt.ArrivalTime)
+	return usage
+}
+
+// onGroupDeltaParity feeds the delta between the last two completed groups
+// to the libwebrtc-parity trendline estimator, as libwebrtc's DelayBasedBwe
+// does each time a packet starts a new group.
+func (e *DelayEstimator) onGroupDeltaParity(arrivalTime time.Time) BandwidthUsage {
+	delta, ok := e.interarrival.LastGroupDelta()
+	if !ok {
+		return e.parity.State()
+	}
+	delta = e.compensate(delta)
+	e.observeGroup(arrivalTime, delta.DelayVariation())
+
+	recvMs := float64(delta.ReceiveDelta) / float64(time.Millisecond)
+	sendMs := float64(delta.SendDelta) / float64(time.Millisecond)
+	usage := e.parity.Update(recvMs, sendMs, arrivalTime)
+	e.lastEstimate = e.parity.ModifiedTrend()
+	e.observeDetection(arrivalTime)
+	return usage
+}
+
+// compensate removes the estimated clock skew from a group delta's receive delta.
+func (e *DelayEstimator) compensate(delta GroupDelta) GroupDelta {
+	if e.drift != nil {
+		delta.ReceiveDelta -= e.drift.Correction(delta.SendDelta)
+	}
+	return delta
+}
+
+// ClockSkewPPM returns the estimated sender/receiver clock skew in parts
+// per million. Returns (skew, true) once enough history exists, (0, false)
+// before or when drift compensation is disabled.
+func (e *DelayEstimator) ClockSkewPPM() (float64, bool) {
+	if e.drift == nil {
+		return 0, false
+	}
+	return e.drift.SkewPPM()
 }
 
 // State returns the current bandwidth usage state without processing a packet.
 // This is useful for querying state between packet arrivals.
 func (e *DelayEstimator) State() BandwidthUsage {
+	if e.parity != nil {
+		return e.parity.State()
+	}
 	return e.detector.State()
 }
 
+// FilterOutput returns the most recent output of the delay filter in milliseconds.
+// For the Kalman filter this is the estimated queuing delay gradient; for the
+// trendline filter it is the scaled trendline slope (the modified trend in
+// libwebrtc-parity mode). This is primarily useful for debugging and monitoring.
+func (e *DelayEstimator) FilterOutput() float64 {
+	return e.lastEstimate
+}
+
+// Threshold returns the current adaptive overuse threshold in milliseconds.
+func (e *DelayEstimator) Threshold() float64 {
+	if e.parity != nil {
+		return e.parity.Threshold()
+	}
+	return e.detector.Threshold()
+}
+
+// KalmanCapacity returns the link capacity estimated by the two-state Kalman
+// filter, in bits per second. Returns (capacity, true) if FilterType is
+// FilterKalmanCapacity and the filter has a positive slope, (0, false) otherwise.
+func (e *DelayEstimator) KalmanCapacity() (int64, bool) {
+	k, ok := e.filter.(*capacityKalmanAdapter)
+	if !ok {
+		return 0, false
+	}
+	return k.filter.Capacity()
+}
+
+// InterArrivalStats returns the counters of packets the inter-arrival
+// calculator discarded for reordering and of the resets it performed.
+func (e *DelayEstimator) InterArrivalStats() InterArrivalStats {
+	return e.interarrival.Stats()
+}
+
 // SetCallback registers a callback that will be invoked when bandwidth usage
 // state changes. Pass nil to disable callbacks.
 func (e *DelayEstimator) SetCallback(cb StateChangeCallback) {
 	e.detector.SetCallback(cb)
+	if e.parity != nil {
+		e.parity.SetCallback(cb)
+	}
 }
 
 // Reset resets all components to their initial state.
@@ -193,4 +396,11 @@ func (e *DelayEstimator) Reset() {
 	e.interarrival.Reset()
 	e.filter.Reset()
 	e.detector.Reset()
+	if e.parity != nil {
+		e.parity.Reset()
+	}
+	if e.drift != nil {
+		e.drift.Reset()
+	}
+	e.lastEstimate = 0
 }
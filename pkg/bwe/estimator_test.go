@@ -5,7 +5,7 @@ import (
 	"testing"
 	"time"
 
-	"github.com/thesyncim/bwe/pkg/bwe/internal"
+	"github.com/thesyncim/bwe/pkg/bwe/clock"
 )
 
 // =============================================================================
@@ -14,7 +14,7 @@ import (
 
 // stableNetworkTrace generates packets with constant delay (no congestion).
 // Packets arrive at the same rate they were sent.
-func stableNetworkTrace(clock *internal.MockClock, count int, intervalMs int) []PacketInfo {
+func stableNetworkTrace(clock *clock.Virtual, count int, intervalMs int) []PacketInfo {
 	packets := make([]PacketInfo, count)
 	sendTime := uint32(0)
 
@@ -35,7 +35,7 @@ func stableNetworkTrace(clock *internal.MockClock, count int, intervalMs int) []
 
 // congestingNetworkTrace generates packets where receive delay increases.
 // Simulates queue building: each packet arrives slightly later than expected.
-func congestingNetworkTrace(clock *internal.MockClock, count int, intervalMs int, delayIncreaseMs float64) []PacketInfo {
+func congestingNetworkTrace(clock *clock.Virtual, count int, intervalMs int, delayIncreaseMs float64) []PacketInfo {
 	packets := make([]PacketInfo, count)
 	sendTime := uint32(0)
 
@@ -56,7 +56,7 @@ func congestingNetworkTrace(clock *internal.MockClock, count int, intervalMs int
 
 // drainingNetworkTrace generates packets where receive delay decreases.
 // Simulates queue draining: packets arrive faster than expected.
-func drainingNetworkTrace(clock *internal.MockClock, count int, intervalMs int, delayDecreaseMs float64) []PacketInfo {
+func drainingNetworkTrace(clock *clock.Virtual, count int, intervalMs int, delayDecreaseMs float64) []PacketInfo {
 	packets := make([]PacketInfo, count)
 	sendTime := uint32(0)
 
@@ -80,7 +80,7 @@ func drainingNetworkTrace(clock *internal.MockClock, count int, intervalMs int,
 }
 
 // wraparoundTrace generates packets that exercise 24-bit abs-send-time wraparound.
-func wraparoundTrace(clock *internal.MockClock, count int) []PacketInfo {
+func wraparoundTrace(clock *clock.Virtual, count int) []PacketInfo {
 	packets := make([]PacketInfo, count)
 	// Start near max (64 second mark), generate packets across wrap
 	sendTime := uint32(AbsSendTimeMax - 100*20*262)
@@ -100,7 +100,7 @@ func wraparoundTrace(clock *internal.MockClock, count int) []PacketInfo {
 }
 
 // burstTrace generates packets in bursts that should be grouped together.
-func burstTrace(clock *internal.MockClock, burstCount, packetsPerBurst, interBurstMs, intraBurstMs int) []PacketInfo {
+func burstTrace(clock *clock.Virtual, burstCount, packetsPerBurst, interBurstMs, intraBurstMs int) []PacketInfo {
 	packets := make([]PacketInfo, burstCount*packetsPerBurst)
 	sendTime := uint32(0)
 	idx := 0
@@ -136,7 +136,7 @@ func burstTrace(clock *internal.MockClock, burstCount, packetsPerBurst, interBur
 
 func TestDelayEstimator_StableNetwork(t *testing.T) {
 	// Stable network: no congestion, state should remain BwNormal
-	clock := internal.NewMockClock(time.Time{})
+	clock := clock.NewVirtual(time.Time{})
 	config := DefaultDelayEstimatorConfig()
 	estimator := NewDelayEstimator(config, clock)
 
@@ -178,7 +178,7 @@ func TestDelayEstimator_CongestingNetwork(t *testing.T) {
 	// Note: Kalman filter converges slowly (~500 iterations for full convergence)
 	// and the initial threshold is 12.5ms, so we need the filtered estimate to exceed that.
 	// With 50ms delay variation, Kalman will converge toward that value and cross the threshold.
-	clock := internal.NewMockClock(time.Time{})
+	clock := clock.NewVirtual(time.Time{})
 	config := DefaultDelayEstimatorConfig()
 	estimator := NewDelayEstimator(config, clock)
 
@@ -222,7 +222,7 @@ func TestDelayEstimator_DrainingNetwork(t *testing.T) {
 	//
 	// Key constraint: arrival gaps must be > 5ms (burst threshold) to create separate groups
 	// So we use longer intervals and smaller delay decrease to maintain > 5ms arrival gaps
-	clock := internal.NewMockClock(time.Time{})
+	clock := clock.NewVirtual(time.Time{})
 	config := DefaultDelayEstimatorConfig()
 	estimator := NewDelayEstimator(config, clock)
 
@@ -262,7 +262,7 @@ func TestDelayEstimator_DrainingNetwork(t *testing.T) {
 
 func TestDelayEstimator_RecoveryFromCongestion(t *testing.T) {
 	// Test recovery: congesting -> stable -> should return to normal
-	clock := internal.NewMockClock(time.Time{})
+	clock := clock.NewVirtual(time.Time{})
 	config := DefaultDelayEstimatorConfig()
 	estimator := NewDelayEstimator(config, clock)
 
@@ -289,7 +289,7 @@ func TestDelayEstimator_RecoveryFromCongestion(t *testing.T) {
 
 func TestDelayEstimator_WraparoundHandling(t *testing.T) {
 	// Wraparound: timestamps crossing 64-second boundary should not cause issues
-	clock := internal.NewMockClock(time.Time{})
+	clock := clock.NewVirtual(time.Time{})
 	config := DefaultDelayEstimatorConfig()
 	estimator := NewDelayEstimator(config, clock)
 
@@ -326,7 +326,7 @@ func TestDelayEstimator_WithTrendlineFilter(t *testing.T) {
 	// Test with Trendline filter produces consistent state
 	// Trendline detects TRENDS - measuring rate of change in delay over time
 	// This test verifies that Trendline integration works correctly
-	clock := internal.NewMockClock(time.Time{})
+	clock := clock.NewVirtual(time.Time{})
 	config := DefaultDelayEstimatorConfig()
 	config.FilterType = FilterTrendline
 	estimator := NewDelayEstimator(config, clock)
@@ -362,10 +362,11 @@ func TestDelayEstimator_WithTrendlineFilter(t *testing.T) {
 
 func TestDelayEstimator_MonotonicTimeUsage(t *testing.T) {
 	// Verify that the estimator uses monotonic time correctly
-	// MockClock panics if we try to go backward, so this test ensures
+	// The virtual clock panics if we try to go backward, so this test ensures
 	// all time operations are forward-only
 
-	clock := internal.NewMockClock(time.Time{})
+	clock2 := clock.NewVirtual(time.Time{})
+	clock := clock.NewVirtual(time.Time{})
 	config := DefaultDelayEstimatorConfig()
 	estimator := NewDelayEstimator(config, clock)
 
@@ -374,7 +375,7 @@ func TestDelayEstimator_MonotonicTimeUsage(t *testing.T) {
 
 	packets := stableNetworkTrace(clock, 100, 20)
 
-	// If monotonic time is violated, MockClock.Advance would panic
+	// If monotonic time is violated, Virtual.Advance would panic
 	defer func() {
 		if r := recover(); r != nil {
 			t.Errorf("Monotonic time violation detected: %v", r)
@@ -386,7 +387,6 @@ func TestDelayEstimator_MonotonicTimeUsage(t *testing.T) {
 	}
 
 	// Also test with congesting trace
-	clock2 := internal.NewMockClock(time.Time{})
 	estimator2 := NewDelayEstimator(config, clock2)
 	packets2 := congestingNetworkTrace(clock2, 100, 20, 2.0)
 	for _, pkt := range packets2 {
@@ -396,7 +396,7 @@ func TestDelayEstimator_MonotonicTimeUsage(t *testing.T) {
 
 func TestDelayEstimator_Reset(t *testing.T) {
 	// Test that Reset() properly clears all state
-	clock := internal.NewMockClock(time.Time{})
+	clock := clock.NewVirtual(time.Time{})
 	config := DefaultDelayEstimatorConfig()
 	estimator := NewDelayEstimator(config, clock)
 
@@ -433,10 +433,36 @@ func TestDelayEstimator_Reset(t *testing.T) {
 	}
 }
 
+func TestDelayEstimator_FilterOutputAndThreshold(t *testing.T) {
+	clock := clock.NewVirtual(time.Time{})
+	config := DefaultDelayEstimatorConfig()
+	estimator := NewDelayEstimator(config, clock)
+
+	if estimator.FilterOutput() != 0 {
+		t.Errorf("Initial filter output = %v, want 0", estimator.FilterOutput())
+	}
+	if estimator.Threshold() != config.OveruseConfig.InitialThreshold {
+		t.Errorf("Initial threshold = %v, want %v", estimator.Threshold(), config.OveruseConfig.InitialThreshold)
+	}
+
+	// Growing queuing delay drives the filter output positive
+	for _, pkt := range congestingNetworkTrace(clock, 100, 20, 50.0) {
+		estimator.OnPacket(pkt)
+	}
+	if estimator.FilterOutput() <= 0 {
+		t.Errorf("Filter output under congestion = %v, want > 0", estimator.FilterOutput())
+	}
+
+	estimator.Reset()
+	if estimator.FilterOutput() != 0 {
+		t.Errorf("Filter output after reset = %v, want 0", estimator.FilterOutput())
+	}
+}
+
 func TestDelayEstimator_BurstGrouping(t *testing.T) {
 	// Test that burst grouping works correctly
 	// Packets within a burst (< 5ms apart) should be grouped
-	clock := internal.NewMockClock(time.Time{})
+	clock := clock.NewVirtual(time.Time{})
 	config := DefaultDelayEstimatorConfig()
 	estimator := NewDelayEstimator(config, clock)
 
@@ -456,7 +482,7 @@ func TestDelayEstimator_BurstGrouping(t *testing.T) {
 }
 
 func TestDelayEstimator_StateMethod(t *testing.T) {
-	clock := internal.NewMockClock(time.Time{})
+	clock := clock.NewVirtual(time.Time{})
 	config := DefaultDelayEstimatorConfig()
 	estimator := NewDelayEstimator(config, clock)
 
@@ -510,7 +536,7 @@ func TestDelayEstimator_NilClock(t *testing.T) {
 
 func TestDelayEstimator_TrendlineStableNetwork(t *testing.T) {
 	// Trendline filter with stable network should remain Normal
-	clock := internal.NewMockClock(time.Time{})
+	clock := clock.NewVirtual(time.Time{})
 	config := DefaultDelayEstimatorConfig()
 	config.FilterType = FilterTrendline
 	estimator := NewDelayEstimator(config, clock)
@@ -531,7 +557,7 @@ func TestDelayEstimator_TrendlineDrainingNetwork(t *testing.T) {
 	// Test Trendline filter with decreasing delay (draining network)
 	// Trendline with constant negative delay variation will have slope -> 0 (constant)
 	// This test verifies the trendline integration maintains stable behavior
-	clock := internal.NewMockClock(time.Time{})
+	clock := clock.NewVirtual(time.Time{})
 	config := DefaultDelayEstimatorConfig()
 	config.FilterType = FilterTrendline
 	estimator := NewDelayEstimator(config, clock)
@@ -566,14 +592,14 @@ func TestDelayEstimator_TrendlineDrainingNetwork(t *testing.T) {
 // =============================================================================
 
 func BenchmarkDelayEstimator_OnPacket(b *testing.B) {
-	clock := internal.NewMockClock(time.Time{})
+	clock := clock.NewVirtual(time.Time{})
 	config := DefaultDelayEstimatorConfig()
 
 	// Pre-generate packets
 	packets := stableNetworkTrace(clock, 10000, 20)
 
 	// Reset clock for benchmark
-	clock = internal.NewMockClock(time.Time{})
+	clock.Set(time.Unix(1000000000, 0))
 	estimator := NewDelayEstimator(config, clock)
 
 	b.ResetTimer()
@@ -583,7 +609,7 @@ func BenchmarkDelayEstimator_OnPacket(b *testing.B) {
 }
 
 func BenchmarkDelayEstimator_TrendlineFilter(b *testing.B) {
-	clock := internal.NewMockClock(time.Time{})
+	clock := clock.NewVirtual(time.Time{})
 	config := DefaultDelayEstimatorConfig()
 	config.FilterType = FilterTrendline
 
@@ -591,7 +617,7 @@ func BenchmarkDelayEstimator_TrendlineFilter(b *testing.B) {
 	packets := stableNetworkTrace(clock, 10000, 20)
 
 	// Reset for benchmark
-	clock = internal.NewMockClock(time.Time{})
+	clock.Set(time.Unix(1000000000, 0))
 	estimator := NewDelayEstimator(config, clock)
 
 	b.ResetTimer()
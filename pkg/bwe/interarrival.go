@@ -7,6 +7,144 @@ import "time"
 // the same burst (typically a single video frame).
 const DefaultBurstThreshold = 5 * time.Millisecond
 
+// DefaultMaxBurstDuration is the longest a burst may last under the
+// libwebrtc burst rule (kMaxBurstDuration).
+const DefaultMaxBurstDuration = 100 * time.Millisecond
+
+// DefaultMaxFrameDuration is the longest send-time span of one frame group
+// under GroupByFrame.
+const DefaultMaxFrameDuration = 500 * time.Millisecond
+
+// DefaultArrivalTimeOffsetThreshold is the jump in arrival time, relative to
+// send time, past which the calculator assumes the receive clock jumped
+// (e.g. after an OS suspend) and resets (kArrivalTimeOffsetThresholdMs).
+const DefaultArrivalTimeOffsetThreshold = 3 * time.Second
+
+// DefaultReorderedResetThreshold is the number of consecutive groups that
+// arrive before their predecessor after which the calculator resets
+// (kReorderedResetThreshold).
+const DefaultReorderedResetThreshold = 3
+
+// GroupingMode selects how InterArrivalCalculator groups packets.
+type GroupingMode int
+
+const (
+	// GroupByArrival starts a new group when a packet arrives more than
+	// BurstThreshold after the previous packet of the group.
+	GroupByArrival GroupingMode = iota
+
+	// GroupBySendTime starts a new group when a packet's send time is more
+	// than BurstThreshold past the group's first send time. Packets a sender
+	// paces within one send-time slot stay together even when the network
+	// spreads out or compresses their arrivals.
+	GroupBySendTime
+
+	// GroupLibWebRTC is GroupBySendTime plus libwebrtc's burst rule: a packet
+	// that arrives within BurstThreshold of the previous one with a negative
+	// propagation delta (it caught up with the group in a queue) joins the
+	// group, as long as the group lasts less than MaxBurstDuration.
+	GroupLibWebRTC
+
+	// GroupByFrame puts all packets of a video frame (same SSRC and RTP
+	// timestamp) into one group, however long the sender takes to pace it
+	// out, so a keyframe larger than one burst yields a single delay
+	// sample. After the frame's marker packet, later-sent packets with the
+	// same timestamp (padding) start a new group. Packets of other SSRCs
+	// are grouped by send time; use per-stream pipelines to keep audio
+	// from splitting video frames.
+	GroupByFrame
+)
+
+// String returns a string representation of the GroupingMode.
+func (m GroupingMode) String() string {
+	switch m {
+	case GroupByArrival:
+		return "Arrival"
+	case GroupBySendTime:
+		return "SendTime"
+	case GroupLibWebRTC:
+		return "LibWebRTC"
+	case GroupByFrame:
+		return "Frame"
+	default:
+		return "Unknown"
+	}
+}
+
+// InterArrivalConfig configures packet grouping in InterArrivalCalculator.
+type InterArrivalConfig struct {
+	// BurstThreshold is the arrival gap (GroupByArrival, and the burst rule
+	// of GroupLibWebRTC) or send-time span (GroupBySendTime, GroupLibWebRTC)
+	// within which packets belong to the same group.
+	// Default: 5ms
+	BurstThreshold time.Duration
+
+	// GroupingMode selects how packets are grouped.
+	// Default: GroupByArrival
+	GroupingMode GroupingMode
+
+	// MaxBurstDuration bounds how long a group may grow under the libwebrtc
+	// burst rule. Only used with GroupLibWebRTC.
+	// Default: 100ms
+	MaxBurstDuration time.Duration
+
+	// MaxFrameDuration bounds the send-time span of a frame group, so
+	// packets without RTP timestamps cannot grow one group indefinitely.
+	// Only used with GroupByFrame.
+	// Default: 500ms
+	MaxFrameDuration time.Duration
+
+	// ArrivalTimeOffsetThreshold is the magnitude of delay variation between
+	// consecutive groups at which a clock is considered to have jumped.
+	// The calculator then resets and starts over from the packet.
+	// Default: 3s
+	ArrivalTimeOffsetThreshold time.Duration
+
+	// ReorderedResetThreshold is the number of consecutive negative arrival
+	// deltas between groups after which the calculator resets. Under
+	// GroupByArrival a packet that arrives early joins the current group, so
+	// only the send-time grouping modes see negative deltas.
+	// Default: 3
+	ReorderedResetThreshold int
+}
+
+// DefaultInterArrivalConfig returns the default grouping configuration.
+func DefaultInterArrivalConfig() InterArrivalConfig {
+	return InterArrivalConfig{
+		BurstThreshold:   DefaultBurstThreshold,
+		GroupingMode:     GroupByArrival,
+		MaxBurstDuration: DefaultMaxBurstDuration,
+		MaxFrameDuration: DefaultMaxFrameDuration,
+
+		ArrivalTimeOffsetThreshold: DefaultArrivalTimeOffsetThreshold,
+		ReorderedResetThreshold:    DefaultReorderedResetThreshold,
+	}
+}
+
+// InterArrivalStats counts the packets InterArrivalCalculator rejected and
+// the resets they caused. Counters accumulate across Reset.
+type InterArrivalStats struct {
+	// ReorderedPackets is the number of packets discarded because their
+	// send time was older than the first packet of the current group.
+	ReorderedPackets int64
+
+	// NegativeArrivalDeltas is the number of packets that started a new
+	// group but arrived before the last packet of the previous group.
+	NegativeArrivalDeltas int64
+
+	// ConsecutiveNegativeDeltas is the current run of negative arrival
+	// deltas. It is cleared by a non-negative delta or a reset.
+	ConsecutiveNegativeDeltas int
+
+	// ReorderResets is the number of resets caused by
+	// ReorderedResetThreshold consecutive negative arrival deltas.
+	ReorderResets int64
+
+	// ClockJumpResets is the number of resets caused by an arrival or send
+	// time jump of ArrivalTimeOffsetThreshold or more.
+	ClockJumpResets int64
+}
+
 // PacketGroup represents a group of packets that arrived in a burst.
 // Video frames typically arrive as multiple packets in quick succession.
 // Grouping them reduces noise in delay variation measurements.
@@ -18,6 +156,23 @@ type PacketGroup struct {
 	// This is used for computing inter-group send delta.
 	LastSendTime uint32
 
+	// SendTimeSource is the send time format of the group's packets. With
+	// SendTimeAbsCaptureTime, send deltas use the capture times below.
+	SendTimeSource SendTimeSource
+
+	// FirstCaptureTime and LastCaptureTime are the 64-bit abs-capture-times
+	// of the first and last packets, when SendTimeSource is SendTimeAbsCaptureTime.
+	FirstCaptureTime uint64
+	LastCaptureTime  uint64
+
+	// SSRC and RTPTimestamp identify the frame of the group's first packet.
+	SSRC         uint32
+	RTPTimestamp uint32
+
+	// FrameEnded reports whether a packet of the group's frame had the
+	// marker bit set.
+	FrameEnded bool
+
 	// FirstArriveTime is the arrival time of the first packet (monotonic clock).
 	FirstArriveTime time.Time
 
@@ -32,6 +187,61 @@ type PacketGroup struct {
 	NumPackets int
 }
 
+// sendStamp is a send time in either format, so that deltas between two
+// 64-bit abs-capture-times keep their full precision and range.
+type sendStamp struct {
+	source  SendTimeSource
+	abs     uint32
+	capture uint64
+}
+
+// stampOf returns the send time of a packet.
+func stampOf(pkt PacketInfo) sendStamp {
+	return sendStamp{source: pkt.SendTimeSource, abs: pkt.SendTime, capture: pkt.CaptureTime}
+}
+
+// firstSend returns the send time of the group's first packet.
+func (g *PacketGroup) firstSend() sendStamp {
+	return sendStamp{source: g.SendTimeSource, abs: g.FirstSendTime, capture: g.FirstCaptureTime}
+}
+
+// lastSend returns the send time of the group's last packet.
+func (g *PacketGroup) lastSend() sendStamp {
+	return sendStamp{source: g.SendTimeSource, abs: g.LastSendTime, capture: g.LastCaptureTime}
+}
+
+// until returns the send time delta from s to t. Two abs-capture-times are
+// compared with UnwrapAbsCaptureTimeDuration; anything else falls back to
+// the 24-bit abs-send-time values.
+func (s sendStamp) until(t sendStamp) time.Duration {
+	if s.source == SendTimeAbsCaptureTime && t.source == SendTimeAbsCaptureTime {
+		return UnwrapAbsCaptureTimeDuration(s.capture, t.capture)
+	}
+	return UnwrapAbsSendTimeDuration(s.abs, t.abs)
+}
+
+// GroupDelta describes the change between two consecutive completed packet
+// groups, measured at their last packets. Unlike the per-packet measurement
+// from AddPacket, the last packet of a completed group arrives only after all
+// of its bytes have crossed the bottleneck, so the receive delta includes the
+// serialization time of the size difference. This is the measurement used by
+// libwebrtc's InterArrival.
+type GroupDelta struct {
+	// SendDelta is the difference in send times of the groups' last packets.
+	SendDelta time.Duration
+
+	// ReceiveDelta is the difference in arrival times of the groups' last packets.
+	ReceiveDelta time.Duration
+
+	// SizeDelta is the difference in group sizes in bytes.
+	SizeDelta int
+}
+
+// DelayVariation returns ReceiveDelta - SendDelta.
+func (g GroupDelta) DelayVariation() time.Duration {
+	return g.ReceiveDelta - g.SendDelta
+}
+
 // InterArrivalCalculator computes delay variation between packet groups.
 // It groups packets arriving within a burst threshold and computes the
 // delay variation d(i) = (receive_delta) - (send_delta) between consecutive groups.
@@ -43,35 +253,136 @@ type InterArrivalCalculator struct {
 	// to be considered part of the same burst.
 	burstThreshold time.Duration
 
+	// groupingMode and maxBurstDuration select the grouping rule.
+	groupingMode     GroupingMode
+	maxBurstDuration time.Duration
+	maxFrameDuration time.Duration
+
+	// Thresholds for the clock-jump and reordering policies
+	arrivalOffsetThreshold  time.Duration
+	reorderedResetThreshold int
+
+	// Rejected packets and resets, for monitoring
+	stats InterArrivalStats
+
 	// currentGroup is the group currently being accumulated.
 	currentGroup *PacketGroup
 
 	// previousGroup is the last completed group, used for inter-group calculations.
 	previousGroup *PacketGroup
+
+	// Delta between the last two completed groups, for filters that
+	// measure at group completion
+	groupDelta    GroupDelta
+	hasGroupDelta bool
 }
 
 // NewInterArrivalCalculator creates a new InterArrivalCalculator with the
 // specified burst threshold. If burstThreshold is <= 0, DefaultBurstThreshold (5ms)
-// is used.
+// is used. Packets are grouped by arrival time.
 func NewInterArrivalCalculator(burstThreshold time.Duration) *InterArrivalCalculator {
-	if burstThreshold <= 0 {
-		burstThreshold = DefaultBurstThreshold
+	return NewInterArrivalCalculatorWithConfig(InterArrivalConfig{BurstThreshold: burstThreshold})
+}
+
+// NewInterArrivalCalculatorWithConfig creates a new InterArrivalCalculator
+// with the given grouping configuration. Zero values in config are replaced
+// with defaults.
+func NewInterArrivalCalculatorWithConfig(config InterArrivalConfig) *InterArrivalCalculator {
+	if config.BurstThreshold <= 0 {
+		config.BurstThreshold = DefaultBurstThreshold
+	}
+	if config.MaxBurstDuration <= 0 {
+		config.MaxBurstDuration = DefaultMaxBurstDuration
+	}
+	if config.MaxFrameDuration <= 0 {
+		config.MaxFrameDuration = DefaultMaxFrameDuration
+	}
+	if config.ArrivalTimeOffsetThreshold <= 0 {
+		config.ArrivalTimeOffsetThreshold = DefaultArrivalTimeOffsetThreshold
+	}
+	if config.ReorderedResetThreshold <= 0 {
+		config.ReorderedResetThreshold = DefaultReorderedResetThreshold
 	}
 	return &InterArrivalCalculator{
-		burstThreshold: burstThreshold,
-		currentGroup:   nil,
-		previousGroup:  nil,
+		burstThreshold:          config.BurstThreshold,
+		groupingMode:            config.GroupingMode,
+		maxBurstDuration:        config.MaxBurstDuration,
+		maxFrameDuration:        config.MaxFrameDuration,
+		arrivalOffsetThreshold:  config.ArrivalTimeOffsetThreshold,
+		reorderedResetThreshold: config.ReorderedResetThreshold,
+		currentGroup:            nil,
+		previousGroup:           nil,
 	}
 }
 
-// BelongsToBurst returns true if the packet should be added to the current group
-// (burst), based on the arrival time difference from the last packet in the group.
+// BelongsToBurst returns true if the packet should be added to the current
+// group (burst), according to the grouping mode:
+//   - GroupByArrival: arrival within BurstThreshold after the group's last packet
+//   - GroupBySendTime: send time within BurstThreshold of the group's first packet
+//   - GroupLibWebRTC: as GroupBySendTime, or a burst per isLibWebRTCBurst
+//   - GroupByFrame: same frame per withinFrame; other SSRCs as GroupBySendTime
 func (c *InterArrivalCalculator) BelongsToBurst(pkt PacketInfo) bool {
 	if c.currentGroup == nil {
 		return false
 	}
-	arrivalDelta := pkt.ArrivalTime.Sub(c.currentGroup.LastArriveTime)
-	return arrivalDelta <= c.burstThreshold
+
+	switch c.groupingMode {
+	case GroupBySendTime:
+		return c.withinSendTimeGroup(pkt)
+	case GroupLibWebRTC:
+		return c.isLibWebRTCBurst(pkt) || c.withinSendTimeGroup(pkt)
+	case GroupByFrame:
+		if pkt.SSRC == c.currentGroup.SSRC {
+			return c.withinFrame(pkt)
+		}
+		return c.withinSendTimeGroup(pkt)
+	default: // GroupByArrival
+		arrivalDelta := pkt.ArrivalTime.Sub(c.currentGroup.LastArriveTime)
+		return arrivalDelta <= c.burstThreshold
+	}
+}
+
+// withinSendTimeGroup reports whether the packet was sent no more than
+// burstThreshold after the first packet of the current group.
+func (c *InterArrivalCalculator) withinSendTimeGroup(pkt PacketInfo) bool {
+	return c.currentGroup.firstSend().until(stampOf(pkt)) <= c.burstThreshold
+}
+
+// withinFrame reports whether a packet of the current group's SSRC belongs
+// to the group's frame: it has the same RTP timestamp, was sent within
+// maxFrameDuration of the group's first packet, and, once the marker packet
+// was seen, was not sent after the frame's last packet.
+func (c *InterArrivalCalculator) withinFrame(pkt PacketInfo) bool {
+	g := c.currentGroup
+	if pkt.RTPTimestamp != g.RTPTimestamp {
+		return false
+	}
+	if g.firstSend().until(stampOf(pkt)) > c.maxFrameDuration {
+		return false
+	}
+	if g.FrameEnded {
+		// A late packet of the frame, not padding sent after it
+		return g.lastSend().until(stampOf(pkt)) <= 0
+	}
+	return true
+}
+
+// isLibWebRTCBurst applies libwebrtc's BelongsToBurst rule: a packet with the
+// same send time as the group, or one that arrived within burstThreshold of
+// the group's last packet but sooner than its send spacing (negative
+// propagation delta, i.e. it was queued behind the group), joins the group
+// while the group is shorter than maxBurstDuration.
+func (c *InterArrivalCalculator) isLibWebRTCBurst(pkt PacketInfo) bool {
+	g := c.currentGroup
+	arrivalDelta := pkt.ArrivalTime.Sub(g.LastArriveTime)
+	sendDelta := g.lastSend().until(stampOf(pkt))
+	if sendDelta == 0 {
+		return true
+	}
+	propagationDelta := arrivalDelta - sendDelta
+	return propagationDelta < 0 &&
+		arrivalDelta <= c.burstThreshold &&
+		pkt.ArrivalTime.Sub(g.FirstArriveTime) < c.maxBurstDuration
 }
 
 // AddPacket processes a received packet and returns the delay variation
@@ -85,30 +396,78 @@ func (c *InterArrivalCalculator) BelongsToBurst(pkt PacketInfo) bool {
 // where t is receive time and T is send time. Positive values indicate
 // increasing delay (queue building), negative values indicate decreasing
 // delay (queue draining).
+//
+// Packets sent before the first packet of the current group are discarded,
+// unless sent ArrivalTimeOffsetThreshold or more before it, in which case
+// the sender's clock is assumed to have restarted and the calculator resets.
+// A packet that would start a new group is checked against the current one:
+// if it arrived before the group's last packet it is discarded, and
+// ReorderedResetThreshold such packets in a row reset the calculator; if its
+// delay variation reaches ArrivalTimeOffsetThreshold in either direction, the
+// arrival or send clock is assumed to have jumped and the calculator restarts
+// from this packet. The send-time checks only apply to packets of the
+// group's SSRC. InterArrivalStats counts each case.
 func (c *InterArrivalCalculator) AddPacket(pkt PacketInfo) (delayVariation time.Duration, hasResult bool) {
+	// Send times are only compared within an SSRC: the streams of a shared
+	// pipeline may come from senders with different clocks (an SFU
+	// forwarding several publishers), whose offsets say nothing about
+	// reordering or clock jumps
+	if c.currentGroup != nil && pkt.SSRC == c.currentGroup.SSRC {
+		sendOffset := c.currentGroup.firstSend().until(stampOf(pkt))
+		if sendOffset <= -c.arrivalOffsetThreshold {
+			// Too old to be reordered: the sender restarted its clock
+			c.stats.ClockJumpResets++
+			c.Reset()
+		} else if sendOffset < 0 {
+			c.stats.ReorderedPackets++
+			return 0, false
+		}
+	}
+
 	if c.BelongsToBurst(pkt) {
-		// Add to current group
-		c.currentGroup.LastSendTime = pkt.SendTime
+		// Add to current group. Send-time grouping keeps the latest send
+		// time so a reordered packet does not move the group back.
+		if c.groupingMode == GroupByArrival || c.currentGroup.lastSend().until(stampOf(pkt)) > 0 {
+			c.currentGroup.LastSendTime = pkt.SendTime
+			c.currentGroup.LastCaptureTime = pkt.CaptureTime
+		}
+		if pkt.SSRC == c.currentGroup.SSRC && pkt.RTPTimestamp == c.currentGroup.RTPTimestamp {
+			c.currentGroup.FrameEnded = c.currentGroup.FrameEnded || pkt.Marker
+		}
 		c.currentGroup.LastArriveTime = pkt.ArrivalTime
 		c.currentGroup.Size += pkt.Size
 		c.currentGroup.NumPackets++
 		return 0, false
 	}
 
-	// New group needed
-	// First, move current to previous (if exists)
+	// New group needed. Check the arrival delta from the current group
+	// before completing it.
+	if c.currentGroup != nil && !c.checkArrivalDelta(pkt) {
+		return 0, false
+	}
+
+	// Move current to previous (if exists)
 	if c.currentGroup != nil {
+		if c.previousGroup != nil {
+			c.computeGroupDelta(c.previousGroup, c.currentGroup)
+		}
 		c.previousGroup = c.currentGroup
 	}
 
 	// Create new current group from this packet
 	c.currentGroup = &PacketGroup{
-		FirstSendTime:   pkt.SendTime,
-		LastSendTime:    pkt.SendTime,
-		FirstArriveTime: pkt.ArrivalTime,
-		LastArriveTime:  pkt.ArrivalTime,
-		Size:            pkt.Size,
-		NumPackets:      1,
+		FirstSendTime:    pkt.SendTime,
+		LastSendTime:     pkt.SendTime,
+		SendTimeSource:   pkt.SendTimeSource,
+		FirstCaptureTime: pkt.CaptureTime,
+		LastCaptureTime:  pkt.CaptureTime,
+		SSRC:             pkt.SSRC,
+		RTPTimestamp:     pkt.RTPTimestamp,
+		FrameEnded:       pkt.Marker,
+		FirstArriveTime:  pkt.ArrivalTime,
+		LastArriveTime:   pkt.ArrivalTime,
+		Size:             pkt.Size,
+		NumPackets:       1,
 	}
 
 	// Compute delay variation if we have a previous group
@@ -119,6 +478,36 @@ func (c *InterArrivalCalculator) AddPacket(pkt PacketInfo) (delayVariation time.
 	return 0, false
 }
 
+// checkArrivalDelta applies the clock-jump and reordering policies to a packet
+// that starts a new group. It returns false if the packet must be discarded.
+// After a clock jump the state is reset and true is returned, so the packet
+// starts the first group of the new timeline.
+func (c *InterArrivalCalculator) checkArrivalDelta(pkt PacketInfo) bool {
+	receiveDelta := pkt.ArrivalTime.Sub(c.currentGroup.LastArriveTime)
+
+	if pkt.SSRC == c.currentGroup.SSRC {
+		sendDelta := c.currentGroup.lastSend().until(stampOf(pkt))
+		if offset := receiveDelta - sendDelta; offset >= c.arrivalOffsetThreshold || offset <= -c.arrivalOffsetThreshold {
+			c.stats.ClockJumpResets++
+			c.Reset()
+			return true
+		}
+	}
+
+	if receiveDelta < 0 {
+		c.stats.NegativeArrivalDeltas++
+		c.stats.ConsecutiveNegativeDeltas++
+		if c.stats.ConsecutiveNegativeDeltas >= c.reorderedResetThreshold {
+			c.stats.ReorderResets++
+			c.Reset()
+		}
+		return false
+	}
+
+	c.stats.ConsecutiveNegativeDeltas = 0
+	return true
+}
+
 // computeDelayVariation calculates the delay variation between the previous
 // and current groups using their last packet timestamps.
 func (c *InterArrivalCalculator) computeDelayVariation() time.Duration {
@@ -126,7 +515,7 @@ func (c *InterArrivalCalculator) computeDelayVariation() time.Duration {
 	receiveDelta := c.currentGroup.LastArriveTime.Sub(c.previousGroup.LastArriveTime)
 
 	// Send delta: difference in send times between groups (handles wraparound)
-	sendDelta := UnwrapAbsSendTimeDuration(c.previousGroup.LastSendTime, c.currentGroup.LastSendTime)
+	sendDelta := c.previousGroup.lastSend().until(c.currentGroup.lastSend())
 
 	// Delay variation = receive delta - send delta
 	// Positive = queue building (packets arriving later than expected)
@@ -134,11 +523,37 @@ func (c *InterArrivalCalculator) computeDelayVariation() time.Duration {
 	return receiveDelta - sendDelta
 }
 
+// computeGroupDelta records the delta between two consecutive completed groups.
+func (c *InterArrivalCalculator) computeGroupDelta(prev, last *PacketGroup) {
+	c.groupDelta = GroupDelta{
+		SendDelta:    prev.lastSend().until(last.lastSend()),
+		ReceiveDelta: last.LastArriveTime.Sub(prev.LastArriveTime),
+		SizeDelta:    last.Size - prev.Size,
+	}
+	c.hasGroupDelta = true
+}
+
 // Reset clears the calculator state. Call this when the stream resets,
 // after a large gap in packets, or when switching streams.
+// The InterArrivalStats counters are kept.
 func (c *InterArrivalCalculator) Reset() {
 	c.currentGroup = nil
 	c.previousGroup = nil
+	c.groupDelta = GroupDelta{}
+	c.hasGroupDelta = false
+	c.stats.ConsecutiveNegativeDeltas = 0
+}
+
+// Stats returns the counters of rejected packets and resets.
+func (c *InterArrivalCalculator) Stats() InterArrivalStats {
+	return c.stats
+}
+
+// LastGroupDelta returns the delta between the last two completed groups,
+// updated each time AddPacket starts a new group.
+// Returns (delta, true) once two groups have completed, (GroupDelta{}, false) before.
+func (c *InterArrivalCalculator) LastGroupDelta() (GroupDelta, bool) {
+	return c.groupDelta, c.hasGroupDelta
 }
 
 // CurrentGroup returns the current packet group being accumulated.
@@ -157,3 +572,8 @@ func (c *InterArrivalCalculator) PreviousGroup() *PacketGroup {
 func (c *InterArrivalCalculator) BurstThreshold() time.Duration {
 	return c.burstThreshold
 }
+
+// GroupingMode returns the configured grouping mode.
+func (c *InterArrivalCalculator) GroupingMode() GroupingMode {
+	return c.groupingMode
+}
@@ -419,3 +419,458 @@ func TestInterArrivalCalculator_MultipleGroups(t *testing.T) {
 		t.Errorf("Expected 2 delay variation results, got %d", results)
 	}
 }
+
+func TestInterArrivalCalculator_LastGroupDelta(t *testing.T) {
+	calc := NewInterArrivalCalculator(5 * time.Millisecond)
+	baseTime := time.Now()
+
+	// Group 1: 2 packets, 2400 bytes, last arrives at t=4ms
+	// Group 2: 3 packets, 3600 bytes, last arrives at t=58ms (sent 50ms later)
+	// Group 3: starts at t=100ms and completes group 2
+	packets := []PacketInfo{
+		{ArrivalTime: baseTime, SendTime: 1000, Size: 1200},
+		{ArrivalTime: baseTime.Add(4 * time.Millisecond), SendTime: 1000, Size: 1200},
+		{ArrivalTime: baseTime.Add(50 * time.Millisecond), SendTime: 1000 + msToAbsSendTime(50), Size: 1200},
+		{ArrivalTime: baseTime.Add(54 * time.Millisecond), SendTime: 1000 + msToAbsSendTime(50), Size: 1200},
+		{ArrivalTime: baseTime.Add(58 * time.Millisecond), SendTime: 1000 + msToAbsSendTime(50), Size: 1200},
+	}
+	for _, pkt := range packets {
+		calc.AddPacket(pkt)
+	}
+	if _, ok := calc.LastGroupDelta(); ok {
+		t.Fatal("LastGroupDelta should not be available before two groups complete")
+	}
+
+	calc.AddPacket(PacketInfo{ArrivalTime: baseTime.Add(100 * time.Millisecond), SendTime: 1000 + msToAbsSendTime(100), Size: 1200})
+
+	delta, ok := calc.LastGroupDelta()
+	if !ok {
+		t.Fatal("LastGroupDelta should be available after two groups complete")
+	}
+	if delta.SizeDelta != 1200 {
+		t.Errorf("SizeDelta = %d, want 1200", delta.SizeDelta)
+	}
+	if delta.ReceiveDelta != 54*time.Millisecond {
+		t.Errorf("ReceiveDelta = %v, want 54ms", delta.ReceiveDelta)
+	}
+	// Last arrivals 54ms apart, sent 50ms apart
+	if diff := delta.DelayVariation() - 4*time.Millisecond; diff < -10*time.Microsecond || diff > 10*time.Microsecond {
+		t.Errorf("DelayVariation() = %v, want ~4ms", delta.DelayVariation())
+	}
+
+	calc.Reset()
+	if _, ok := calc.LastGroupDelta(); ok {
+		t.Error("LastGroupDelta should be cleared by Reset")
+	}
+}
+
+func TestInterArrivalCalculator_GroupBySendTime(t *testing.T) {
+	calc := NewInterArrivalCalculatorWithConfig(InterArrivalConfig{GroupingMode: GroupBySendTime})
+	baseTime := time.Now()
+
+	// Packets paced 2ms apart in send time but spread out to 8ms apart by
+	// the network: arrival grouping would split them, send-time grouping
+	// keeps the first three (within 5ms of the first send time) together.
+	for i := 0; i < 3; i++ {
+		_, hasResult := calc.AddPacket(PacketInfo{
+			ArrivalTime: baseTime.Add(time.Duration(i*8) * time.Millisecond),
+			SendTime:    1000 + msToAbsSendTime(i*2),
+			Size:        100,
+		})
+		if hasResult {
+			t.Fatalf("packet %d should not produce result", i)
+		}
+	}
+	if calc.CurrentGroup().NumPackets != 3 {
+		t.Fatalf("Expected 3 packets in group, got %d", calc.CurrentGroup().NumPackets)
+	}
+
+	// Sent 6ms after the group's first packet: starts a new group even
+	// though it arrives right behind the previous packet.
+	_, hasResult := calc.AddPacket(PacketInfo{
+		ArrivalTime: baseTime.Add(17 * time.Millisecond),
+		SendTime:    1000 + msToAbsSendTime(6),
+		Size:        100,
+	})
+	if !hasResult {
+		t.Error("packet sent past the send-time slot should start a new group")
+	}
+	if calc.PreviousGroup().NumPackets != 3 {
+		t.Errorf("Previous group should have 3 packets, got %d", calc.PreviousGroup().NumPackets)
+	}
+}
+
+func TestInterArrivalCalculator_GroupBySendTime_Reordered(t *testing.T) {
+	calc := NewInterArrivalCalculatorWithConfig(InterArrivalConfig{GroupingMode: GroupBySendTime})
+	baseTime := time.Now()
+
+	calc.AddPacket(PacketInfo{ArrivalTime: baseTime, SendTime: 1000 + msToAbsSendTime(2), Size: 100})
+	calc.AddPacket(PacketInfo{ArrivalTime: baseTime.Add(time.Millisecond), SendTime: 1000 + msToAbsSendTime(4), Size: 100})
+	// Reordered packet sent earlier than the group's last packet
+	calc.AddPacket(PacketInfo{ArrivalTime: baseTime.Add(2 * time.Millisecond), SendTime: 1000 + msToAbsSendTime(3), Size: 100})
+
+	g := calc.CurrentGroup()
+	if g.NumPackets != 3 {
+		t.Fatalf("Expected 3 packets in group, got %d", g.NumPackets)
+	}
+	if g.LastSendTime != 1000+msToAbsSendTime(4) {
+		t.Errorf("LastSendTime moved back to reordered packet: got %d", g.LastSendTime)
+	}
+}
+
+func TestInterArrivalCalculator_GroupLibWebRTC_Burst(t *testing.T) {
+	calc := NewInterArrivalCalculatorWithConfig(InterArrivalConfig{GroupingMode: GroupLibWebRTC})
+	baseTime := time.Now()
+
+	// Packets sent 10ms apart but compressed by a queue to arrive 1ms apart
+	// (negative propagation delta) form one burst.
+	for i := 0; i < 5; i++ {
+		_, hasResult := calc.AddPacket(PacketInfo{
+			ArrivalTime: baseTime.Add(time.Duration(i) * time.Millisecond),
+			SendTime:    1000 + msToAbsSendTime(i*10),
+			Size:        100,
+		})
+		if hasResult {
+			t.Fatalf("packet %d should join the burst", i)
+		}
+	}
+	if calc.CurrentGroup().NumPackets != 5 {
+		t.Errorf("Expected 5 packets in burst, got %d", calc.CurrentGroup().NumPackets)
+	}
+
+	// Same send spacing arriving 10ms later: propagation delta is zero, so
+	// it is not a burst and starts a new group.
+	_, hasResult := calc.AddPacket(PacketInfo{
+		ArrivalTime: baseTime.Add(14 * time.Millisecond),
+		SendTime:    1000 + msToAbsSendTime(50),
+		Size:        100,
+	})
+	if !hasResult {
+		t.Error("packet without negative propagation delta should start a new group")
+	}
+}
+
+func TestInterArrivalCalculator_GroupLibWebRTC_MaxBurstDuration(t *testing.T) {
+	calc := NewInterArrivalCalculatorWithConfig(InterArrivalConfig{GroupingMode: GroupLibWebRTC})
+	baseTime := time.Now()
+
+	// A queue drains packets 4ms apart that were sent 10ms apart. Each one is
+	// a burst candidate, but the burst is cut at 100ms.
+	groups := 0
+	for i := 0; i <= 30; i++ {
+		if _, hasResult := calc.AddPacket(PacketInfo{
+			ArrivalTime: baseTime.Add(time.Duration(i*4) * time.Millisecond),
+			SendTime:    1000 + msToAbsSendTime(i*10),
+			Size:        100,
+		}); hasResult {
+			groups++
+		}
+	}
+	if groups != 1 {
+		t.Fatalf("Expected the burst to be split once, got %d new groups", groups)
+	}
+	if n := calc.PreviousGroup().NumPackets; n != 25 {
+		t.Errorf("Expected 25 packets (100ms / 4ms) in the first burst, got %d", n)
+	}
+}
+
+func TestDelayEstimator_GroupingModeConfig(t *testing.T) {
+	config := DefaultDelayEstimatorConfig()
+	if config.GroupingMode != GroupByArrival {
+		t.Errorf("default GroupingMode = %v, want Arrival", config.GroupingMode)
+	}
+
+	config.GroupingMode = GroupLibWebRTC
+	e := NewDelayEstimator(config, nil)
+	if got := e.interarrival.GroupingMode(); got != GroupLibWebRTC {
+		t.Errorf("interarrival GroupingMode = %v, want LibWebRTC", got)
+	}
+
+	config.MaxBurstDuration = 50 * time.Millisecond
+	config.MaxFrameDuration = 200 * time.Millisecond
+	config.ArrivalTimeOffsetThreshold = time.Second
+	config.ReorderedResetThreshold = 5
+	e = NewDelayEstimator(config, nil)
+	if e.interarrival.maxBurstDuration != 50*time.Millisecond || e.interarrival.maxFrameDuration != 200*time.Millisecond {
+		t.Error("burst and frame durations should be passed to the interarrival calculator")
+	}
+	if e.interarrival.arrivalOffsetThreshold != time.Second || e.interarrival.reorderedResetThreshold != 5 {
+		t.Error("reset thresholds should be passed to the interarrival calculator")
+	}
+}
+
+func TestInterArrivalCalculator_DiscardsReorderedPacket(t *testing.T) {
+	calc := NewInterArrivalCalculator(5 * time.Millisecond)
+	baseTime := time.Now()
+
+	calc.AddPacket(PacketInfo{ArrivalTime: baseTime, SendTime: 1000 + msToAbsSendTime(20), Size: 100})
+	calc.AddPacket(PacketInfo{ArrivalTime: baseTime.Add(20 * time.Millisecond), SendTime: 1000 + msToAbsSendTime(40), Size: 100})
+
+	// Sent before the current group: discarded without a result
+	_, hasResult := calc.AddPacket(PacketInfo{ArrivalTime: baseTime.Add(40 * time.Millisecond), SendTime: 1000, Size: 100})
+	if hasResult {
+		t.Error("reordered packet should not produce a result")
+	}
+	if calc.CurrentGroup().NumPackets != 1 || calc.CurrentGroup().FirstSendTime != 1000+msToAbsSendTime(40) {
+		t.Error("reordered packet should not change the current group")
+	}
+	if got := calc.Stats().ReorderedPackets; got != 1 {
+		t.Errorf("ReorderedPackets = %d, want 1", got)
+	}
+}
+
+func TestInterArrivalCalculator_InterleavedSSRCs(t *testing.T) {
+	calc := NewInterArrivalCalculatorWithConfig(InterArrivalConfig{BurstThreshold: 5 * time.Millisecond, GroupingMode: GroupBySendTime})
+	baseTime := time.Now()
+
+	// Two senders whose send-time origins are 10s apart share one pipeline:
+	// neither is treated as reordered or as a clock jump against the other.
+	for i := 0; i < 20; i++ {
+		arrival := baseTime.Add(time.Duration(i) * 20 * time.Millisecond)
+		calc.AddPacket(PacketInfo{ArrivalTime: arrival, SendTime: msToAbsSendTime(10000 + i*20), Size: 100, SSRC: 1})
+		calc.AddPacket(PacketInfo{ArrivalTime: arrival.Add(time.Millisecond), SendTime: msToAbsSendTime(i*20 + 1), Size: 100, SSRC: 2})
+	}
+	if got := calc.Stats().ReorderedPackets; got != 0 {
+		t.Errorf("ReorderedPackets = %d, want 0", got)
+	}
+	if got := calc.Stats().ClockJumpResets; got != 0 {
+		t.Errorf("ClockJumpResets = %d, want 0", got)
+	}
+}
+
+func TestInterArrivalCalculator_ClockJumpResets(t *testing.T) {
+	calc := NewInterArrivalCalculator(5 * time.Millisecond)
+	baseTime := time.Now()
+
+	calc.AddPacket(PacketInfo{ArrivalTime: baseTime, SendTime: 1000, Size: 100})
+	calc.AddPacket(PacketInfo{ArrivalTime: baseTime.Add(20 * time.Millisecond), SendTime: 1000 + msToAbsSendTime(20), Size: 100})
+
+	// The receive clock jumps 5s forward (e.g. OS suspend) while the send
+	// time advances 20ms: no bogus 5s delay variation is reported.
+	_, hasResult := calc.AddPacket(PacketInfo{ArrivalTime: baseTime.Add(5 * time.Second), SendTime: 1000 + msToAbsSendTime(40), Size: 100})
+	if hasResult {
+		t.Error("packet after a clock jump should not produce a result")
+	}
+	if calc.PreviousGroup() != nil {
+		t.Error("clock jump should reset the previous group")
+	}
+	if calc.CurrentGroup() == nil || calc.CurrentGroup().FirstArriveTime != baseTime.Add(5*time.Second) {
+		t.Error("packet after a clock jump should start a new group")
+	}
+
+	// The sender restarts its clock 30s back: reset rather than discard
+	calc.AddPacket(PacketInfo{ArrivalTime: baseTime.Add(5020 * time.Millisecond), SendTime: 1000 + msToAbsSendTime(60) - msToAbsSendTime(30000), Size: 100})
+	if got := calc.Stats().ClockJumpResets; got != 2 {
+		t.Errorf("ClockJumpResets = %d, want 2", got)
+	}
+	if got := calc.Stats().ReorderedPackets; got != 0 {
+		t.Errorf("ReorderedPackets = %d, want 0", got)
+	}
+}
+
+func TestInterArrivalCalculator_EarlyArrivalJoinsGroup(t *testing.T) {
+	calc := NewInterArrivalCalculator(5 * time.Millisecond)
+	baseTime := time.Now()
+
+	calc.AddPacket(PacketInfo{ArrivalTime: baseTime.Add(100 * time.Millisecond), SendTime: 1000, Size: 100})
+	calc.AddPacket(PacketInfo{ArrivalTime: baseTime.Add(120 * time.Millisecond), SendTime: 1000 + msToAbsSendTime(20), Size: 100})
+
+	// GroupByArrival only splits on an arrival gap above the threshold, so
+	// a packet arriving before the group's last packet joins the group
+	calc.AddPacket(PacketInfo{ArrivalTime: baseTime.Add(110 * time.Millisecond), SendTime: 1000 + msToAbsSendTime(40), Size: 100})
+	if got := calc.CurrentGroup().NumPackets; got != 2 {
+		t.Errorf("current group has %d packets, want 2", got)
+	}
+	if stats := calc.Stats(); stats.NegativeArrivalDeltas != 0 {
+		t.Errorf("NegativeArrivalDeltas = %d, want 0", stats.NegativeArrivalDeltas)
+	}
+}
+
+func TestInterArrivalCalculator_NegativeArrivalDeltas(t *testing.T) {
+	calc := NewInterArrivalCalculatorWithConfig(InterArrivalConfig{
+		BurstThreshold: 5 * time.Millisecond,
+		GroupingMode:   GroupBySendTime,
+	})
+	baseTime := time.Now()
+
+	calc.AddPacket(PacketInfo{ArrivalTime: baseTime.Add(100 * time.Millisecond), SendTime: 1000, Size: 100})
+	calc.AddPacket(PacketInfo{ArrivalTime: baseTime.Add(120 * time.Millisecond), SendTime: 1000 + msToAbsSendTime(20), Size: 100})
+
+	// New groups that arrive before the current group's last packet
+	for i := 1; i <= 2; i++ {
+		if _, hasResult := calc.AddPacket(PacketInfo{
+			ArrivalTime: baseTime.Add(time.Duration(i) * time.Millisecond),
+			SendTime:    1000 + msToAbsSendTime(20+i*20),
+			Size:        100,
+		}); hasResult {
+			t.Fatalf("negative arrival delta %d should not produce a result", i)
+		}
+	}
+	stats := calc.Stats()
+	if stats.NegativeArrivalDeltas != 2 || stats.ConsecutiveNegativeDeltas != 2 {
+		t.Errorf("NegativeArrivalDeltas = %d, ConsecutiveNegativeDeltas = %d, want 2, 2",
+			stats.NegativeArrivalDeltas, stats.ConsecutiveNegativeDeltas)
+	}
+	if calc.PreviousGroup() == nil {
+		t.Fatal("state should be kept below the reorder reset threshold")
+	}
+
+	// Third in a row resets
+	calc.AddPacket(PacketInfo{ArrivalTime: baseTime.Add(3 * time.Millisecond), SendTime: 1000 + msToAbsSendTime(80), Size: 100})
+	stats = calc.Stats()
+	if stats.ReorderResets != 1 || stats.ConsecutiveNegativeDeltas != 0 {
+		t.Errorf("ReorderResets = %d, ConsecutiveNegativeDeltas = %d, want 1, 0",
+			stats.ReorderResets, stats.ConsecutiveNegativeDeltas)
+	}
+	if calc.CurrentGroup() != nil || calc.PreviousGroup() != nil {
+		t.Error("reorder reset should clear the groups")
+	}
+	if stats.NegativeArrivalDeltas != 3 {
+		t.Errorf("NegativeArrivalDeltas = %d, want 3 (kept across reset)", stats.NegativeArrivalDeltas)
+	}
+}
+
+// captureTimePacket returns a packet sent at sendOffset on the
+// abs-capture-time clock, starting at 1000s of NTP time.
+func captureTimePacket(arrival time.Time, sendOffset time.Duration) PacketInfo {
+	seconds := uint64(sendOffset / time.Second)
+	fraction := uint64(sendOffset%time.Second) * (1 << 32) / uint64(time.Second)
+	capture := (1000+seconds)<<32 + fraction
+	return PacketInfo{
+		ArrivalTime:    arrival,
+		SendTime:       AbsCaptureTimeToAbsSendTime(capture),
+		SendTimeSource: SendTimeAbsCaptureTime,
+		CaptureTime:    capture,
+		Size:           1200,
+	}
+}
+
+func TestInterArrivalCalculator_AbsCaptureTime(t *testing.T) {
+	calc := NewInterArrivalCalculator(5 * time.Millisecond)
+	baseTime := time.Now()
+
+	calc.AddPacket(captureTimePacket(baseTime, 0))
+	calc.AddPacket(captureTimePacket(baseTime.Add(2*time.Millisecond), 0))
+
+	// 70s later: the 24-bit values wrapped, the 64-bit capture times did not
+	dv, hasResult := calc.AddPacket(captureTimePacket(baseTime.Add(70*time.Second+10*time.Millisecond), 70*time.Second))
+	if !hasResult {
+		t.Fatal("Expected result from second group")
+	}
+	if want := 8 * time.Millisecond; dv < want-time.Microsecond || dv > want+time.Microsecond {
+		t.Errorf("delay variation = %v, want %v", dv, want)
+	}
+	if got := calc.Stats().ClockJumpResets; got != 0 {
+		t.Errorf("ClockJumpResets = %d, want 0", got)
+	}
+	if calc.CurrentGroup().SendTimeSource != SendTimeAbsCaptureTime {
+		t.Errorf("group SendTimeSource = %v, want AbsCaptureTime", calc.CurrentGroup().SendTimeSource)
+	}
+}
+
+func TestInterArrivalCalculator_AbsCaptureTimePrecision(t *testing.T) {
+	calc := NewInterArrivalCalculator(5 * time.Millisecond)
+	baseTime := time.Now()
+
+	// 1µs of queuing per group is below the 3.8µs abs-send-time resolution
+	calc.AddPacket(captureTimePacket(baseTime, 0))
+	calc.AddPacket(captureTimePacket(baseTime.Add(20*time.Millisecond+time.Microsecond), 20*time.Millisecond))
+	calc.AddPacket(captureTimePacket(baseTime.Add(40*time.Millisecond+2*time.Microsecond), 40*time.Millisecond))
+
+	delta, ok := calc.LastGroupDelta()
+	if !ok {
+		t.Fatal("LastGroupDelta should be available")
+	}
+	if dv := delta.DelayVariation(); dv < 900*time.Nanosecond || dv > 1100*time.Nanosecond {
+		t.Errorf("DelayVariation() = %v, want ~1µs", dv)
+	}
+}
+
+// framePacket returns a packet of the video frame with the given RTP
+// timestamp, sent sendMs and arriving arriveMs after baseTime.
+func framePacket(baseTime time.Time, timestamp uint32, sendMs, arriveMs int, marker bool) PacketInfo {
+	return PacketInfo{
+		ArrivalTime:  baseTime.Add(time.Duration(arriveMs) * time.Millisecond),
+		SendTime:     1000 + msToAbsSendTime(sendMs),
+		Size:         1200,
+		SSRC:         1,
+		RTPTimestamp: timestamp,
+		Marker:       marker,
+	}
+}
+
+func TestInterArrivalCalculator_GroupByFrame_Keyframe(t *testing.T) {
+	baseTime := time.Now()
+	byFrame := NewInterArrivalCalculatorWithConfig(InterArrivalConfig{GroupingMode: GroupByFrame})
+	bySendTime := NewInterArrivalCalculatorWithConfig(InterArrivalConfig{GroupingMode: GroupBySendTime})
+
+	// A 30-packet keyframe paced out over 30ms, then a small delta frame
+	var frameResults, sendTimeResults int
+	for i := 0; i < 30; i++ {
+		pkt := framePacket(baseTime, 3000, i, i+1, i == 29)
+		if _, ok := byFrame.AddPacket(pkt); ok {
+			frameResults++
+		}
+		if _, ok := bySendTime.AddPacket(pkt); ok {
+			sendTimeResults++
+		}
+	}
+	if frameResults != 0 {
+		t.Errorf("keyframe should form one group, got %d results", frameResults)
+	}
+	if sendTimeResults == 0 {
+		t.Error("send-time grouping should split the keyframe")
+	}
+	if g := byFrame.CurrentGroup(); g.NumPackets != 30 || !g.FrameEnded {
+		t.Errorf("frame group: NumPackets = %d, FrameEnded = %v; want 30, true", g.NumPackets, g.FrameEnded)
+	}
+
+	if _, ok := byFrame.AddPacket(framePacket(baseTime, 6000, 33, 34, true)); !ok {
+		t.Error("next frame should complete the keyframe group")
+	}
+	if n := byFrame.PreviousGroup().NumPackets; n != 30 {
+		t.Errorf("previous group has %d packets, want 30", n)
+	}
+}
+
+func TestInterArrivalCalculator_GroupByFrame_Marker(t *testing.T) {
+	calc := NewInterArrivalCalculatorWithConfig(InterArrivalConfig{GroupingMode: GroupByFrame})
+	baseTime := time.Now()
+
+	calc.AddPacket(framePacket(baseTime, 3000, 0, 0, false))
+	calc.AddPacket(framePacket(baseTime, 3000, 2, 3, true))
+
+	// A reordered packet of the finished frame still joins it
+	calc.AddPacket(framePacket(baseTime, 3000, 1, 4, false))
+	if n := calc.CurrentGroup().NumPackets; n != 3 {
+		t.Fatalf("late packet of the frame should join its group, got %d packets", n)
+	}
+
+	// Padding reusing the frame's timestamp after the marker starts a new group
+	calc.AddPacket(framePacket(baseTime, 3000, 3, 5, false))
+	if n := calc.PreviousGroup().NumPackets; n != 3 {
+		t.Errorf("padding after the marker should start a new group, previous has %d packets", n)
+	}
+}
+
+func TestInterArrivalCalculator_GroupByFrame_MaxFrameDuration(t *testing.T) {
+	calc := NewInterArrivalCalculatorWithConfig(InterArrivalConfig{
+		GroupingMode:     GroupByFrame,
+		MaxFrameDuration: 50 * time.Millisecond,
+	})
+	baseTime := time.Now()
+
+	// Packets without RTP timestamps all look like one frame
+	groups := 0
+	for i := 0; i <= 100; i += 10 {
+		if _, ok := calc.AddPacket(framePacket(baseTime, 0, i, i, false)); ok {
+			groups++
+		}
+	}
+	if groups != 1 {
+		t.Errorf("frame group should be cut at MaxFrameDuration, got %d new groups", groups)
+	}
+	if n := calc.PreviousGroup().NumPackets; n != 6 {
+		t.Errorf("expected 6 packets (0-50ms) in the first group, got %d", n)
+	}
+}
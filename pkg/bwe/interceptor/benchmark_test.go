@@ -59,7 +59,7 @@ import (
 	"github.com/pion/rtp"
 
 	"github.com/thesyncim/bwe/pkg/bwe"
-	"github.com/thesyncim/bwe/pkg/bwe/internal"
+	"github.com/thesyncim/bwe/pkg/bwe/clock"
 )
 
 // benchResult is a package-level variable to prevent compiler optimizations
@@ -83,7 +83,7 @@ func BenchmarkProcessRTP_Allocations(b *testing.B) {
 
 	// Setup estimator with mock clock
 	config := bwe.DefaultBandwidthEstimatorConfig()
-	clock := internal.NewMockClock(time.Now())
+	clock := clock.NewVirtual(time.Now())
 	estimator := bwe.NewBandwidthEstimator(config, clock)
 
 	// Create interceptor
@@ -95,7 +95,7 @@ func BenchmarkProcessRTP_Allocations(b *testing.B) {
 
 	// Create stream state (normally created in BindRemoteStream)
 	ssrc := uint32(0x12345678)
-	state := newStreamState(ssrc)
+	state := newStreamState(ssrc, time.Now())
 	interceptor.streams.Store(ssrc, state)
 
 	// Pre-create a valid RTP packet with abs-send-time extension
@@ -136,7 +136,7 @@ func BenchmarkInterceptor_FullPath(b *testing.B) {
 
 	// Setup estimator with mock clock
 	config := bwe.DefaultBandwidthEstimatorConfig()
-	clock := internal.NewMockClock(time.Now())
+	clock := clock.NewVirtual(time.Now())
 	estimator := bwe.NewBandwidthEstimator(config, clock)
 
 	// Create interceptor
@@ -148,7 +148,7 @@ func BenchmarkInterceptor_FullPath(b *testing.B) {
 
 	// Create stream state
 	ssrc := uint32(0x12345678)
-	state := newStreamState(ssrc)
+	state := newStreamState(ssrc, time.Now())
 	interceptor.streams.Store(ssrc, state)
 
 	// Pre-create packet
@@ -215,7 +215,7 @@ func BenchmarkPacketInfoPool_GetPut(b *testing.B) {
 func BenchmarkStreamState_Update(b *testing.B) {
 	b.ReportAllocs()
 
-	state := newStreamState(0x12345678)
+	state := newStreamState(0x12345678, time.Now())
 	now := time.Now()
 
 	b.ResetTimer()
@@ -0,0 +1,50 @@
+package interceptor
+
+import "time"
+
+// captureInterpolationMaxInterval is how long a received abs-capture-time
+// is used to derive the capture time of later frames (libwebrtc's
+// AbsoluteCaptureTimeInterpolator::kInterpolationMaxInterval).
+const captureInterpolationMaxInterval = 5 * time.Second
+
+// captureTimeInterpolator fills in the abs-capture-time of packets that do
+// not carry the extension. Senders may attach it only to the first packet
+// of a frame, or only when it drifts from the RTP timestamp: packets of the
+// same frame share its capture time, and later frames are extrapolated from
+// the RTP timestamp at the stream's clock rate.
+type captureTimeInterpolator struct {
+	clockRate uint32
+
+	// Last received extension
+	rtpTimestamp uint32
+	captureTime  uint64
+	receivedAt   time.Time
+	valid        bool
+}
+
+// OnCaptureTime records an abs-capture-time received with an RTP timestamp.
+func (c *captureTimeInterpolator) OnCaptureTime(rtpTimestamp uint32, captureTime uint64, now time.Time) {
+	c.rtpTimestamp = rtpTimestamp
+	c.captureTime = captureTime
+	c.receivedAt = now
+	c.valid = true
+}
+
+// Interpolate returns the capture time of a packet without the extension.
+// Returns (0, false) if no extension was received within the last 5s, or
+// the packet belongs to another frame and the clock rate is unknown.
+func (c *captureTimeInterpolator) Interpolate(rtpTimestamp uint32, now time.Time) (uint64, bool) {
+	if !c.valid || now.Sub(c.receivedAt) > captureInterpolationMaxInterval {
+		return 0, false
+	}
+	if rtpTimestamp == c.rtpTimestamp {
+		return c.captureTime, true
+	}
+	if c.clockRate == 0 {
+		return 0, false
+	}
+
+	// UQ32.32 offset of the RTP timestamp difference, handling wrap
+	ticks := int64(int32(rtpTimestamp - c.rtpTimestamp))
+	return uint64(int64(c.captureTime) + ticks<<32/int64(c.clockRate)), true
+}
@@ -0,0 +1,107 @@
+package interceptor
+
+import (
+	"testing"
+	"time"
+
+	"github.com/pion/interceptor"
+	"github.com/pion/rtp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/thesyncim/bwe/pkg/bwe"
+)
+
+func TestCaptureTimeInterpolator(t *testing.T) {
+	c := captureTimeInterpolator{clockRate: 90000}
+	now := time.Now()
+
+	_, ok := c.Interpolate(1000, now)
+	assert.False(t, ok, "no capture time received yet")
+
+	capture := uint64(5000) << 32
+	c.OnCaptureTime(1000, capture, now)
+
+	// Same frame
+	got, ok := c.Interpolate(1000, now)
+	require.True(t, ok)
+	assert.Equal(t, capture, got)
+
+	// Next frame, 3000 ticks = 1/30s later
+	got, ok = c.Interpolate(4000, now)
+	require.True(t, ok)
+	assert.Equal(t, capture+(1<<32)/30, got)
+
+	// Across the RTP timestamp wrap
+	c.OnCaptureTime(0xFFFF_FFFF-1499, capture, now)
+	got, ok = c.Interpolate(1500, now)
+	require.True(t, ok)
+	assert.Equal(t, capture+(1<<32)/30, got)
+
+	// Too long after the last extension
+	_, ok = c.Interpolate(1500, now.Add(6*time.Second))
+	assert.False(t, ok)
+}
+
+func TestCaptureTimeInterpolator_UnknownClockRate(t *testing.T) {
+	c := captureTimeInterpolator{}
+	now := time.Now()
+	c.OnCaptureTime(1000, 1<<32, now)
+
+	_, ok := c.Interpolate(1000, now)
+	assert.True(t, ok, "packets of the same frame need no clock rate")
+	_, ok = c.Interpolate(4000, now)
+	assert.False(t, ok)
+}
+
+func TestProcessRTP_AbsCaptureTimeFirstPacketOfFrame(t *testing.T) {
+	estimator := bwe.NewBandwidthEstimator(bwe.DefaultBandwidthEstimatorConfig(), nil)
+	i := NewBWEInterceptor(estimator)
+
+	testSSRC := uint32(0x12345678)
+	extID := uint8(5)
+	i.BindRemoteStream(&interceptor.StreamInfo{
+		SSRC:      testSSRC,
+		ClockRate: 90000,
+		RTPHeaderExtensions: []interceptor.RTPHeaderExtension{
+			{URI: AbsCaptureTimeURI, ID: int(extID)},
+		},
+	}, &mockRTPReader{})
+
+	makePacket := func(seq uint16, ts uint32, capture uint64, withExt bool) []byte {
+		pkt := &rtp.Packet{
+			Header: rtp.Header{
+				Version:        2,
+				PayloadType:    96,
+				SequenceNumber: seq,
+				Timestamp:      ts,
+				SSRC:           testSSRC,
+			},
+			Payload: make([]byte, 1000),
+		}
+		if withExt {
+			ext, err := rtp.AbsCaptureTimeExtension{Timestamp: capture}.Marshal()
+			require.NoError(t, err)
+			require.NoError(t, pkt.Header.SetExtension(extID, ext))
+		}
+		raw, err := pkt.Marshal()
+		require.NoError(t, err)
+		return raw
+	}
+
+	// Only the first packet of each frame carries abs-capture-time
+	for frame := 0; frame < 5; frame++ {
+		ts := uint32(frame * 3000)
+		capture := uint64(100)<<32 + uint64(frame)*(1<<32)/30
+		for p := 0; p < 3; p++ {
+			i.processRTP(makePacket(uint16(frame*3+p), ts, capture, p == 0), testSSRC)
+		}
+	}
+
+	assert.Contains(t, estimator.GetSSRCs(), testSSRC)
+	incoming, ok := i.streams.Load(testSSRC)
+	require.True(t, ok)
+	c := incoming.(*streamState).capture
+	assert.Equal(t, uint32(12000), c.rtpTimestamp)
+	assert.Equal(t, uint64(100)<<32+4*(1<<32)/30, c.captureTime)
+}
@@ -0,0 +1,222 @@
+package interceptor
+
+import (
+	"errors"
+	"sync"
+
+	"github.com/pion/interceptor"
+	"github.com/pion/interceptor/pkg/cc"
+	"github.com/pion/rtcp"
+
+	"github.com/thesyncim/bwe/pkg/bwe"
+)
+
+// ErrCCEstimatorClosed is returned by WriteRTCP after Close has been called.
+var ErrCCEstimatorClosed = errors.New("bandwidth estimator closed")
+
+// Compile-time check that CCEstimator satisfies Pion's interface.
+var _ cc.BandwidthEstimator = (*CCEstimator)(nil)
+
+// CCEstimator adapts the sender-side GCC estimator to Pion's
+// cc.BandwidthEstimator interface, so applications written against
+// github.com/pion/interceptor/pkg/cc can swap in this implementation without
+// changing their media code.
+//
+// Usage:
+//
+//	factory, err := cc.NewInterceptor(NewCCEstimatorFactory())
+//	if err != nil {
+//	    return err
+//	}
+//	factory.OnNewPeerConnection(func(id string, estimator cc.BandwidthEstimator) {
+//	    estimator.OnTargetBitrateChange(encoder.SetBitrate)
+//	})
+//	registry.Add(factory)
+//
+// Outgoing packets are tagged with transport-wide sequence numbers unless a
+// previous interceptor (e.g. Pion's twcc header extension interceptor) already
+// assigned one, in which case that number is reused.
+type CCEstimator struct {
+	sendSide *SendSideInterceptor
+
+	mu     sync.RWMutex
+	closed bool
+
+	cbMu     sync.Mutex
+	onChange func(bitrate int)
+}
+
+// CCEstimatorOption configures a CCEstimator.
+type CCEstimatorOption func(*ccEstimatorOptions) error
+
+// ccEstimatorOptions holds the settings applied by CCEstimatorOption.
+type ccEstimatorOptions struct {
+	config bwe.SendSideConfig
+}
+
+// WithCCConfig sets the configuration for the underlying sender-side estimator.
+// Default: bwe.DefaultSendSideConfig()
+func WithCCConfig(config bwe.SendSideConfig) CCEstimatorOption {
+	return func(o *ccEstimatorOptions) error {
+		o.config = config
+		return nil
+	}
+}
+
+// WithCCInitialBitrate sets the initial target bitrate in bits per second.
+// Default: 300000 (300 kbps)
+func WithCCInitialBitrate(bitrate int) CCEstimatorOption {
+	return func(o *ccEstimatorOptions) error {
+		if bitrate <= 0 {
+			return errors.New("initial bitrate must be positive")
+		}
+		o.config.RateControllerConfig.InitialBitrate = int64(bitrate)
+		return nil
+	}
+}
+
+// WithCCMinBitrate sets the minimum target bitrate in bits per second.
+// Default: 10000 (10 kbps)
+func WithCCMinBitrate(bitrate int) CCEstimatorOption {
+	return func(o *ccEstimatorOptions) error {
+		if bitrate <= 0 {
+			return errors.New("min bitrate must be positive")
+		}
+		o.config.RateControllerConfig.MinBitrate = int64(bitrate)
+		return nil
+	}
+}
+
+// WithCCMaxBitrate sets the maximum target bitrate in bits per second.
+// Default: 30000000 (30 Mbps)
+func WithCCMaxBitrate(bitrate int) CCEstimatorOption {
+	return func(o *ccEstimatorOptions) error {
+		if bitrate <= 0 {
+			return errors.New("max bitrate must be positive")
+		}
+		o.config.RateControllerConfig.MaxBitrate = int64(bitrate)
+		return nil
+	}
+}
+
+// NewCCEstimator creates a new CCEstimator backed by a bwe.SendSideEstimator.
+func NewCCEstimator(opts ...CCEstimatorOption) (*CCEstimator, error) {
+	o := ccEstimatorOptions{config: bwe.DefaultSendSideConfig()}
+	for _, opt := range opts {
+		if err := opt(&o); err != nil {
+			return nil, err
+		}
+	}
+	if o.config.RateControllerConfig.MinBitrate > o.config.RateControllerConfig.MaxBitrate {
+		return nil, errors.New("min bitrate must not exceed max bitrate")
+	}
+
+	e := &CCEstimator{
+		sendSide: NewSendSideInterceptor(bwe.NewSendSideEstimator(o.config, nil)),
+	}
+	e.sendSide.OnTargetBitrateChange(e.notify)
+	return e, nil
+}
+
+// NewCCEstimatorFactory returns a cc.BandwidthEstimatorFactory that creates a
+// CCEstimator per PeerConnection. Pass it to cc.NewInterceptor.
+func NewCCEstimatorFactory(opts ...CCEstimatorOption) cc.BandwidthEstimatorFactory {
+	return func() (cc.BandwidthEstimator, error) {
+		return NewCCEstimator(opts...)
+	}
+}
+
+// AddStream registers a local stream with the estimator. It returns a writer
+// that tags each packet with a transport-wide sequence number and records it
+// for matching with transport-cc feedback. Streams without the transport-cc
+// header extension are passed through unchanged.
+func (e *CCEstimator) AddStream(info *interceptor.StreamInfo, writer interceptor.RTPWriter) interceptor.RTPWriter {
+	return e.sendSide.BindLocalStream(info, writer)
+}
+
+// WriteRTCP feeds incoming RTCP feedback to the estimator.
+// Only transport-cc feedback is consumed; other packets are ignored.
+func (e *CCEstimator) WriteRTCP(pkts []rtcp.Packet, _ interceptor.Attributes) error {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	if e.closed {
+		return ErrCCEstimatorClosed
+	}
+
+	for _, pkt := range pkts {
+		if fb, ok := pkt.(*rtcp.TransportLayerCC); ok {
+			e.sendSide.onTransportCC(fb)
+		}
+	}
+	return nil
+}
+
+// GetTargetBitrate returns the current target bitrate in bits per second.
+func (e *CCEstimator) GetTargetBitrate() int {
+	return int(e.sendSide.GetTargetBitrate())
+}
+
+// OnTargetBitrateChange sets the callback invoked when the target bitrate
+// changes after processing feedback.
+func (e *CCEstimator) OnTargetBitrateChange(f func(bitrate int)) {
+	e.cbMu.Lock()
+	defer e.cbMu.Unlock()
+	e.onChange = f
+}
+
+// notify forwards target changes from the sender-side interceptor.
+func (e *CCEstimator) notify(bitrate int64) {
+	e.cbMu.Lock()
+	cb := e.onChange
+	e.cbMu.Unlock()
+	if cb != nil {
+		cb(int(bitrate))
+	}
+}
+
+// GetStats returns internal statistics of the estimator.
+//
+// Keys:
+//   - "targetBitrate": combined target in bps (int)
+//   - "delayTargetBitrate": delay-based AIMD estimate in bps (int)
+//   - "lossTargetBitrate": loss-based bound in bps, 0 if inactive (int)
+//   - "averageLoss": fraction of packets lost over the loss window (float64)
+//   - "ackedBitrate": acknowledged throughput in bps, 0 if unknown (int)
+//   - "delayEstimate": delay filter output in ms (float64)
+//   - "delayThreshold": adaptive overuse threshold in ms (float64)
+//   - "usage": bandwidth usage state (string)
+//   - "state": AIMD rate control state (string)
+//   - "activeBound": controller limiting the target (string)
+func (e *CCEstimator) GetStats() map[string]any {
+	est := e.sendSide.Estimator()
+	loss, _ := est.GetLossBasedEstimate()
+	fraction, _ := est.GetLossFraction()
+	acked, _ := est.GetAckedBitrate()
+
+	return map[string]any{
+		"targetBitrate":      int(est.TargetBitrate()),
+		"delayTargetBitrate": int(est.GetDelayBasedEstimate()),
+		"lossTargetBitrate":  int(loss),
+		"averageLoss":        fraction,
+		"ackedBitrate":       int(acked),
+		"delayEstimate":      est.GetDelayFilterOutput(),
+		"delayThreshold":     est.GetThreshold(),
+		"usage":              est.GetCongestionState().String(),
+		"state":              est.GetRateControlState().String(),
+		"activeBound":        est.GetActiveBound().String(),
+	}
+}
+
+// Estimator returns the underlying sender-side estimator.
+func (e *CCEstimator) Estimator() *bwe.SendSideEstimator {
+	return e.sendSide.Estimator()
+}
+
+// Close stops the estimator. Subsequent WriteRTCP calls return
+// ErrCCEstimatorClosed. Close is idempotent.
+func (e *CCEstimator) Close() error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.closed = true
+	return nil
+}
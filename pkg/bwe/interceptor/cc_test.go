@@ -0,0 +1,136 @@
+package interceptor
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/pion/interceptor"
+	"github.com/pion/interceptor/pkg/cc"
+	"github.com/pion/interceptor/pkg/twcc"
+	"github.com/pion/rtcp"
+	"github.com/pion/rtp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/thesyncim/bwe/pkg/bwe"
+)
+
+func TestCCEstimator_Options(t *testing.T) {
+	e, err := NewCCEstimator(WithCCInitialBitrate(500_000), WithCCMinBitrate(50_000), WithCCMaxBitrate(2_000_000))
+	require.NoError(t, err)
+	assert.Equal(t, 500_000, e.GetTargetBitrate())
+
+	_, err = NewCCEstimator(WithCCInitialBitrate(0))
+	assert.Error(t, err)
+
+	_, err = NewCCEstimator(WithCCMinBitrate(-1))
+	assert.Error(t, err)
+
+	_, err = NewCCEstimator(WithCCMinBitrate(2_000_000), WithCCMaxBitrate(1_000_000))
+	assert.Error(t, err, "min above max should be rejected")
+}
+
+func TestCCEstimator_Feedback(t *testing.T) {
+	e, err := NewCCEstimator()
+	require.NoError(t, err)
+
+	var mu sync.Mutex
+	var targets []int
+	e.OnTargetBitrateChange(func(bitrate int) {
+		mu.Lock()
+		targets = append(targets, bitrate)
+		mu.Unlock()
+	})
+
+	info := &interceptor.StreamInfo{
+		SSRC: 0x1234,
+		RTPHeaderExtensions: []interceptor.RTPHeaderExtension{
+			{URI: TransportCCURI, ID: 5},
+		},
+	}
+	sink := &capturingRTPWriter{extID: 5}
+	writer := e.AddStream(info, sink)
+
+	recorder := twcc.NewRecorder(1)
+	seq := uint16(0)
+	for report := 0; report < 20; report++ {
+		for j := 0; j < 10; j++ {
+			_, err := writer.Write(&rtp.Header{Version: 2, SSRC: 0x1234}, make([]byte, 1000), nil)
+			require.NoError(t, err)
+			recorder.Record(0x1234, seq, int64(seq)*10_000)
+			seq++
+			time.Sleep(time.Millisecond)
+		}
+		require.NoError(t, e.WriteRTCP(recorder.BuildFeedbackPacket(), nil))
+	}
+
+	require.Len(t, sink.seqs, 200, "every packet should carry a transport sequence number")
+
+	mu.Lock()
+	require.NotEmpty(t, targets, "target bitrate callback should fire")
+	assert.Equal(t, e.GetTargetBitrate(), targets[len(targets)-1])
+	mu.Unlock()
+
+	_, ok := e.Estimator().GetAckedBitrate()
+	assert.True(t, ok, "feedback should produce an acknowledged bitrate")
+}
+
+func TestCCEstimator_IgnoresOtherRTCP(t *testing.T) {
+	e, err := NewCCEstimator()
+	require.NoError(t, err)
+
+	err = e.WriteRTCP([]rtcp.Packet{
+		&rtcp.ReceiverReport{SSRC: 1},
+		&rtcp.PictureLossIndication{MediaSSRC: 2},
+	}, nil)
+	require.NoError(t, err)
+	assert.Equal(t, int(bwe.DefaultRateControllerConfig().InitialBitrate), e.GetTargetBitrate())
+}
+
+func TestCCEstimator_GetStats(t *testing.T) {
+	e, err := NewCCEstimator()
+	require.NoError(t, err)
+
+	stats := e.GetStats()
+	for _, key := range []string{
+		"targetBitrate", "delayTargetBitrate", "lossTargetBitrate", "averageLoss",
+		"ackedBitrate", "delayEstimate", "delayThreshold", "usage", "state", "activeBound",
+	} {
+		assert.Contains(t, stats, key)
+	}
+
+	assert.Equal(t, 300_000, stats["targetBitrate"])
+	assert.Equal(t, 0.0, stats["delayEstimate"])
+	assert.Equal(t, bwe.DefaultOveruseConfig().InitialThreshold, stats["delayThreshold"])
+	assert.Equal(t, bwe.BwNormal.String(), stats["usage"])
+	assert.Equal(t, bwe.RateHold.String(), stats["state"])
+}
+
+func TestCCEstimator_Close(t *testing.T) {
+	e, err := NewCCEstimator()
+	require.NoError(t, err)
+
+	require.NoError(t, e.Close())
+	require.NoError(t, e.Close(), "close should be idempotent")
+	assert.ErrorIs(t, e.WriteRTCP([]rtcp.Packet{&rtcp.TransportLayerCC{}}, nil), ErrCCEstimatorClosed)
+}
+
+func TestCCEstimator_PionInterceptorFactory(t *testing.T) {
+	factory, err := cc.NewInterceptor(NewCCEstimatorFactory(WithCCInitialBitrate(1_000_000)))
+	require.NoError(t, err)
+
+	var got cc.BandwidthEstimator
+	factory.OnNewPeerConnection(func(_ string, estimator cc.BandwidthEstimator) {
+		got = estimator
+	})
+
+	i, err := factory.NewInterceptor("pc-1")
+	require.NoError(t, err)
+	defer i.Close()
+
+	require.NotNil(t, got)
+	_, ok := got.(*CCEstimator)
+	assert.True(t, ok, "pion should receive our estimator")
+	assert.Equal(t, 1_000_000, got.GetTargetBitrate())
+}
@@ -0,0 +1,94 @@
+package interceptor
+
+import (
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"github.com/pion/interceptor"
+
+	"github.com/thesyncim/bwe/pkg/bwe"
+)
+
+// packetClasses is an immutable snapshot of the negotiated RTX and FEC
+// payload types and SSRCs.
+type packetClasses struct {
+	payloadTypes [128]bwe.PacketClass
+	ssrcs        map[uint32]bwe.PacketClass
+}
+
+// packetClassifier classifies received packets as media, padding-only, RTX
+// or FEC. Streams are added from BindRemoteStream; lookups on the packet
+// path read a snapshot without locking or allocating.
+type packetClassifier struct {
+	mu      sync.Mutex // Serializes writers
+	classes atomic.Pointer[packetClasses]
+}
+
+// AddStream records the RTX and FEC payload types and SSRCs of a stream.
+// A stream whose own codec is RTX (a video/rtx MIME type or an apt= fmtp
+// parameter), ULPFEC or FlexFEC is classified by its SSRC and payload type.
+func (c *packetClassifier) AddStream(info *interceptor.StreamInfo) {
+	type entry struct {
+		pt    uint8
+		ssrc  uint32
+		class bwe.PacketClass
+	}
+	entries := []entry{
+		{info.PayloadTypeRetransmission, info.SSRCRetransmission, bwe.PacketRTX},
+		{info.PayloadTypeForwardErrorCorrection, info.SSRCForwardErrorCorrection, bwe.PacketFEC},
+	}
+	if class := codecClass(info); class != bwe.PacketMedia {
+		entries = append(entries, entry{info.PayloadType, info.SSRC, class})
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	next := &packetClasses{ssrcs: make(map[uint32]bwe.PacketClass)}
+	if old := c.classes.Load(); old != nil {
+		next.payloadTypes = old.payloadTypes
+		for ssrc, class := range old.ssrcs {
+			next.ssrcs[ssrc] = class
+		}
+	}
+	for _, e := range entries {
+		if e.pt != 0 {
+			next.payloadTypes[e.pt&0x7F] = e.class
+		}
+		if e.ssrc != 0 {
+			next.ssrcs[e.ssrc] = e.class
+		}
+	}
+	c.classes.Store(next)
+}
+
+// codecClass returns the class of a stream's own codec.
+func codecClass(info *interceptor.StreamInfo) bwe.PacketClass {
+	mime := strings.ToLower(info.MimeType)
+	switch {
+	case strings.HasSuffix(mime, "/rtx") || strings.Contains(info.SDPFmtpLine, "apt="):
+		return bwe.PacketRTX
+	case strings.HasSuffix(mime, "/ulpfec") || strings.Contains(mime, "/flexfec"):
+		return bwe.PacketFEC
+	default:
+		return bwe.PacketMedia
+	}
+}
+
+// Classify returns the class of a packet. Padding-only packets are
+// PacketPadding whatever stream they belong to; otherwise the SSRC, then
+// the payload type, decides.
+func (c *packetClassifier) Classify(pkt *bwe.PacketInfo) bwe.PacketClass {
+	if pkt.PaddingOnly() {
+		return bwe.PacketPadding
+	}
+	classes := c.classes.Load()
+	if classes == nil {
+		return bwe.PacketMedia
+	}
+	if class, ok := classes.ssrcs[pkt.SSRC]; ok {
+		return class
+	}
+	return classes.payloadTypes[pkt.PayloadType&0x7F]
+}
@@ -0,0 +1,71 @@
+package interceptor
+
+import (
+	"testing"
+
+	"github.com/pion/interceptor"
+	"github.com/pion/rtp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/thesyncim/bwe/pkg/bwe"
+)
+
+func TestPacketClassifier_StreamInfo(t *testing.T) {
+	var c packetClassifier
+
+	// Before any stream is bound everything is media
+	assert.Equal(t, bwe.PacketMedia, c.Classify(&bwe.PacketInfo{SSRC: 1, PayloadType: 97}))
+
+	c.AddStream(&interceptor.StreamInfo{
+		SSRC:                              1,
+		PayloadType:                       96,
+		SSRCRetransmission:                2,
+		PayloadTypeRetransmission:         97,
+		SSRCForwardErrorCorrection:        3,
+		PayloadTypeForwardErrorCorrection: 98,
+	})
+	c.AddStream(&interceptor.StreamInfo{SSRC: 10, PayloadType: 100, MimeType: "video/rtx", SDPFmtpLine: "apt=96"})
+	c.AddStream(&interceptor.StreamInfo{SSRC: 11, PayloadType: 101, MimeType: "video/flexfec-03"})
+
+	tests := []struct {
+		name string
+		pkt  bwe.PacketInfo
+		want bwe.PacketClass
+	}{
+		{"media", bwe.PacketInfo{SSRC: 1, PayloadType: 96, Size: 100, HeaderSize: 12}, bwe.PacketMedia},
+		{"RTX by SSRC", bwe.PacketInfo{SSRC: 2, PayloadType: 96, Size: 100, HeaderSize: 12}, bwe.PacketRTX},
+		{"RTX by payload type", bwe.PacketInfo{SSRC: 1, PayloadType: 97, Size: 100, HeaderSize: 12}, bwe.PacketRTX},
+		{"FEC by SSRC", bwe.PacketInfo{SSRC: 3, PayloadType: 96, Size: 100, HeaderSize: 12}, bwe.PacketFEC},
+		{"FEC by payload type", bwe.PacketInfo{SSRC: 1, PayloadType: 98, Size: 100, HeaderSize: 12}, bwe.PacketFEC},
+		{"RTX stream", bwe.PacketInfo{SSRC: 10, PayloadType: 100, Size: 100, HeaderSize: 12}, bwe.PacketRTX},
+		{"FlexFEC stream", bwe.PacketInfo{SSRC: 11, PayloadType: 101, Size: 100, HeaderSize: 12}, bwe.PacketFEC},
+		{"padding-only on RTX", bwe.PacketInfo{SSRC: 2, Padding: true, Size: 212, HeaderSize: 12, PaddingSize: 200}, bwe.PacketPadding},
+		{"padded media", bwe.PacketInfo{SSRC: 1, PayloadType: 96, Padding: true, Size: 100, HeaderSize: 12, PaddingSize: 4}, bwe.PacketMedia},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, c.Classify(&tt.pkt), tt.name)
+	}
+}
+
+func TestProcessRTP_IgnoresRTXByPolicy(t *testing.T) {
+	config := bwe.DefaultBandwidthEstimatorConfig()
+	config.PacketPolicyConfig.RTX = bwe.PolicyIgnore
+	estimator := bwe.NewBandwidthEstimator(config, nil)
+	i := NewBWEInterceptor(estimator)
+
+	extID := uint8(3)
+	exts := []interceptor.RTPHeaderExtension{{URI: AbsSendTimeURI, ID: int(extID)}}
+	i.BindRemoteStream(&interceptor.StreamInfo{SSRC: 1, PayloadType: 96, SSRCRetransmission: 2, PayloadTypeRetransmission: 97, RTPHeaderExtensions: exts}, &mockRTPReader{})
+	i.BindRemoteStream(&interceptor.StreamInfo{SSRC: 2, PayloadType: 97, RTPHeaderExtensions: exts}, &mockRTPReader{})
+
+	rtx := &rtp.Packet{Header: rtp.Header{Version: 2, PayloadType: 97, SSRC: 2}, Payload: []byte{0x04, 0xD2, 0x00}}
+	require.NoError(t, rtx.Header.SetExtension(extID, []byte{0x01, 0x00, 0x00}))
+	raw, err := rtx.Marshal()
+	require.NoError(t, err)
+	i.processRTP(raw, 2)
+	assert.Empty(t, estimator.GetSSRCs(), "RTX packet should be ignored")
+
+	i.processRTP(makeRTPWithAbsSendTime(1, extID, 0x010000), 1)
+	assert.Equal(t, []uint32{1}, estimator.GetSSRCs())
+}
@@ -52,6 +52,53 @@
 //	    bweint.WithFactoryREMBInterval(500*time.Millisecond), // Send REMB twice per second
 //	)
 //
+// # Feedback Modes
+//
+// By default the interceptor sends REMB feedback carrying its own estimate.
+// It can instead (or additionally) send transport-wide congestion control
+// feedback, letting senders that run their own estimator (Chrome, libwebrtc,
+// Pion's cc package) do the estimation:
+//
+//	factory, err := bweint.NewBWEInterceptorFactory(
+//	    bweint.WithFactoryFeedbackMode(bweint.FeedbackBoth),      // REMB and transport-cc
+//	    bweint.WithFactoryTWCCInterval(100*time.Millisecond),     // transport-cc cadence
+//	)
+//
+// Transport-cc feedback requires the transport-wide sequence number extension
+// (TransportCCURI) to be negotiated.
+//
+// # Round-Trip Time
+//
+// The estimator sizes its additive increase and paces its decreases by RTT.
+// The interceptor measures RTT from incoming RTCP: report blocks echoing our
+// sender reports (LSR/DLSR), and DLRR replies to RTCP XR receiver reference
+// time reports. Receive-only peers rely on the XR path, which the remote
+// sender must support, so RRTR is off until an interval is set:
+//
+//	factory, err := bweint.NewBWEInterceptorFactory(
+//	    bweint.WithFactoryRRTRInterval(time.Second),
+//	)
+//
+// Report blocks count only for SSRCs of bound local streams, and their LSR
+// is compared with the clock that stamps our sender reports, set with
+// WithFactorySenderReportClock. It defaults to the system clock, as used by
+// Pion's report interceptor.
+//
+// # Sender-Side Estimation
+//
+// SendSideInterceptor runs GCC on the sending peer from transport-cc feedback.
+// Applications already written against Pion's cc package can use CCEstimator,
+// which implements cc.BandwidthEstimator:
+//
+//	ccFactory, err := cc.NewInterceptor(bweint.NewCCEstimatorFactory())
+//	if err != nil {
+//	    return err
+//	}
+//	ccFactory.OnNewPeerConnection(func(id string, estimator cc.BandwidthEstimator) {
+//	    estimator.OnTargetBitrateChange(encoder.SetBitrate)
+//	})
+//	registry.Add(ccFactory)
+//
 // # How It Works
 //
 // 1. When a remote stream is bound (BindRemoteStream), the interceptor extracts
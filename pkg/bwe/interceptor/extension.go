@@ -18,6 +18,11 @@ const (
 	// AbsCaptureTimeURI is the URI for the absolute capture time extension (8-byte, 64-bit).
 	// Contains the NTP timestamp when the frame was captured.
 	AbsCaptureTimeURI = "http://www.webrtc.org/experiments/rtp-hdrext/abs-capture-time"
+
+	// TransportCCURI is the URI for the transport-wide sequence number extension (2-byte).
+	// Contains a 16-bit sequence number shared by all streams on the transport,
+	// used to generate transport-cc feedback.
+	TransportCCURI = "http://www.ietf.org/id/draft-holmer-rmcat-transport-wide-cc-extensions-01"
 )
 
 // FindExtensionID searches for an extension with the given URI in the list
@@ -50,3 +55,11 @@ func FindAbsSendTimeID(exts []interceptor.RTPHeaderExtension) uint8 {
 func FindAbsCaptureTimeID(exts []interceptor.RTPHeaderExtension) uint8 {
 	return FindExtensionID(exts, AbsCaptureTimeURI)
 }
+
+// FindTransportCCID is a convenience function that searches for the
+// transport-wide sequence number extension ID in the list of negotiated extensions.
+//
+// Returns 0 if transport-cc was not negotiated.
+func FindTransportCCID(exts []interceptor.RTPHeaderExtension) uint8 {
+	return FindExtensionID(exts, TransportCCURI)
+}
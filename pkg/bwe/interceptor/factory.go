@@ -2,11 +2,15 @@ package interceptor
 
 import (
 	"errors"
+	"strconv"
+	"sync/atomic"
 	"time"
 
 	"github.com/pion/interceptor"
 
 	"github.com/thesyncim/bwe/pkg/bwe"
+	"github.com/thesyncim/bwe/pkg/bwe/clock"
+	"github.com/thesyncim/bwe/pkg/bwe/metrics"
 )
 
 // FactoryOption configures the BWEInterceptorFactory.
@@ -20,6 +24,14 @@ type BWEInterceptorFactory struct {
 	rembInterval time.Duration
 	senderSSRC   uint32
 	onREMB       func(bitrate float32, ssrcs []uint32)
+	feedbackMode FeedbackMode
+	twccInterval time.Duration
+	rrtrInterval time.Duration
+	rtpFallback  bool
+	clock        clock.Clock
+	srClock      clock.Clock
+	metrics      *metrics.Registry
+	sessions     atomic.Uint64 // Names unnamed metrics sessions
 }
 
 // WithInitialBitrate sets the initial bandwidth estimate.
@@ -80,6 +92,107 @@ func WithFactoryOnREMB(fn func(bitrate float32, ssrcs []uint32)) FactoryOption {
 	}
 }
 
+// WithFactoryFeedbackMode selects which congestion control feedback the
+// interceptors send: FeedbackREMB, FeedbackTransportCC or FeedbackBoth.
+// Default: FeedbackREMB
+func WithFactoryFeedbackMode(mode FeedbackMode) FactoryOption {
+	return func(f *BWEInterceptorFactory) error {
+		if !mode.valid() {
+			return errors.New("invalid feedback mode")
+		}
+		f.feedbackMode = mode
+		return nil
+	}
+}
+
+// WithFactoryTWCCInterval sets how often transport-cc feedback is sent.
+// Default: 100ms
+func WithFactoryTWCCInterval(interval time.Duration) FactoryOption {
+	return func(f *BWEInterceptorFactory) error {
+		if interval <= 0 {
+			return errors.New("transport-cc interval must be positive")
+		}
+		f.twccInterval = interval
+		return nil
+	}
+}
+
+// WithFactoryRRTRInterval sets how often RTCP XR receiver reference time
+// reports are sent for RTT measurement. Zero disables them.
+// Default: 0 (disabled)
+func WithFactoryRRTRInterval(interval time.Duration) FactoryOption {
+	return func(f *BWEInterceptorFactory) error {
+		if interval < 0 {
+			return errors.New("RRTR interval must not be negative")
+		}
+		f.rrtrInterval = interval
+		return nil
+	}
+}
+
+// WithFactoryRTPTimestampFallback derives send times from RTP timestamps
+// for streams without abs-send-time or abs-capture-time, so audio-only and
+// minimal senders still get an estimate. It also switches the delay-based
+// detector, ALR and probe settings to bwe.DefaultSingleStreamConfig; rate
+// limits set with other options are kept.
+// Default: disabled
+func WithFactoryRTPTimestampFallback() FactoryOption {
+	return func(f *BWEInterceptorFactory) error {
+		single := bwe.DefaultSingleStreamConfig()
+		f.config.DelayConfig = single.DelayConfig
+		f.config.PerStreamConfig = single.PerStreamConfig
+		f.config.ALRConfig = single.ALRConfig
+		f.config.ProbeConfig = single.ProbeConfig
+		f.rtpFallback = true
+		return nil
+	}
+}
+
+// WithFactoryPacketPolicies sets how padding-only, RTX and FEC packets are
+// used by the estimator. Packets are classified from the negotiated RTX and
+// FEC payload types and SSRCs and the RTP padding bit.
+// Default: bwe.DefaultPacketPolicyConfig
+func WithFactoryPacketPolicies(config bwe.PacketPolicyConfig) FactoryOption {
+	return func(f *BWEInterceptorFactory) error {
+		f.config.PacketPolicyConfig = config
+		return nil
+	}
+}
+
+// WithFactoryClock sets the time source shared by each interceptor and its
+// estimator. Use a clock.Virtual to run whole sessions in simulated time.
+// Default: clock.Monotonic
+func WithFactoryClock(c clock.Clock) FactoryOption {
+	return func(f *BWEInterceptorFactory) error {
+		f.clock = c
+		return nil
+	}
+}
+
+// WithFactorySenderReportClock sets the time source that stamps the sender
+// reports sent on the PeerConnection. See WithSenderReportClock.
+// Default: clock.Monotonic
+func WithFactorySenderReportClock(c clock.Clock) FactoryOption {
+	return func(f *BWEInterceptorFactory) error {
+		f.srClock = c
+		return nil
+	}
+}
+
+// WithFactoryMetrics registers each interceptor's estimator with the
+// registry, under the id passed to NewInterceptor or, if it is empty, a
+// generated "session-N" name. The session is unregistered when the
+// interceptor is closed.
+func WithFactoryMetrics(r *metrics.Registry) FactoryOption {
+	return func(f *BWEInterceptorFactory) error {
+		if r == nil {
+			return errors.New("metrics registry must not be nil")
+		}
+		f.metrics = r
+		return nil
+	}
+}
+
 // NewBWEInterceptorFactory creates a new factory for BWEInterceptor instances.
 // Configure the factory using FactoryOption functions.
 //
@@ -98,6 +211,9 @@ func NewBWEInterceptorFactory(opts ...FactoryOption) (*BWEInterceptorFactory, er
 		config:       bwe.DefaultBandwidthEstimatorConfig(),
 		rembInterval: time.Second,
 		senderSSRC:   0,
+		feedbackMode: FeedbackREMB,
+		twccInterval: defaultTWCCInterval,
+		rrtrInterval: defaultRRTRInterval,
 	}
 	for _, opt := range opts {
 		if err := opt(f); err != nil {
@@ -109,14 +225,20 @@ func NewBWEInterceptorFactory(opts ...FactoryOption) (*BWEInterceptorFactory, er
 
 // NewInterceptor creates a new BWEInterceptor for a PeerConnection.
 // This method is called by the interceptor registry when setting up a connection.
-func (f *BWEInterceptorFactory) NewInterceptor(_ string) (interceptor.Interceptor, error) {
+func (f *BWEInterceptorFactory) NewInterceptor(id string) (interceptor.Interceptor, error) {
 	// Create a new BandwidthEstimator with factory config
-	estimator := bwe.NewBandwidthEstimator(f.config, nil)
+	estimator := bwe.NewBandwidthEstimator(f.config, f.clock)
 
 	// Build options list
 	opts := []InterceptorOption{
 		WithREMBInterval(f.rembInterval),
 		WithSenderSSRC(f.senderSSRC),
+		WithFeedbackMode(f.feedbackMode),
+		WithTWCCInterval(f.twccInterval),
+		WithRRTRInterval(f.rrtrInterval),
+		WithRTPTimestampFallback(f.rtpFallback),
+		WithClock(f.clock),
+		WithSenderReportClock(f.srClock),
 	}
 	if f.onREMB != nil {
 		opts = append(opts, WithOnREMB(f.onREMB))
@@ -125,5 +247,16 @@ func (f *BWEInterceptorFactory) NewInterceptor(_ string) (interceptor.Intercepto
 	// Create interceptor with configured options
 	i := NewBWEInterceptor(estimator, opts...)
 
+	if f.metrics != nil {
+		name := id
+		if name == "" {
+			name = "session-" + strconv.FormatUint(f.sessions.Add(1), 10)
+		}
+		if err := f.metrics.Register(name, estimator); err != nil {
+			return nil, err
+		}
+		i.onClose = func() { f.metrics.Unregister(name) }
+	}
+
 	return i, nil
 }
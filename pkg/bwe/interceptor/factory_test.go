@@ -1,11 +1,16 @@
 package interceptor
 
 import (
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+
+	"github.com/thesyncim/bwe/pkg/bwe"
+	"github.com/thesyncim/bwe/pkg/bwe/clock"
+	"github.com/thesyncim/bwe/pkg/bwe/metrics"
 )
 
 func TestNewBWEInterceptorFactory_Defaults(t *testing.T) {
@@ -35,6 +40,29 @@ func TestNewBWEInterceptorFactory_WithOptions(t *testing.T) {
 	assert.Equal(t, uint32(12345), factory.senderSSRC)
 }
 
+func TestNewBWEInterceptorFactory_RTPTimestampFallback(t *testing.T) {
+	factory, err := NewBWEInterceptorFactory(
+		WithMaxBitrate(5000000),
+		WithFactoryRTPTimestampFallback(),
+	)
+	require.NoError(t, err)
+
+	assert.True(t, factory.rtpFallback)
+	assert.True(t, factory.config.PerStreamConfig.Enabled)
+	assert.Equal(t, int64(5000000), factory.config.RateControllerConfig.MaxBitrate, "rate limits are kept")
+
+	i, err := factory.NewInterceptor("")
+	require.NoError(t, err)
+	assert.True(t, i.(*BWEInterceptor).rtpTimestampFallback)
+}
+
+func TestNewBWEInterceptorFactory_PacketPolicies(t *testing.T) {
+	policies := bwe.PacketPolicyConfig{RTX: bwe.PolicyIgnore, FEC: bwe.PolicyRateOnly}
+	factory, err := NewBWEInterceptorFactory(WithFactoryPacketPolicies(policies))
+	require.NoError(t, err)
+	assert.Equal(t, policies, factory.config.PacketPolicyConfig)
+}
+
 func TestNewBWEInterceptorFactory_InvalidOption(t *testing.T) {
 	_, err := NewBWEInterceptorFactory(
 		WithFactoryREMBInterval(-1 * time.Second),
@@ -69,6 +97,66 @@ func TestBWEInterceptorFactory_NewInterceptor(t *testing.T) {
 	assert.NoError(t, err)
 }
 
+func TestBWEInterceptorFactory_Clock(t *testing.T) {
+	factory, err := NewBWEInterceptorFactory()
+	require.NoError(t, err)
+	i, err := factory.NewInterceptor("default")
+	require.NoError(t, err)
+	defer i.Close()
+	assert.Equal(t, clock.Monotonic{}, i.(*BWEInterceptor).clock)
+
+	clk := clock.NewVirtual(time.Time{})
+	factory, err = NewBWEInterceptorFactory(WithFactoryClock(clk))
+	require.NoError(t, err)
+	i, err = factory.NewInterceptor("virtual")
+	require.NoError(t, err)
+	defer i.Close()
+	assert.Same(t, clk, i.(*BWEInterceptor).clock)
+	assert.Equal(t, clock.Monotonic{}, i.(*BWEInterceptor).srClock, "sender reports keep the system clock")
+
+	srClock := clock.NewVirtual(time.Time{})
+	factory, err = NewBWEInterceptorFactory(WithFactorySenderReportClock(srClock))
+	require.NoError(t, err)
+	i, err = factory.NewInterceptor("sr")
+	require.NoError(t, err)
+	defer i.Close()
+	assert.Same(t, srClock, i.(*BWEInterceptor).srClock)
+}
+
+func TestBWEInterceptorFactory_Metrics(t *testing.T) {
+	_, err := NewBWEInterceptorFactory(WithFactoryMetrics(nil))
+	assert.Error(t, err)
+
+	registry := metrics.NewRegistry(metrics.DefaultConfig())
+	factory, err := NewBWEInterceptorFactory(WithFactoryMetrics(registry))
+	require.NoError(t, err)
+
+	named, err := factory.NewInterceptor("pc-1")
+	require.NoError(t, err)
+	first, err := factory.NewInterceptor("")
+	require.NoError(t, err)
+	second, err := factory.NewInterceptor("")
+	require.NoError(t, err)
+	assert.Equal(t, 3, registry.NumSessions())
+
+	// A registered name cannot be reused while its interceptor is open
+	_, err = factory.NewInterceptor("pc-1")
+	assert.ErrorIs(t, err, metrics.ErrDuplicateSession)
+
+	var out strings.Builder
+	_, err = registry.WriteTo(&out)
+	require.NoError(t, err)
+	for _, session := range []string{"pc-1", "session-1", "session-2"} {
+		assert.Contains(t, out.String(), `bwe_estimate_bps{session="`+session+`"}`)
+	}
+
+	// Closing an interceptor unregisters its session
+	require.NoError(t, named.Close())
+	require.NoError(t, first.Close())
+	require.NoError(t, second.Close())
+	assert.Equal(t, 0, registry.NumSessions())
+}
+
 func TestBWEInterceptorFactory_NewInterceptor_WithOptions(t *testing.T) {
 	factory, err := NewBWEInterceptorFactory(
 		WithInitialBitrate(1000000),
@@ -12,6 +12,7 @@ import (
 	"github.com/pion/rtp"
 
 	"github.com/thesyncim/bwe/pkg/bwe"
+	"github.com/thesyncim/bwe/pkg/bwe/clock"
 )
 
 const (
@@ -40,6 +41,12 @@ type BWEInterceptor struct {
 	// Extension IDs (atomic for concurrent access)
 	absExtID     atomic.Uint32
 	captureExtID atomic.Uint32
+	twccExtID    atomic.Uint32
+
+	// Feedback selection and transport-cc state
+	feedbackMode FeedbackMode
+	twccInterval time.Duration
+	twcc         *twccFeedback
 
 	// RTCP writer and REMB scheduling
 	mu           sync.Mutex
@@ -48,7 +55,24 @@ type BWEInterceptor struct {
 	senderSSRC   uint32
 	onREMB       func(bitrate float32, ssrcs []uint32)
 
+	// RTT measurement from RTCP
+	rrtrInterval time.Duration
+	rtt          rttTracker
+	localStreams sync.Map    // SSRC (uint32) -> struct{}, streams we send
+	srClock      clock.Clock // Time source that stamps our sender reports
+
+	// Derive send times from RTP timestamps for packets without
+	// abs-send-time or abs-capture-time
+	rtpTimestampFallback bool
+
+	// Negotiated RTX and FEC payload types and SSRCs
+	classifier packetClassifier
+
+	// Time source for arrival times and the feedback and cleanup loops
+	clock clock.Clock
+
 	// Lifecycle
+	onClose   func() // Called by Close, e.g. to unregister metrics
 	closed    chan struct{}
 	wg        sync.WaitGroup
 	startOnce sync.Once // Ensures cleanup loop starts only once
@@ -73,6 +97,22 @@ func WithSenderSSRC(ssrc uint32) InterceptorOption {
 	}
 }
 
+// WithFeedbackMode selects which congestion control feedback is sent:
+// REMB, transport-cc or both. Default is FeedbackREMB.
+func WithFeedbackMode(mode FeedbackMode) InterceptorOption {
+	return func(i *BWEInterceptor) {
+		i.feedbackMode = mode
+	}
+}
+
+// WithTWCCInterval sets the interval for sending transport-cc feedback.
+// Default is 100ms. Only used when the feedback mode includes transport-cc.
+func WithTWCCInterval(d time.Duration) InterceptorOption {
+	return func(i *BWEInterceptor) {
+		i.twccInterval = d
+	}
+}
+
 // WithOnREMB sets a callback that is invoked each time a REMB packet is sent.
 // The callback receives the bitrate estimate and the SSRCs included in the REMB.
 func WithOnREMB(fn func(bitrate float32, ssrcs []uint32)) InterceptorOption {
@@ -81,6 +121,52 @@ func WithOnREMB(fn func(bitrate float32, ssrcs []uint32)) InterceptorOption {
 	}
 }
 
+// WithRRTRInterval sets how often RTCP XR receiver reference time reports
+// are sent. The remote sender answers them with DLRR blocks, which gives a
+// receive-only endpoint an RTT measurement. Zero disables RRTR; RTT is then
+// only measured from report blocks when we also send media.
+// Default is 0 (disabled).
+func WithRRTRInterval(d time.Duration) InterceptorOption {
+	return func(i *BWEInterceptor) {
+		i.rrtrInterval = d
+	}
+}
+
+// WithRTPTimestampFallback derives the send time of packets that carry
+// neither abs-send-time nor abs-capture-time from their RTP timestamp and
+// the stream's clock rate, as libwebrtc's RemoteBitrateEstimatorSingleStream
+// does. Without it such packets are ignored. The estimator should use
+// bwe.DefaultSingleStreamConfig, which runs one delay pipeline per SSRC.
+// Default is disabled.
+func WithRTPTimestampFallback(enabled bool) InterceptorOption {
+	return func(i *BWEInterceptor) {
+		i.rtpTimestampFallback = enabled
+	}
+}
+
+// WithClock sets the time source for packet arrival times and the REMB,
+// transport-cc, RRTR and cleanup loops. Pass the clock the estimator was
+// created with; a clock.Virtual then runs the interceptor in simulated time.
+// Default is clock.Monotonic.
+func WithClock(c clock.Clock) InterceptorOption {
+	return func(i *BWEInterceptor) {
+		i.clock = c
+	}
+}
+
+// WithSenderReportClock sets the time source that stamps the NTP time of
+// the sender reports we send, which the remote echoes back as LSR in its
+// report blocks. RTT from report blocks is only correct if this is the
+// clock the sender report generator uses. Pion's report interceptor uses
+// time.Now unless configured with report.SenderNow, so leave this at its
+// default even when WithClock sets a clock.Virtual.
+// Default is clock.Monotonic.
+func WithSenderReportClock(c clock.Clock) InterceptorOption {
+	return func(i *BWEInterceptor) {
+		i.srClock = c
+	}
+}
+
 // NewBWEInterceptor creates a new bandwidth estimation interceptor.
 //
 // The estimator parameter is the core BandwidthEstimator from the bwe package
@@ -88,16 +174,36 @@ func WithOnREMB(fn func(bitrate float32, ssrcs []uint32)) InterceptorOption {
 //
 // Options can be provided to customize behavior:
 //   - WithREMBInterval: Set REMB sending interval (default 1s)
-//   - WithSenderSSRC: Set sender SSRC for REMB packets
+//   - WithSenderSSRC: Set sender SSRC for REMB and transport-cc packets
+//   - WithFeedbackMode: Send REMB, transport-cc or both (default REMB)
+//   - WithTWCCInterval: Set transport-cc feedback interval (default 100ms)
+//   - WithRRTRInterval: Send RTCP XR RRTR for RTT (default off)
+//   - WithRTPTimestampFallback: Use RTP timestamps without send-time extensions
+//   - WithClock: Set the time source (default clock.Monotonic)
+//   - WithSenderReportClock: Set the sender report time source (default clock.Monotonic)
 func NewBWEInterceptor(estimator *bwe.BandwidthEstimator, opts ...InterceptorOption) *BWEInterceptor {
 	i := &BWEInterceptor{
 		estimator:    estimator,
 		closed:       make(chan struct{}),
 		rembInterval: time.Second, // default 1Hz
+		feedbackMode: FeedbackREMB,
+		twccInterval: defaultTWCCInterval,
+		rrtrInterval: defaultRRTRInterval,
 	}
 	for _, opt := range opts {
 		opt(i)
 	}
+	if i.clock == nil {
+		i.clock = clock.Monotonic{}
+	}
+	if i.srClock == nil {
+		i.srClock = clock.Monotonic{}
+	}
+
+	// Create transport-cc recorder if that feedback is enabled
+	if i.feedbackMode.sendsTransportCC() {
+		i.twcc = newTWCCFeedback(i.senderSSRC, i.clock.Now())
+	}
 
 	// Create and attach REMB scheduler
 	rembConfig := bwe.DefaultREMBSchedulerConfig()
@@ -113,23 +219,151 @@ func NewBWEInterceptor(estimator *bwe.BandwidthEstimator, opts ...InterceptorOpt
 func (i *BWEInterceptor) Close() error {
 	close(i.closed)
 	i.wg.Wait()
+	if i.onClose != nil {
+		i.onClose()
+	}
 	return nil
 }
 
+// Stats returns a snapshot of the estimator's state, including the REMBs
+// this interceptor built. See bwe.BandwidthEstimator.Stats.
+func (i *BWEInterceptor) Stats() bwe.Stats {
+	return i.estimator.Stats()
+}
+
 // BindRTCPWriter is called by Pion when the RTCP writer is ready.
-// It captures the writer for sending REMB packets and starts the REMB loop.
+// It captures the writer for sending feedback and starts the REMB and/or
+// transport-cc loops, depending on the feedback mode, and the RRTR loop.
 func (i *BWEInterceptor) BindRTCPWriter(writer interceptor.RTCPWriter) interceptor.RTCPWriter {
 	i.mu.Lock()
 	i.rtcpWriter = writer
 	i.mu.Unlock()
 
 	// Start REMB loop goroutine
-	i.wg.Add(1)
-	go i.rembLoop()
+	if i.feedbackMode.sendsREMB() {
+		i.wg.Add(1)
+		go i.rembLoop()
+	}
+
+	// Start transport-cc feedback loop goroutine
+	if i.twcc != nil {
+		i.wg.Add(1)
+		go i.twccLoop()
+	}
+
+	// Start RTCP XR RRTR loop goroutine for RTT measurement
+	if i.rrtrInterval > 0 {
+		i.wg.Add(1)
+		go i.rrtrLoop()
+	}
 
 	return writer // Pass through unchanged
 }
 
+// BindRTCPReader is called by Pion when the RTCP reader is ready.
+// It wraps the reader to measure RTT from incoming sender and receiver
+// report blocks and from RTCP XR DLRR replies, and feeds it to the estimator.
+func (i *BWEInterceptor) BindRTCPReader(reader interceptor.RTCPReader) interceptor.RTCPReader {
+	return interceptor.RTCPReaderFunc(func(b []byte, a interceptor.Attributes) (int, interceptor.Attributes, error) {
+		n, a, err := reader.Read(b, a)
+		if err != nil {
+			return n, a, err
+		}
+
+		if a == nil {
+			a = make(interceptor.Attributes)
+		}
+		pkts, err := a.GetRTCPPackets(b[:n])
+		if err != nil {
+			return n, a, nil // Not our concern; pass through
+		}
+
+		i.processRTCP(pkts, i.clock.Now())
+		return n, a, nil
+	})
+}
+
+// processRTCP extracts RTT measurements from incoming RTCP packets and
+// feeds the most recent one to the estimator. now is the interceptor clock
+// time, which times DLRR replies; report blocks use the sender report clock.
+func (i *BWEInterceptor) processRTCP(pkts []rtcp.Packet, now time.Time) {
+	var (
+		rtt   time.Duration
+		found bool
+	)
+	for _, pkt := range pkts {
+		switch p := pkt.(type) {
+		case *rtcp.SenderReport:
+			if r, ok := i.rttFromReports(p.Reports); ok {
+				rtt, found = r, true
+			}
+		case *rtcp.ReceiverReport:
+			if r, ok := i.rttFromReports(p.Reports); ok {
+				rtt, found = r, true
+			}
+		case *rtcp.ExtendedReport:
+			for _, block := range p.Reports {
+				dlrr, ok := block.(*rtcp.DLRRReportBlock)
+				if !ok {
+					continue
+				}
+				for _, report := range dlrr.Reports {
+					if report.SSRC != i.senderSSRC {
+						continue // Reply to another receiver
+					}
+					if r, ok := i.rtt.fromDLRR(report, now); ok {
+						rtt, found = r, true
+					}
+				}
+			}
+		}
+	}
+
+	if found {
+		i.estimator.UpdateRTT(rtt)
+	}
+}
+
+// rttFromReports returns the RTT from the last report block about one of
+// our local streams. Blocks about other SSRCs, e.g. another sender's
+// streams in receiver reports relayed by an SFU, echo sender reports we did
+// not send and are skipped. The LSR is compared with the sender report
+// clock, which is the time source that stamped it.
+func (i *BWEInterceptor) rttFromReports(reports []rtcp.ReceptionReport) (time.Duration, bool) {
+	var (
+		rtt   time.Duration
+		found bool
+	)
+	for _, report := range reports {
+		if _, ok := i.localStreams.Load(report.SSRC); !ok {
+			continue
+		}
+		if r, ok := rttFromReportBlock(report, i.srClock.Now()); ok {
+			rtt, found = r, true
+		}
+	}
+	return rtt, found
+}
+
+// BindLocalStream is called by Pion when a new local stream is created.
+// It records the stream's SSRCs, whose report blocks give RTT measurements.
+// The writer is returned unchanged.
+func (i *BWEInterceptor) BindLocalStream(info *interceptor.StreamInfo, writer interceptor.RTPWriter) interceptor.RTPWriter {
+	for _, ssrc := range []uint32{info.SSRC, info.SSRCRetransmission, info.SSRCForwardErrorCorrection} {
+		if ssrc != 0 {
+			i.localStreams.Store(ssrc, struct{}{})
+		}
+	}
+	return writer
+}
+
+// UnbindLocalStream is called by Pion when a local stream is removed.
+func (i *BWEInterceptor) UnbindLocalStream(info *interceptor.StreamInfo) {
+	for _, ssrc := range []uint32{info.SSRC, info.SSRCRetransmission, info.SSRCForwardErrorCorrection} {
+		i.localStreams.Delete(ssrc)
+	}
+}
+
 // BindRemoteStream is called by Pion when a new remote stream is detected.
 // It extracts RTP header extension IDs and wraps the reader to observe packets.
 func (i *BWEInterceptor) BindRemoteStream(info *interceptor.StreamInfo, reader interceptor.RTPReader) interceptor.RTPReader {
@@ -146,15 +380,29 @@ func (i *BWEInterceptor) BindRemoteStream(info *interceptor.StreamInfo, reader i
 	if captureID := FindAbsCaptureTimeID(info.RTPHeaderExtensions); captureID != 0 {
 		i.captureExtID.CompareAndSwap(0, uint32(captureID))
 	}
+	if twccID := FindTransportCCID(info.RTPHeaderExtensions); twccID != 0 {
+		i.twccExtID.CompareAndSwap(0, uint32(twccID))
+	}
+
+	// Learn the RTX and FEC payload types and SSRCs
+	i.classifier.AddStream(info)
 
 	// Track stream
-	state := newStreamState(info.SSRC)
+	state := newStreamState(info.SSRC, i.clock.Now())
+	state.capture.clockRate = info.ClockRate
+	if i.rtpTimestampFallback {
+		state.rtpTime = bwe.NewRTPTimestampConverter(info.ClockRate)
+	}
 	i.streams.Store(info.SSRC, state)
 
 	// Return observing reader
 	return interceptor.RTPReaderFunc(func(b []byte, a interceptor.Attributes) (int, interceptor.Attributes, error) {
 		n, a, err := reader.Read(b, a)
 		if err == nil && n > 0 {
+			// Track the stream again if it was removed while paused
+			if state.removed.CompareAndSwap(true, false) {
+				i.streams.Store(info.SSRC, state)
+			}
 			i.processRTP(b[:n], info.SSRC)
 		}
 		return n, a, err
@@ -162,23 +410,47 @@ func (i *BWEInterceptor) BindRemoteStream(info *interceptor.StreamInfo, reader i
 }
 
 // UnbindRemoteStream is called by Pion when a remote stream is removed.
+// The SSRC is also removed from the estimator, so REMB stops listing it.
 func (i *BWEInterceptor) UnbindRemoteStream(info *interceptor.StreamInfo) {
 	i.streams.Delete(info.SSRC)
+	i.estimator.RemoveSSRC(info.SSRC)
 }
 
 // processRTP parses an RTP packet and feeds timing information to the estimator.
 func (i *BWEInterceptor) processRTP(raw []byte, ssrc uint32) {
 	// Parse RTP header
 	var header rtp.Header
-	if _, err := header.Unmarshal(raw); err != nil {
+	headerSize, err := header.Unmarshal(raw)
+	if err != nil {
 		return // Invalid RTP, skip
 	}
 
-	now := time.Now()
+	now := i.clock.Now()
 
 	// Update stream state
-	if state, ok := i.streams.Load(ssrc); ok {
-		state.(*streamState).UpdateLastPacket(now)
+	var state *streamState
+	if v, ok := i.streams.Load(ssrc); ok {
+		state = v.(*streamState)
+		state.UpdateLastPacket(now)
+	}
+
+	// Read the transport-wide sequence number, and record it for
+	// transport-cc feedback. This is independent of the send-time
+	// extensions below.
+	var (
+		transportSeq    uint16
+		hasTransportSeq bool
+	)
+	if twccID := uint8(i.twccExtID.Load()); twccID != 0 {
+		if extData := header.GetExtension(twccID); len(extData) >= 2 {
+			var ext rtp.TransportCCExtension // Stack allocated
+			if err := ext.Unmarshal(extData); err == nil {
+				transportSeq, hasTransportSeq = ext.TransportSequence, true
+				if i.twcc != nil {
+					i.twcc.Record(ssrc, transportSeq, now)
+				}
+			}
+		}
 	}
 
 	// Get extension IDs
@@ -186,33 +458,53 @@ func (i *BWEInterceptor) processRTP(raw []byte, ssrc uint32) {
 	captureID := uint8(i.captureExtID.Load())
 
 	// Try abs-send-time first (preferred, 3 bytes)
-	var sendTime uint32
+	var (
+		sendTime    uint32
+		hasSendTime bool
+	)
 	if absID != 0 {
 		if extData := header.GetExtension(absID); len(extData) >= 3 {
 			var ext rtp.AbsSendTimeExtension // Stack allocated - CRITICAL for 0 allocs/op
 			if err := ext.Unmarshal(extData); err == nil {
 				sendTime = uint32(ext.Timestamp) // Cast from uint64 to uint32 (24-bit fits)
+				hasSendTime = true
 			}
 		}
 	}
 
-	// Fallback to abs-capture-time (8 bytes, convert to abs-send-time scale)
-	if sendTime == 0 && captureID != 0 {
+	// Fallback to abs-capture-time (8 bytes). The full 64-bit value is
+	// passed on; packets without it inherit their frame's capture time.
+	var (
+		captureTime    uint64
+		hasCaptureTime bool
+	)
+	if !hasSendTime && captureID != 0 {
 		if extData := header.GetExtension(captureID); len(extData) >= 8 {
 			var ext rtp.AbsCaptureTimeExtension // Stack allocated - CRITICAL for 0 allocs/op
 			if err := ext.Unmarshal(extData); err == nil {
-				// Convert 64-bit UQ32.32 to 24-bit 6.18 fixed point
-				// AbsCaptureTime: upper 32 bits = seconds, lower 32 bits = fraction
-				// We need seconds (6 bits) + fraction (18 bits) = 24 bits total
-				seconds := (ext.Timestamp >> 32) & 0x3F    // 6 bits of seconds (mod 64)
-				fraction := (ext.Timestamp >> 14) & 0x3FFFF // 18 bits of fraction
-				sendTime = uint32((seconds << 18) | fraction)
+				captureTime, hasCaptureTime = ext.Timestamp, true
+				if state != nil {
+					state.capture.OnCaptureTime(header.Timestamp, captureTime, now)
+				}
 			}
 		}
+		if !hasCaptureTime && state != nil {
+			captureTime, hasCaptureTime = state.capture.Interpolate(header.Timestamp, now)
+		}
+		if hasCaptureTime {
+			sendTime = bwe.AbsCaptureTimeToAbsSendTime(captureTime)
+			hasSendTime = true
+		}
 	}
 
-	// No timing extension found, skip packet
-	if sendTime == 0 {
+	// Last resort: the RTP timestamp at the stream's clock rate
+	if !hasSendTime && state != nil && state.rtpTime != nil {
+		sendTime = state.rtpTime.SendTime(header.Timestamp)
+		hasSendTime = true
+	}
+
+	// No timing information, skip packet
+	if !hasSendTime {
 		return
 	}
 
@@ -220,8 +512,15 @@ func (i *BWEInterceptor) processRTP(raw []byte, ssrc uint32) {
 	pkt := getPacketInfo()
 	pkt.ArrivalTime = now
 	pkt.SendTime = sendTime
-	pkt.Size = len(raw)
+	if hasCaptureTime {
+		pkt.SendTimeSource = bwe.SendTimeAbsCaptureTime
+		pkt.CaptureTime = captureTime
+	}
 	pkt.SSRC = ssrc
+	pkt.TransportSequenceNumber = transportSeq
+	pkt.HasTransportSequenceNumber = hasTransportSeq
+	setRTPFields(pkt, &header, raw, headerSize)
+	pkt.Class = i.classifier.Classify(pkt)
 
 	// Feed to estimator (OnPacket takes by value, so dereference)
 	i.estimator.OnPacket(*pkt)
@@ -230,19 +529,36 @@ func (i *BWEInterceptor) processRTP(raw []byte, ssrc uint32) {
 	putPacketInfo(pkt)
 }
 
+// setRTPFields copies the RTP header fields and packet sizes into pkt.
+// headerSize is the length of the header as returned by header.Unmarshal.
+func setRTPFields(pkt *bwe.PacketInfo, header *rtp.Header, raw []byte, headerSize int) {
+	pkt.Size = len(raw)
+	pkt.HeaderSize = headerSize
+	pkt.SequenceNumber = header.SequenceNumber
+	pkt.RTPTimestamp = header.Timestamp
+	pkt.Marker = header.Marker
+	pkt.PayloadType = header.PayloadType
+	pkt.Padding = header.Padding
+
+	// The last byte of a padded packet holds the padding length
+	if header.Padding && len(raw) > headerSize {
+		pkt.PaddingSize = min(int(raw[len(raw)-1]), len(raw)-headerSize)
+	}
+}
+
 // rembLoop runs periodically to send REMB packets.
 // It uses the configured rembInterval (default 1s).
 func (i *BWEInterceptor) rembLoop() {
 	defer i.wg.Done()
 
-	ticker := time.NewTicker(i.rembInterval)
+	ticker := i.clock.NewTicker(i.rembInterval)
 	defer ticker.Stop()
 
 	for {
 		select {
 		case <-i.closed:
 			return
-		case now := <-ticker.C:
+		case now := <-ticker.C():
 			i.maybeSendREMB(now)
 		}
 	}
@@ -283,32 +599,127 @@ func (i *BWEInterceptor) maybeSendREMB(now time.Time) {
 	}
 }
 
+// twccLoop runs periodically to send transport-cc feedback.
+// It uses the configured twccInterval (default 100ms).
+func (i *BWEInterceptor) twccLoop() {
+	defer i.wg.Done()
+
+	ticker := i.clock.NewTicker(i.twccInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-i.closed:
+			return
+		case <-ticker.C():
+			i.sendTransportCC()
+		}
+	}
+}
+
+// sendTransportCC builds transport-cc feedback for the packets recorded
+// since the last report and sends it via the RTCPWriter.
+func (i *BWEInterceptor) sendTransportCC() {
+	pkts := i.twcc.BuildFeedback()
+	if len(pkts) == 0 {
+		return // Nothing received since last report
+	}
+
+	// Get writer under lock
+	i.mu.Lock()
+	writer := i.rtcpWriter
+	i.mu.Unlock()
+
+	if writer == nil {
+		return // Not bound yet, skip
+	}
+
+	_, _ = writer.Write(pkts, nil) // Ignore errors (network issues)
+}
+
+// rrtrLoop runs periodically to send RTCP XR receiver reference time reports.
+// It uses the configured rrtrInterval and only runs when it is positive.
+func (i *BWEInterceptor) rrtrLoop() {
+	defer i.wg.Done()
+
+	ticker := i.clock.NewTicker(i.rrtrInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-i.closed:
+			return
+		case now := <-ticker.C():
+			i.sendRRTR(now)
+		}
+	}
+}
+
+// sendRRTR sends a receiver reference time report and records it for
+// matching the DLRR reply. Nothing is sent until a remote stream exists,
+// since only a sender of media answers with DLRR.
+func (i *BWEInterceptor) sendRRTR(now time.Time) {
+	hasStreams := false
+	i.streams.Range(func(_, _ any) bool {
+		hasStreams = true
+		return false
+	})
+	if !hasStreams {
+		return
+	}
+
+	// Get writer under lock
+	i.mu.Lock()
+	writer := i.rtcpWriter
+	i.mu.Unlock()
+
+	if writer == nil {
+		return // Not bound yet, skip
+	}
+
+	ntp := toNTP(now)
+	i.rtt.onRRTRSent(ntp, now)
+	_, _ = writer.Write([]rtcp.Packet{&rtcp.ExtendedReport{
+		SenderSSRC: i.senderSSRC,
+		Reports: []rtcp.ReportBlock{
+			&rtcp.ReceiverReferenceTimeReportBlock{NTPTimestamp: ntp},
+		},
+	}}, nil) // Ignore errors (network issues)
+}
+
 // cleanupLoop runs periodically to remove inactive streams.
 // It checks every second and removes streams that haven't received
-// packets for longer than streamTimeout (2 seconds).
+// packets for longer than streamTimeout (2 seconds). It also lets the
+// estimator detect silence, so mute is noticed without waiting for the
+// next packet.
 func (i *BWEInterceptor) cleanupLoop() {
 	defer i.wg.Done()
 
-	ticker := time.NewTicker(time.Second) // Check every second
+	ticker := i.clock.NewTicker(time.Second) // Check every second
 	defer ticker.Stop()
 
 	for {
 		select {
 		case <-i.closed:
 			return
-		case now := <-ticker.C:
+		case now := <-ticker.C():
+			i.estimator.CheckIdle(now)
 			i.cleanupInactiveStreams(now)
 		}
 	}
 }
 
 // cleanupInactiveStreams removes streams that haven't received packets
-// for longer than streamTimeout. Uses sync.Map.Range for thread-safe iteration.
+// for longer than streamTimeout. A removed stream that is still bound is
+// tracked again by its reader when packets resume. The estimator expires
+// the SSRC itself after SSRCConfig.Timeout, in CheckIdle.
+// Uses sync.Map.Range for thread-safe iteration.
 func (i *BWEInterceptor) cleanupInactiveStreams(now time.Time) {
 	i.streams.Range(func(key, value any) bool {
 		state := value.(*streamState)
 		if now.Sub(state.LastPacket()) > streamTimeout {
 			i.streams.Delete(key)
+			state.removed.Store(true)
 		}
 		return true // Continue iteration
 	})
@@ -12,6 +12,7 @@ import (
 	"github.com/stretchr/testify/require"
 
 	"github.com/thesyncim/bwe/pkg/bwe"
+	"github.com/thesyncim/bwe/pkg/bwe/clock"
 )
 
 // makeRTPWithAbsSendTime creates an RTP packet with the abs-send-time extension.
@@ -195,6 +196,102 @@ func TestProcessRTP_FeedsEstimator(t *testing.T) {
 	assert.Contains(t, ssrcs, testSSRC, "Estimator should have tracked the SSRC")
 }
 
+func TestProcessRTP_PaddingMarksProbe(t *testing.T) {
+	estimator := bwe.NewBandwidthEstimator(bwe.DefaultBandwidthEstimatorConfig(), nil)
+	i := NewBWEInterceptor(estimator)
+
+	testSSRC := uint32(0xABCDEF12)
+	extID := uint8(3)
+	i.BindRemoteStream(&interceptor.StreamInfo{
+		SSRC: testSSRC,
+		RTPHeaderExtensions: []interceptor.RTPHeaderExtension{
+			{URI: AbsSendTimeURI, ID: int(extID)},
+		},
+	}, &mockRTPReader{})
+
+	// Padding-only packets form a probe cluster
+	sendTime := uint32(0x010000)
+	for n := 0; n < 10; n++ {
+		pkt := &rtp.Packet{
+			Header: rtp.Header{
+				Version:        2,
+				Padding:        true,
+				PayloadType:    96,
+				SequenceNumber: uint16(n),
+				SSRC:           testSSRC,
+			},
+			PaddingSize: 200,
+		}
+		_ = pkt.Header.SetExtension(extID, []byte{byte(sendTime >> 16), byte(sendTime >> 8), byte(sendTime)})
+		raw, err := pkt.Marshal()
+		require.NoError(t, err)
+		i.processRTP(raw, testSSRC)
+
+		sendTime += bwe.DurationToAbsSendTime(2 * time.Millisecond)
+		time.Sleep(2 * time.Millisecond)
+	}
+
+	// A media packet after the cluster gap completes the cluster
+	time.Sleep(100 * time.Millisecond)
+	i.processRTP(makeRTPWithAbsSendTime(testSSRC, extID, sendTime+bwe.DurationToAbsSendTime(100*time.Millisecond)), testSSRC)
+
+	results := estimator.GetProbeResults()
+	require.Len(t, results, 1, "padding packets should be detected as a probe cluster")
+	assert.Equal(t, 10, results[0].Packets)
+}
+
+func TestSetRTPFields(t *testing.T) {
+	pkt := &rtp.Packet{
+		Header: rtp.Header{
+			Version:        2,
+			Padding:        true,
+			Marker:         true,
+			PayloadType:    111,
+			SequenceNumber: 4321,
+			Timestamp:      90000,
+			SSRC:           0xABCDEF12,
+			CSRC:           []uint32{1, 2},
+		},
+		Payload:     make([]byte, 100),
+		PaddingSize: 20,
+	}
+	require.NoError(t, pkt.Header.SetExtension(3, []byte{0x01, 0x02, 0x03}))
+	raw, err := pkt.Marshal()
+	require.NoError(t, err)
+
+	var header rtp.Header
+	headerSize, err := header.Unmarshal(raw)
+	require.NoError(t, err)
+
+	var info bwe.PacketInfo
+	setRTPFields(&info, &header, raw, headerSize)
+
+	assert.Equal(t, len(raw), info.Size)
+	assert.Equal(t, pkt.Header.MarshalSize(), info.HeaderSize, "header includes CSRCs and extensions")
+	assert.Equal(t, 20, info.PaddingSize)
+	assert.Equal(t, 100, info.PayloadSize())
+	assert.Equal(t, uint16(4321), info.SequenceNumber)
+	assert.Equal(t, uint32(90000), info.RTPTimestamp)
+	assert.Equal(t, uint8(111), info.PayloadType)
+	assert.True(t, info.Marker)
+	assert.True(t, info.Padding)
+}
+
+func TestSetRTPFields_InvalidPaddingLength(t *testing.T) {
+	raw := makeRTPWithoutExtension(0xABCDEF12)
+	raw[0] |= 0x20         // Padding bit
+	raw[len(raw)-1] = 0xFF // Longer than the packet
+
+	var header rtp.Header
+	headerSize, err := header.Unmarshal(raw)
+	require.NoError(t, err)
+
+	var info bwe.PacketInfo
+	setRTPFields(&info, &header, raw, headerSize)
+	assert.Equal(t, len(raw)-headerSize, info.PaddingSize)
+	assert.Zero(t, info.PayloadSize())
+}
+
 func TestProcessRTP_NoExtension_Skips(t *testing.T) {
 	estimator := bwe.NewBandwidthEstimator(bwe.DefaultBandwidthEstimatorConfig(), nil)
 	i := NewBWEInterceptor(estimator)
@@ -226,6 +323,74 @@ func TestProcessRTP_NoExtension_Skips(t *testing.T) {
 	assert.NotContains(t, ssrcs, testSSRC, "Estimator should not track SSRC from packet without timing extension")
 }
 
+func TestProcessRTP_RTPTimestampFallback(t *testing.T) {
+	estimator := bwe.NewBandwidthEstimator(bwe.DefaultSingleStreamConfig(), nil)
+	i := NewBWEInterceptor(estimator, WithRTPTimestampFallback(true))
+
+	testSSRC := uint32(0x99999999)
+	i.BindRemoteStream(&interceptor.StreamInfo{SSRC: testSSRC, ClockRate: 48000}, &mockRTPReader{})
+
+	// Stream without clock rate cannot fall back
+	noRateSSRC := uint32(0x88888888)
+	i.BindRemoteStream(&interceptor.StreamInfo{SSRC: noRateSSRC}, &mockRTPReader{})
+
+	i.processRTP(makeRTPWithoutExtension(testSSRC), testSSRC)
+	i.processRTP(makeRTPWithoutExtension(noRateSSRC), noRateSSRC)
+
+	ssrcs := estimator.GetSSRCs()
+	assert.Contains(t, ssrcs, testSSRC, "RTP timestamp should be used as the send time")
+	assert.NotContains(t, ssrcs, noRateSSRC)
+
+	state, ok := i.streams.Load(testSSRC)
+	require.True(t, ok)
+	assert.Equal(t, uint32(48000), state.(*streamState).rtpTime.ClockRate())
+}
+
+func TestRTPTimestampFallback_ResumesAfterMute(t *testing.T) {
+	clk := clock.NewVirtual(time.Unix(0, 0))
+	estimator := bwe.NewBandwidthEstimator(bwe.DefaultSingleStreamConfig(), clk)
+	i := NewBWEInterceptor(estimator, WithRTPTimestampFallback(true), WithClock(clk))
+	defer i.Close()
+
+	testSSRC := uint32(0x99999999)
+	packets := make([][]byte, 3)
+	for n := range packets {
+		pkt := &rtp.Packet{
+			Header: rtp.Header{
+				Version:        2,
+				PayloadType:    111,
+				SequenceNumber: uint16(n),
+				Timestamp:      uint32(n) * 144000, // 3s at 48 kHz
+				SSRC:           testSSRC,
+			},
+			Payload: []byte{0x00, 0x01, 0x02, 0x03},
+		}
+		packets[n], _ = pkt.Marshal()
+	}
+	reader := i.BindRemoteStream(&interceptor.StreamInfo{SSRC: testSSRC, ClockRate: 48000}, &mockRTPReader{packets: packets})
+	buf := make([]byte, 1500)
+
+	_, _, err := reader.Read(buf, nil)
+	require.NoError(t, err)
+	require.Equal(t, []uint32{testSSRC}, estimator.GetSSRCs())
+
+	// Muted past the stream timeout
+	clk.Advance(3 * time.Second)
+	i.cleanupInactiveStreams(clk.Now())
+	_, ok := i.streams.Load(testSSRC)
+	require.False(t, ok)
+
+	// Unmuted: the stream keeps its RTP timestamp converter
+	for n := 0; n < 2; n++ {
+		_, _, err = reader.Read(buf, nil)
+		require.NoError(t, err)
+	}
+	assert.NotEmpty(t, estimator.GetSSRCs())
+	state, ok := i.streams.Load(testSSRC)
+	require.True(t, ok, "stream should be tracked again")
+	assert.NotNil(t, state.(*streamState).rtpTime)
+}
+
 func TestMultipleStreams_TrackedSeparately(t *testing.T) {
 	estimator := bwe.NewBandwidthEstimator(bwe.DefaultBandwidthEstimatorConfig(), nil)
 	i := NewBWEInterceptor(estimator)
@@ -276,10 +441,12 @@ func TestUnbindRemoteStream(t *testing.T) {
 		},
 	}
 	_ = i.BindRemoteStream(info, &mockRTPReader{})
+	i.processRTP(makeRTPWithAbsSendTime(testSSRC, 3, 0x010000), testSSRC)
 
 	// Verify stream is tracked
 	_, ok := i.streams.Load(testSSRC)
 	assert.True(t, ok, "Stream should be tracked after BindRemoteStream")
+	assert.Equal(t, []uint32{testSSRC}, estimator.GetSSRCs())
 
 	// Unbind stream
 	i.UnbindRemoteStream(info)
@@ -287,6 +454,7 @@ func TestUnbindRemoteStream(t *testing.T) {
 	// Verify stream is removed
 	_, ok = i.streams.Load(testSSRC)
 	assert.False(t, ok, "Stream should be removed after UnbindRemoteStream")
+	assert.Empty(t, estimator.GetSSRCs(), "REMB should stop listing the unbound SSRC")
 }
 
 func TestClose(t *testing.T) {
@@ -507,6 +675,105 @@ func TestREMB_IncludesAllSSRCs(t *testing.T) {
 	assert.True(t, foundREMBWithBothSSRCs, "Expected REMB to include both SSRCs")
 }
 
+func TestREMB_SimulatedTime(t *testing.T) {
+	clk := clock.NewVirtual(time.Time{})
+	estimator := bwe.NewBandwidthEstimator(bwe.DefaultBandwidthEstimatorConfig(), clk)
+
+	type remb struct {
+		bitrate float32
+		ssrcs   []uint32
+	}
+	rembs := make(chan remb, 10)
+	i := NewBWEInterceptor(estimator,
+		WithClock(clk),
+		WithRRTRInterval(0),
+		WithOnREMB(func(bitrate float32, ssrcs []uint32) {
+			rembs <- remb{bitrate, ssrcs}
+		}),
+	)
+	defer i.Close()
+
+	testSSRC := uint32(0x12345678)
+	extID := uint8(3)
+	info := &interceptor.StreamInfo{
+		SSRC: testSSRC,
+		RTPHeaderExtensions: []interceptor.RTPHeaderExtension{
+			{URI: AbsSendTimeURI, ID: int(extID)},
+		},
+	}
+	packets := make([][]byte, 50)
+	for j := range packets {
+		sendTime := uint32(j * 20 * 262) // 20ms in 6.18 fixed point
+		packets[j] = makeRTPWithAbsSendTime(testSSRC, extID, sendTime)
+	}
+	reader := i.BindRemoteStream(info, &mockRTPReader{packets: packets})
+	i.BindRTCPWriter(&mockRTCPWriter{})
+	clk.BlockUntil(2) // Cleanup and REMB tickers
+
+	// One packet every 20ms of virtual time
+	buf := make([]byte, 1500)
+	for range packets {
+		_, _, err := reader.Read(buf, nil)
+		require.NoError(t, err)
+		clk.Advance(20 * time.Millisecond)
+	}
+
+	// Arrival times come from the virtual clock
+	stream, ok := i.streams.Load(testSSRC)
+	require.True(t, ok)
+	assert.Equal(t, clk.Now().Add(-20*time.Millisecond), stream.(*streamState).LastPacket())
+
+	// The REMB loop ran on the virtual ticker, without waiting in real time
+	select {
+	case got := <-rembs:
+		assert.Equal(t, []uint32{testSSRC}, got.ssrcs)
+		assert.Greater(t, got.bitrate, float32(0))
+	case <-time.After(time.Second):
+		require.Fail(t, "no REMB sent in simulated time")
+	}
+}
+
+func TestStats_CountsPacketsAndREMB(t *testing.T) {
+	clk := clock.NewVirtual(time.Time{})
+	estimator := bwe.NewBandwidthEstimator(bwe.DefaultBandwidthEstimatorConfig(), clk)
+	i := NewBWEInterceptor(estimator, WithClock(clk))
+	defer i.Close()
+
+	testSSRC := uint32(0x12345678)
+	extID := uint8(3)
+	info := &interceptor.StreamInfo{
+		SSRC: testSSRC,
+		RTPHeaderExtensions: []interceptor.RTPHeaderExtension{
+			{URI: AbsSendTimeURI, ID: int(extID)},
+		},
+	}
+	packets := make([][]byte, 20)
+	var bytes int64
+	for j := range packets {
+		packets[j] = makeRTPWithAbsSendTime(testSSRC, extID, uint32(j*20*262))
+		bytes += int64(len(packets[j]))
+	}
+	reader := i.BindRemoteStream(info, &mockRTPReader{packets: packets})
+
+	buf := make([]byte, 1500)
+	for range packets {
+		_, _, err := reader.Read(buf, nil)
+		require.NoError(t, err)
+		clk.Advance(20 * time.Millisecond)
+	}
+	i.maybeSendREMB(clk.Now())
+
+	stats := i.Stats()
+	assert.Equal(t, estimator.Stats(), stats)
+	require.Len(t, stats.SSRCs, 1)
+	assert.Equal(t, testSSRC, stats.SSRCs[0].SSRC)
+	assert.Equal(t, int64(len(packets)), stats.SSRCs[0].Packets)
+	assert.Equal(t, bytes, stats.SSRCs[0].Bytes)
+	assert.Equal(t, int64(1), stats.REMB.Sent)
+	assert.Equal(t, stats.Estimate, stats.REMB.LastBitrate)
+	assert.Equal(t, clk.Now(), stats.REMB.LastTime)
+}
+
 func TestREMB_WriterNotBound_NoError(t *testing.T) {
 	estimator := bwe.NewBandwidthEstimator(bwe.DefaultBandwidthEstimatorConfig(), nil)
 	i := NewBWEInterceptor(estimator)
@@ -555,8 +822,9 @@ func TestREMBScheduler_AttachedOnCreate(t *testing.T) {
 // --- Stream Timeout and Close Tests ---
 
 func TestStreamTimeout_RemovesInactiveStreams(t *testing.T) {
-	estimator := bwe.NewBandwidthEstimator(bwe.DefaultBandwidthEstimatorConfig(), nil)
-	i := NewBWEInterceptor(estimator)
+	clk := clock.NewVirtual(time.Time{})
+	estimator := bwe.NewBandwidthEstimator(bwe.DefaultBandwidthEstimatorConfig(), clk)
+	i := NewBWEInterceptor(estimator, WithClock(clk))
 	defer i.Close()
 
 	testSSRC := uint32(0x12345678)
@@ -569,17 +837,47 @@ func TestStreamTimeout_RemovesInactiveStreams(t *testing.T) {
 		},
 	}
 	_ = i.BindRemoteStream(info, &mockRTPReader{})
+	clk.BlockUntil(1) // Cleanup ticker
 
 	// Verify stream exists initially
 	_, exists := i.streams.Load(testSSRC)
 	require.True(t, exists, "stream should exist initially")
 
-	// Wait for timeout (2s) + cleanup interval (1s) + margin
-	time.Sleep(3500 * time.Millisecond)
+	// Timeout (2s) + cleanup interval (1s), in simulated time
+	clk.Advance(3 * time.Second)
 
 	// Verify stream was removed by cleanup loop
-	_, exists = i.streams.Load(testSSRC)
-	assert.False(t, exists, "stream should be removed after timeout")
+	assert.Eventually(t, func() bool {
+		_, exists := i.streams.Load(testSSRC)
+		return !exists
+	}, time.Second, time.Millisecond, "stream should be removed after timeout")
+}
+
+func TestCleanupInactiveStreams_LeavesSSRCTimeoutToEstimator(t *testing.T) {
+	clk := clock.NewVirtual(time.Unix(0, 0))
+	config := bwe.DefaultBandwidthEstimatorConfig()
+	config.SSRCConfig.Timeout = 30 * time.Second
+	estimator := bwe.NewBandwidthEstimator(config, clk)
+	i := NewBWEInterceptor(estimator, WithClock(clk))
+
+	testSSRC := uint32(0x12345678)
+	i.streams.Store(testSSRC, newStreamState(testSSRC, clk.Now()))
+	i.absExtID.Store(3)
+	i.processRTP(makeRTPWithAbsSendTime(testSSRC, 3, 0x010000), testSSRC)
+	require.Equal(t, []uint32{testSSRC}, estimator.GetSSRCs())
+
+	// The stream times out, but the SSRC stays until SSRCConfig.Timeout
+	clk.Advance(streamTimeout + time.Second)
+	i.cleanupInactiveStreams(clk.Now())
+	estimator.CheckIdle(clk.Now())
+	_, ok := i.streams.Load(testSSRC)
+	assert.False(t, ok)
+	assert.Equal(t, []uint32{testSSRC}, estimator.GetSSRCs())
+
+	clk.Advance(30 * time.Second)
+	i.cleanupInactiveStreams(clk.Now())
+	estimator.CheckIdle(clk.Now())
+	assert.Empty(t, estimator.GetSSRCs())
 }
 
 func TestClose_StopsGoroutines(t *testing.T) {
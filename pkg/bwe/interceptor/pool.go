@@ -27,7 +27,20 @@ func putPacketInfo(pkt *bwe.PacketInfo) {
 	// Reset all fields to zero values
 	pkt.ArrivalTime = time.Time{}
 	pkt.SendTime = 0
+	pkt.SendTimeSource = bwe.SendTimeAbsSendTime
+	pkt.CaptureTime = 0
 	pkt.Size = 0
+	pkt.HeaderSize = 0
+	pkt.PaddingSize = 0
 	pkt.SSRC = 0
+	pkt.SequenceNumber = 0
+	pkt.TransportSequenceNumber = 0
+	pkt.HasTransportSequenceNumber = false
+	pkt.PayloadType = 0
+	pkt.RTPTimestamp = 0
+	pkt.Marker = false
+	pkt.Padding = false
+	pkt.ProbeClusterID = 0
+	pkt.Class = bwe.PacketMedia
 	packetInfoPool.Put(pkt)
 }
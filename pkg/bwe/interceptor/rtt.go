@@ -0,0 +1,129 @@
+package interceptor
+
+import (
+	"sync"
+	"time"
+
+	"github.com/pion/rtcp"
+)
+
+const (
+	// defaultRRTRInterval is how often RTCP XR receiver reference time
+	// reports are sent so the remote sender can answer with DLRR. They are
+	// off unless enabled, since not every sender supports RTCP XR.
+	defaultRRTRInterval = 0
+
+	// rrtrHistorySize is how many sent RRTR timestamps are remembered for
+	// matching DLRR replies. Replies to older reports are ignored.
+	rrtrHistorySize = 8
+
+	// maxRTCPRTT bounds RTT measurements; larger values come from
+	// mismatched or corrupt timestamps.
+	maxRTCPRTT = 10 * time.Second
+
+	// ntpEpochOffset is the number of seconds between the NTP epoch (1900)
+	// and the Unix epoch (1970).
+	ntpEpochOffset = 2208988800
+)
+
+// toNTP converts a wall-clock time to a 64-bit NTP timestamp
+// (32.32 fixed-point seconds since 1900).
+func toNTP(t time.Time) uint64 {
+	nanos := uint64(t.UnixNano())
+	seconds := nanos/uint64(time.Second) + ntpEpochOffset
+	fraction := (nanos % uint64(time.Second)) << 32 / uint64(time.Second)
+	return seconds<<32 | fraction
+}
+
+// compactNTP returns the middle 32 bits of an NTP timestamp (16.16
+// fixed-point seconds), the format used by LSR, DLSR, LRR and DLRR.
+func compactNTP(ntp uint64) uint32 {
+	return uint32(ntp >> 16)
+}
+
+// compactToDuration converts a 16.16 fixed-point duration in seconds.
+func compactToDuration(v uint32) time.Duration {
+	return time.Duration(uint64(v) * uint64(time.Second) >> 16)
+}
+
+// rrtrRecord is one sent receiver reference time report.
+type rrtrRecord struct {
+	compact uint32    // Middle 32 bits of the NTP timestamp sent
+	sent    time.Time // Local send time
+}
+
+// rttTracker measures round-trip time from RTCP.
+//
+// Two sources are supported:
+//
+//   - Report blocks in incoming sender and receiver reports. When we send
+//     media, the remote echoes the compact NTP time of our last sender
+//     report (LSR) and how long it held it (DLSR), giving
+//     RTT = now - LSR - DLSR (RFC 3550 Section 6.4.1). Only blocks about
+//     our local streams are used, with now read from the sender report clock.
+//   - RTCP XR (RFC 3611). A receive-only endpoint sends no sender reports,
+//     so it sends receiver reference time reports (RRTR) instead and the
+//     sender answers with DLRR blocks echoing them (LRR) and the delay.
+//
+// RRTR replies are matched against the reports we actually sent and timed
+// with the local monotonic clock, so wall-clock adjustments between the
+// report and the reply do not distort the measurement.
+type rttTracker struct {
+	mu    sync.Mutex
+	rrtrs [rrtrHistorySize]rrtrRecord
+	next  int
+}
+
+// onRRTRSent records a sent RRTR with the given NTP timestamp.
+func (r *rttTracker) onRRTRSent(ntp uint64, now time.Time) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.rrtrs[r.next] = rrtrRecord{compact: compactNTP(ntp), sent: now}
+	r.next = (r.next + 1) % rrtrHistorySize
+}
+
+// fromDLRR computes the RTT from a DLRR reply to one of our RRTRs.
+// Returns (rtt, true) if the reply matches a sent report and gives a
+// plausible RTT, (0, false) otherwise.
+func (r *rttTracker) fromDLRR(report rtcp.DLRRReport, now time.Time) (time.Duration, bool) {
+	if report.LastRR == 0 {
+		return 0, false // Sender has not received an RRTR yet
+	}
+
+	r.mu.Lock()
+	var sent time.Time
+	for _, rec := range r.rrtrs {
+		if !rec.sent.IsZero() && rec.compact == report.LastRR {
+			sent = rec.sent
+			break
+		}
+	}
+	r.mu.Unlock()
+
+	if sent.IsZero() {
+		return 0, false // Not one of ours, or too old
+	}
+	return validRTT(now.Sub(sent) - compactToDuration(report.DLRR))
+}
+
+// rttFromReportBlock computes the RTT from the LSR and DLSR fields of a
+// report block about a stream we send. now must come from the clock that
+// stamped the sender report. Returns (0, false) if the remote has not
+// received a sender report yet or the result is implausible.
+func rttFromReportBlock(report rtcp.ReceptionReport, now time.Time) (time.Duration, bool) {
+	if report.LastSenderReport == 0 {
+		return 0, false
+	}
+	// Modular arithmetic handles the 16-bit seconds wrap; a negative result
+	// shows up as a huge value and is rejected by validRTT.
+	rtt := compactNTP(toNTP(now)) - report.LastSenderReport - report.Delay
+	return validRTT(compactToDuration(rtt))
+}
+
+// validRTT rejects non-positive and implausibly large RTTs.
+func validRTT(rtt time.Duration) (time.Duration, bool) {
+	if rtt <= 0 || rtt > maxRTCPRTT {
+		return 0, false
+	}
+	return rtt, true
+}
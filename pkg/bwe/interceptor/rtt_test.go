@@ -0,0 +1,237 @@
+package interceptor
+
+import (
+	"testing"
+	"time"
+
+	"github.com/pion/interceptor"
+	"github.com/pion/rtcp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/thesyncim/bwe/pkg/bwe"
+	"github.com/thesyncim/bwe/pkg/bwe/clock"
+)
+
+// durationToCompact converts a duration to 16.16 fixed-point seconds.
+func durationToCompact(d time.Duration) uint32 {
+	return uint32(uint64(d) << 16 / uint64(time.Second))
+}
+
+func TestToNTP(t *testing.T) {
+	// Unix epoch is 2208988800 seconds after the NTP epoch
+	assert.Equal(t, uint64(ntpEpochOffset)<<32, toNTP(time.Unix(0, 0)))
+
+	// Half a second is half of the 32-bit fraction
+	ntp := toNTP(time.Unix(1, 500_000_000))
+	assert.Equal(t, uint64(ntpEpochOffset+1), ntp>>32)
+	assert.Equal(t, uint64(1)<<31, ntp&0xFFFFFFFF)
+
+	assert.Equal(t, uint32((ntpEpochOffset+1)&0xFFFF)<<16|0x8000, compactNTP(ntp))
+	assert.Equal(t, 1500*time.Millisecond, compactToDuration(0x18000))
+}
+
+func TestRTTFromReportBlock(t *testing.T) {
+	now := time.Unix(1_700_000_000, 0)
+
+	// Our SR went out 80ms ago and the remote held it for 30ms
+	report := rtcp.ReceptionReport{
+		SSRC:             0x1234,
+		LastSenderReport: compactNTP(toNTP(now.Add(-80 * time.Millisecond))),
+		Delay:            durationToCompact(30 * time.Millisecond),
+	}
+	rtt, ok := rttFromReportBlock(report, now)
+	require.True(t, ok)
+	assert.InDelta(t, 50*time.Millisecond, rtt, float64(100*time.Microsecond))
+
+	// No SR received yet
+	_, ok = rttFromReportBlock(rtcp.ReceptionReport{SSRC: 0x1234}, now)
+	assert.False(t, ok)
+
+	// Delay longer than the elapsed time: negative RTT is rejected
+	report.Delay = durationToCompact(200 * time.Millisecond)
+	_, ok = rttFromReportBlock(report, now)
+	assert.False(t, ok)
+}
+
+func TestRTTTracker_DLRR(t *testing.T) {
+	var tracker rttTracker
+	now := time.Unix(1_700_000_000, 0)
+	ntp := toNTP(now)
+	tracker.onRRTRSent(ntp, now)
+
+	rtt, ok := tracker.fromDLRR(rtcp.DLRRReport{
+		LastRR: compactNTP(ntp),
+		DLRR:   durationToCompact(10 * time.Millisecond),
+	}, now.Add(60*time.Millisecond))
+	require.True(t, ok)
+	assert.InDelta(t, 50*time.Millisecond, rtt, float64(100*time.Microsecond))
+
+	// A reply to a report we never sent is ignored
+	_, ok = tracker.fromDLRR(rtcp.DLRRReport{LastRR: compactNTP(ntp) + 1}, now.Add(60*time.Millisecond))
+	assert.False(t, ok)
+}
+
+func TestRTTTracker_DLRRHistoryBounded(t *testing.T) {
+	var tracker rttTracker
+	now := time.Unix(1_700_000_000, 0)
+	first := toNTP(now)
+	for j := 0; j <= rrtrHistorySize; j++ {
+		sent := now.Add(time.Duration(j) * time.Second)
+		tracker.onRRTRSent(toNTP(sent), sent)
+	}
+
+	_, ok := tracker.fromDLRR(rtcp.DLRRReport{LastRR: compactNTP(first)}, now.Add(20*time.Second))
+	assert.False(t, ok, "replies to evicted reports are ignored")
+}
+
+func TestBWEInterceptor_RTTFromReceiverReport(t *testing.T) {
+	// Sender reports are stamped by a different clock than the simulated
+	// interceptor clock, as with Pion's report interceptor
+	clk := clock.NewVirtual(time.Time{})
+	srClock := clock.NewVirtual(time.Unix(1_700_000_000, 0))
+	estimator := bwe.NewBandwidthEstimator(bwe.DefaultBandwidthEstimatorConfig(), clk)
+	i := NewBWEInterceptor(estimator, WithClock(clk), WithSenderReportClock(srClock))
+	defer i.Close()
+	i.BindLocalStream(&interceptor.StreamInfo{SSRC: 0x1234}, nil)
+
+	now := srClock.Now()
+	i.processRTCP([]rtcp.Packet{&rtcp.ReceiverReport{
+		SSRC: 0x5678,
+		Reports: []rtcp.ReceptionReport{{
+			SSRC:             0x1234,
+			LastSenderReport: compactNTP(toNTP(now.Add(-100 * time.Millisecond))),
+			Delay:            durationToCompact(40 * time.Millisecond),
+		}},
+	}}, clk.Now())
+
+	assert.InDelta(t, 60*time.Millisecond, estimator.GetRTT(), float64(100*time.Microsecond))
+}
+
+func TestBWEInterceptor_RTTIgnoresOtherSenders(t *testing.T) {
+	srClock := clock.NewVirtual(time.Unix(1_700_000_000, 0))
+	estimator := bwe.NewBandwidthEstimator(bwe.DefaultBandwidthEstimatorConfig(), nil)
+	i := NewBWEInterceptor(estimator, WithSenderReportClock(srClock))
+	defer i.Close()
+	i.BindLocalStream(&interceptor.StreamInfo{SSRC: 0x1234}, nil)
+
+	// An SFU relays a receiver report about another publisher's stream
+	now := srClock.Now()
+	report := &rtcp.ReceiverReport{
+		SSRC: 0x5678,
+		Reports: []rtcp.ReceptionReport{{
+			SSRC:             0x9999,
+			LastSenderReport: compactNTP(toNTP(now.Add(-30 * time.Millisecond))),
+		}},
+	}
+	i.processRTCP([]rtcp.Packet{report}, now)
+	assert.Equal(t, 200*time.Millisecond, estimator.GetRTT(), "RTT should stay at the default")
+
+	// Once our stream is unbound its report blocks are ignored as well
+	i.UnbindLocalStream(&interceptor.StreamInfo{SSRC: 0x1234})
+	report.Reports[0].SSRC = 0x1234
+	i.processRTCP([]rtcp.Packet{report}, now)
+	assert.Equal(t, 200*time.Millisecond, estimator.GetRTT(), "RTT should stay at the default")
+}
+
+func TestBWEInterceptor_RTTFromSenderReport(t *testing.T) {
+	srClock := clock.NewVirtual(time.Unix(1_700_000_000, 0))
+	estimator := bwe.NewBandwidthEstimator(bwe.DefaultBandwidthEstimatorConfig(), nil)
+	i := NewBWEInterceptor(estimator, WithSenderReportClock(srClock))
+	defer i.Close()
+	i.BindLocalStream(&interceptor.StreamInfo{SSRC: 0x1234}, nil)
+
+	now := srClock.Now()
+	i.processRTCP([]rtcp.Packet{&rtcp.SenderReport{
+		SSRC:    0x5678,
+		NTPTime: toNTP(now),
+		Reports: []rtcp.ReceptionReport{{
+			SSRC:             0x1234,
+			LastSenderReport: compactNTP(toNTP(now.Add(-30 * time.Millisecond))),
+			Delay:            durationToCompact(5 * time.Millisecond),
+		}},
+	}}, now)
+
+	assert.InDelta(t, 25*time.Millisecond, estimator.GetRTT(), float64(100*time.Microsecond))
+}
+
+func TestBWEInterceptor_RTTFromXR(t *testing.T) {
+	estimator := bwe.NewBandwidthEstimator(bwe.DefaultBandwidthEstimatorConfig(), nil)
+	i := NewBWEInterceptor(estimator, WithSenderSSRC(0xABCD), WithRRTRInterval(0))
+	defer i.Close()
+
+	mockWriter := &mockRTCPWriter{}
+	i.BindRTCPWriter(mockWriter)
+
+	// No RRTR before any media arrives
+	now := time.Now()
+	i.sendRRTR(now)
+	require.Empty(t, mockWriter.getPackets())
+
+	i.BindRemoteStream(&interceptor.StreamInfo{SSRC: 0x1234}, &mockRTPReader{})
+	i.sendRRTR(now)
+
+	pkts := mockWriter.getPackets()
+	require.Len(t, pkts, 1)
+	xr, ok := pkts[0].(*rtcp.ExtendedReport)
+	require.True(t, ok)
+	assert.Equal(t, uint32(0xABCD), xr.SenderSSRC)
+	require.Len(t, xr.Reports, 1)
+	rrtr, ok := xr.Reports[0].(*rtcp.ReceiverReferenceTimeReportBlock)
+	require.True(t, ok)
+
+	// The sender answers with DLRR for us and for another receiver
+	reply := &rtcp.ExtendedReport{
+		SenderSSRC: 0x1234,
+		Reports: []rtcp.ReportBlock{&rtcp.DLRRReportBlock{Reports: []rtcp.DLRRReport{
+			{SSRC: 0x9999, LastRR: compactNTP(rrtr.NTPTimestamp), DLRR: 0},
+			{SSRC: 0xABCD, LastRR: compactNTP(rrtr.NTPTimestamp), DLRR: durationToCompact(20 * time.Millisecond)},
+		}}},
+	}
+
+	i.processRTCP([]rtcp.Packet{reply}, now.Add(90*time.Millisecond))
+	assert.InDelta(t, 70*time.Millisecond, estimator.GetRTT(), float64(100*time.Microsecond),
+		"RTT should come from our DLRR entry")
+}
+
+func TestBWEInterceptor_BindRTCPReaderMeasuresRTT(t *testing.T) {
+	estimator := bwe.NewBandwidthEstimator(bwe.DefaultBandwidthEstimatorConfig(), nil)
+	i := NewBWEInterceptor(estimator)
+	defer i.Close()
+	i.BindLocalStream(&interceptor.StreamInfo{SSRC: 0x1234}, nil)
+
+	reader := &queuedRTCPReader{}
+	reader.push([]rtcp.Packet{&rtcp.ReceiverReport{
+		SSRC: 0x5678,
+		Reports: []rtcp.ReceptionReport{{
+			SSRC:             0x1234,
+			LastSenderReport: compactNTP(toNTP(time.Now().Add(-100 * time.Millisecond))),
+			Delay:            durationToCompact(40 * time.Millisecond),
+		}},
+	}})
+
+	wrapped := i.BindRTCPReader(reader)
+	_, _, err := wrapped.Read(make([]byte, 1500), nil)
+	require.NoError(t, err)
+
+	rtt := estimator.GetRTT()
+	assert.GreaterOrEqual(t, rtt, 59*time.Millisecond)
+	assert.Less(t, rtt, 200*time.Millisecond, "RTT should be measured, not the default")
+}
+
+func TestBWEInterceptor_RRTRIntervalOption(t *testing.T) {
+	estimator := bwe.NewBandwidthEstimator(bwe.DefaultBandwidthEstimatorConfig(), nil)
+	i := NewBWEInterceptor(estimator)
+	defer i.Close()
+	assert.Zero(t, i.rrtrInterval, "RRTR is off by default")
+
+	factory, err := NewBWEInterceptorFactory(WithFactoryRRTRInterval(500 * time.Millisecond))
+	require.NoError(t, err)
+	created, err := factory.NewInterceptor("test")
+	require.NoError(t, err)
+	defer created.Close()
+	assert.Equal(t, 500*time.Millisecond, created.(*BWEInterceptor).rrtrInterval)
+
+	_, err = NewBWEInterceptorFactory(WithFactoryRRTRInterval(-time.Second))
+	assert.Error(t, err)
+}
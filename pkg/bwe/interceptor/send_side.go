@@ -0,0 +1,246 @@
+package interceptor
+
+import (
+	"sync"
+	"sync/atomic"
+
+	"github.com/pion/interceptor"
+	"github.com/pion/rtcp"
+	"github.com/pion/rtp"
+
+	"github.com/thesyncim/bwe/pkg/bwe"
+	"github.com/thesyncim/bwe/pkg/bwe/clock"
+)
+
+// SendSideInterceptor is a Pion interceptor that performs sender-side
+// bandwidth estimation using the GCC algorithm. It tags outgoing RTP packets
+// with transport-wide sequence numbers, consumes the transport-cc feedback
+// returned by the receiver, and produces a target bitrate for the encoders.
+//
+// Usage:
+//
+//	estimator := bwe.NewSendSideEstimator(bwe.DefaultSendSideConfig(), nil)
+//	i := NewSendSideInterceptor(estimator)
+//	i.OnTargetBitrateChange(func(bitrate int64) { encoder.SetBitrate(bitrate) })
+//	// Add to interceptor registry...
+type SendSideInterceptor struct {
+	interceptor.NoOp // Embed for interface compliance
+
+	estimator *bwe.SendSideEstimator
+	clock     clock.Clock
+
+	// Transport-wide sequence number shared by all local streams
+	nextSeq atomic.Uint32
+
+	mu             sync.Mutex
+	onTargetChange func(bitrate int64)
+	lastTarget     int64
+}
+
+// SendSideOption configures a SendSideInterceptor.
+type SendSideOption func(*SendSideInterceptor)
+
+// WithSendSideClock sets the time source for packet send times and feedback
+// processing. Pass the clock the estimator was created with; a clock.Virtual
+// then runs the interceptor in simulated time.
+// Default is clock.Monotonic.
+func WithSendSideClock(c clock.Clock) SendSideOption {
+	return func(i *SendSideInterceptor) {
+		i.clock = c
+	}
+}
+
+// NewSendSideInterceptor creates a new sender-side bandwidth estimation interceptor.
+//
+// The estimator parameter is the SendSideEstimator from the bwe package that
+// performs the GCC calculations on transport-cc feedback.
+func NewSendSideInterceptor(estimator *bwe.SendSideEstimator, opts ...SendSideOption) *SendSideInterceptor {
+	i := &SendSideInterceptor{
+		estimator:  estimator,
+		lastTarget: estimator.TargetBitrate(),
+	}
+	for _, opt := range opts {
+		opt(i)
+	}
+	if i.clock == nil {
+		i.clock = clock.Monotonic{}
+	}
+	return i
+}
+
+// OnTargetBitrateChange sets a callback that is invoked whenever the target
+// bitrate changes after processing transport-cc feedback.
+func (i *SendSideInterceptor) OnTargetBitrateChange(fn func(bitrate int64)) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	i.onTargetChange = fn
+}
+
+// GetTargetBitrate returns the current target bitrate in bits per second.
+func (i *SendSideInterceptor) GetTargetBitrate() int64 {
+	return i.estimator.TargetBitrate()
+}
+
+// Estimator returns the underlying sender-side estimator.
+func (i *SendSideInterceptor) Estimator() *bwe.SendSideEstimator {
+	return i.estimator
+}
+
+// BindLocalStream is called by Pion when a new local stream is created.
+// If transport-cc was negotiated, it wraps the writer to tag each packet with
+// a transport-wide sequence number and record it in the estimator.
+func (i *SendSideInterceptor) BindLocalStream(info *interceptor.StreamInfo, writer interceptor.RTPWriter) interceptor.RTPWriter {
+	extID := FindTransportCCID(info.RTPHeaderExtensions)
+	if extID == 0 {
+		return writer // transport-cc not negotiated, nothing to do
+	}
+
+	return interceptor.RTPWriterFunc(func(header *rtp.Header, payload []byte, a interceptor.Attributes) (int, error) {
+		i.onPacketSent(header, payload, extID)
+		return writer.Write(header, payload, a)
+	})
+}
+
+// onPacketSent assigns (or reuses) the transport-wide sequence number of an
+// outgoing packet and records it in the estimator.
+func (i *SendSideInterceptor) onPacketSent(header *rtp.Header, payload []byte, extID uint8) {
+	var seq uint16
+
+	// Another interceptor (e.g. Pion's twcc header extension interceptor)
+	// may already have assigned a sequence number; reuse it if so.
+	var ext rtp.TransportCCExtension // Stack allocated
+	if extData := header.GetExtension(extID); len(extData) >= 2 && ext.Unmarshal(extData) == nil {
+		seq = ext.TransportSequence
+	} else {
+		seq = uint16(i.nextSeq.Add(1) - 1)
+		ext.TransportSequence = seq
+		data, err := ext.Marshal()
+		if err != nil {
+			return
+		}
+		if err := header.SetExtension(extID, data); err != nil {
+			return
+		}
+	}
+
+	i.estimator.OnPacketSent(bwe.SentPacket{
+		TransportSequenceNumber: seq,
+		SendTime:                i.clock.Now(),
+		Size:                    header.MarshalSize() + len(payload),
+		SSRC:                    header.SSRC,
+	})
+}
+
+// BindRTCPReader is called by Pion when the RTCP reader is ready.
+// It wraps the reader to feed incoming transport-cc feedback to the estimator.
+func (i *SendSideInterceptor) BindRTCPReader(reader interceptor.RTCPReader) interceptor.RTCPReader {
+	return interceptor.RTCPReaderFunc(func(b []byte, a interceptor.Attributes) (int, interceptor.Attributes, error) {
+		n, a, err := reader.Read(b, a)
+		if err != nil {
+			return n, a, err
+		}
+
+		if a == nil {
+			a = make(interceptor.Attributes)
+		}
+		pkts, err := a.GetRTCPPackets(b[:n])
+		if err != nil {
+			return n, a, nil // Not our concern; pass through
+		}
+
+		for _, pkt := range pkts {
+			if fb, ok := pkt.(*rtcp.TransportLayerCC); ok {
+				i.onTransportCC(fb)
+			}
+		}
+		return n, a, nil
+	})
+}
+
+// onTransportCC feeds one feedback packet to the estimator and notifies the
+// target bitrate callback if the target changed.
+func (i *SendSideInterceptor) onTransportCC(fb *rtcp.TransportLayerCC) {
+	target := i.estimator.OnTransportCC(fb, i.clock.Now())
+
+	i.mu.Lock()
+	changed := target != i.lastTarget
+	i.lastTarget = target
+	cb := i.onTargetChange
+	i.mu.Unlock()
+
+	if changed && cb != nil {
+		cb(target)
+	}
+}
+
+// SendSideInterceptorFactory creates SendSideInterceptor instances for each
+// PeerConnection. Register this factory with the interceptor registry to enable
+// sender-side bandwidth estimation.
+type SendSideInterceptorFactory struct {
+	config           bwe.SendSideConfig
+	clock            clock.Clock
+	onNewInterceptor func(id string, i *SendSideInterceptor)
+}
+
+// SendSideFactoryOption configures the SendSideInterceptorFactory.
+type SendSideFactoryOption func(*SendSideInterceptorFactory) error
+
+// WithSendSideConfig sets the configuration for the sender-side estimators.
+// Default: bwe.DefaultSendSideConfig()
+func WithSendSideConfig(config bwe.SendSideConfig) SendSideFactoryOption {
+	return func(f *SendSideInterceptorFactory) error {
+		f.config = config
+		return nil
+	}
+}
+
+// WithSendSideFactoryClock sets the time source shared by each interceptor
+// and its estimator. Use a clock.Virtual to run whole sessions in simulated
+// time.
+// Default: clock.Monotonic
+func WithSendSideFactoryClock(c clock.Clock) SendSideFactoryOption {
+	return func(f *SendSideInterceptorFactory) error {
+		f.clock = c
+		return nil
+	}
+}
+
+// NewSendSideInterceptorFactory creates a new factory for SendSideInterceptor instances.
+//
+// Example:
+//
+//	factory, err := NewSendSideInterceptorFactory()
+//	if err != nil {
+//	    return err
+//	}
+//	factory.OnNewInterceptor(func(id string, i *SendSideInterceptor) {
+//	    i.OnTargetBitrateChange(encoder.SetBitrate)
+//	})
+//	registry.Add(factory)
+func NewSendSideInterceptorFactory(opts ...SendSideFactoryOption) (*SendSideInterceptorFactory, error) {
+	f := &SendSideInterceptorFactory{
+		config: bwe.DefaultSendSideConfig(),
+	}
+	for _, opt := range opts {
+		if err := opt(f); err != nil {
+			return nil, err
+		}
+	}
+	return f, nil
+}
+
+// OnNewInterceptor sets a callback that is invoked each time the factory
+// creates an interceptor, so the application can subscribe to its target bitrate.
+func (f *SendSideInterceptorFactory) OnNewInterceptor(fn func(id string, i *SendSideInterceptor)) {
+	f.onNewInterceptor = fn
+}
+
+// NewInterceptor creates a new SendSideInterceptor for a PeerConnection.
+// This method is called by the interceptor registry when setting up a connection.
+func (f *SendSideInterceptorFactory) NewInterceptor(id string) (interceptor.Interceptor, error) {
+	i := NewSendSideInterceptor(bwe.NewSendSideEstimator(f.config, f.clock), WithSendSideClock(f.clock))
+	if f.onNewInterceptor != nil {
+		f.onNewInterceptor(id, i)
+	}
+	return i, nil
+}
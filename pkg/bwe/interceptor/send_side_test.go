@@ -0,0 +1,221 @@
+package interceptor
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/pion/interceptor"
+	"github.com/pion/interceptor/pkg/twcc"
+	"github.com/pion/rtcp"
+	"github.com/pion/rtp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/thesyncim/bwe/pkg/bwe"
+	"github.com/thesyncim/bwe/pkg/bwe/clock"
+)
+
+// capturingRTPWriter records the transport-wide sequence numbers of written packets.
+type capturingRTPWriter struct {
+	extID uint8
+	seqs  []uint16
+}
+
+func (w *capturingRTPWriter) Write(header *rtp.Header, payload []byte, _ interceptor.Attributes) (int, error) {
+	var ext rtp.TransportCCExtension
+	if err := ext.Unmarshal(header.GetExtension(w.extID)); err == nil {
+		w.seqs = append(w.seqs, ext.TransportSequence)
+	}
+	return header.MarshalSize() + len(payload), nil
+}
+
+// queuedRTCPReader returns marshaled RTCP packets one at a time.
+type queuedRTCPReader struct {
+	mu      sync.Mutex
+	packets [][]byte
+}
+
+func (r *queuedRTCPReader) push(pkts []rtcp.Packet) {
+	raw, _ := rtcp.Marshal(pkts)
+	r.mu.Lock()
+	r.packets = append(r.packets, raw)
+	r.mu.Unlock()
+}
+
+func (r *queuedRTCPReader) Read(b []byte, a interceptor.Attributes) (int, interceptor.Attributes, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if len(r.packets) == 0 {
+		return 0, a, nil
+	}
+	n := copy(b, r.packets[0])
+	r.packets = r.packets[1:]
+	return n, a, nil
+}
+
+func TestSendSideInterceptor_AssignsTransportSequence(t *testing.T) {
+	i := NewSendSideInterceptor(bwe.NewSendSideEstimator(bwe.DefaultSendSideConfig(), nil))
+
+	info := &interceptor.StreamInfo{
+		SSRC: 0x1234,
+		RTPHeaderExtensions: []interceptor.RTPHeaderExtension{
+			{URI: TransportCCURI, ID: 5},
+		},
+	}
+	sink := &capturingRTPWriter{extID: 5}
+	writer := i.BindLocalStream(info, sink)
+
+	for j := 0; j < 5; j++ {
+		_, err := writer.Write(&rtp.Header{Version: 2, SSRC: 0x1234, SequenceNumber: uint16(j)}, make([]byte, 100), nil)
+		require.NoError(t, err)
+	}
+
+	assert.Equal(t, []uint16{0, 1, 2, 3, 4}, sink.seqs, "packets should carry consecutive transport sequence numbers")
+}
+
+func TestSendSideInterceptor_ReusesExistingSequence(t *testing.T) {
+	i := NewSendSideInterceptor(bwe.NewSendSideEstimator(bwe.DefaultSendSideConfig(), nil))
+
+	info := &interceptor.StreamInfo{
+		SSRC: 0x1234,
+		RTPHeaderExtensions: []interceptor.RTPHeaderExtension{
+			{URI: TransportCCURI, ID: 5},
+		},
+	}
+	sink := &capturingRTPWriter{extID: 5}
+	writer := i.BindLocalStream(info, sink)
+
+	header := &rtp.Header{Version: 2, SSRC: 0x1234}
+	ext := rtp.TransportCCExtension{TransportSequence: 777}
+	data, _ := ext.Marshal()
+	require.NoError(t, header.SetExtension(5, data))
+
+	_, err := writer.Write(header, make([]byte, 100), nil)
+	require.NoError(t, err)
+	assert.Equal(t, []uint16{777}, sink.seqs, "an already assigned sequence number should be kept")
+}
+
+func TestSendSideInterceptor_WithoutTransportCC(t *testing.T) {
+	i := NewSendSideInterceptor(bwe.NewSendSideEstimator(bwe.DefaultSendSideConfig(), nil))
+
+	sink := &capturingRTPWriter{extID: 5}
+	writer := i.BindLocalStream(&interceptor.StreamInfo{SSRC: 0x1234}, sink)
+	assert.Equal(t, sink, writer, "writer should pass through when transport-cc is not negotiated")
+}
+
+func TestSendSideInterceptor_ConsumesFeedback(t *testing.T) {
+	i := NewSendSideInterceptor(bwe.NewSendSideEstimator(bwe.DefaultSendSideConfig(), nil))
+
+	var targets []int64
+	var mu sync.Mutex
+	i.OnTargetBitrateChange(func(bitrate int64) {
+		mu.Lock()
+		targets = append(targets, bitrate)
+		mu.Unlock()
+	})
+
+	info := &interceptor.StreamInfo{
+		SSRC: 0x1234,
+		RTPHeaderExtensions: []interceptor.RTPHeaderExtension{
+			{URI: TransportCCURI, ID: 5},
+		},
+	}
+	writer := i.BindLocalStream(info, &capturingRTPWriter{extID: 5})
+	rtcpSource := &queuedRTCPReader{}
+	rtcpReader := i.BindRTCPReader(rtcpSource)
+
+	// Simulate the remote recorder: every packet arrives 10ms after the previous
+	recorder := twcc.NewRecorder(1)
+	buf := make([]byte, 1500)
+	seq := uint16(0)
+	for report := 0; report < 20; report++ {
+		for j := 0; j < 10; j++ {
+			_, err := writer.Write(&rtp.Header{Version: 2, SSRC: 0x1234}, make([]byte, 1000), nil)
+			require.NoError(t, err)
+			recorder.Record(0x1234, seq, int64(seq)*10_000)
+			seq++
+			time.Sleep(time.Millisecond)
+		}
+		rtcpSource.push(recorder.BuildFeedbackPacket())
+		_, _, err := rtcpReader.Read(buf, nil)
+		require.NoError(t, err)
+	}
+
+	acked, ok := i.Estimator().GetAckedBitrate()
+	require.True(t, ok, "feedback should produce an acknowledged bitrate")
+	assert.Greater(t, acked, int64(0))
+
+	mu.Lock()
+	defer mu.Unlock()
+	require.NotEmpty(t, targets, "target bitrate callback should fire")
+	assert.Equal(t, i.GetTargetBitrate(), targets[len(targets)-1])
+}
+
+func TestSendSideInterceptor_VirtualClock(t *testing.T) {
+	clk := clock.NewVirtual(time.Unix(0, 0))
+	i := NewSendSideInterceptor(bwe.NewSendSideEstimator(bwe.DefaultSendSideConfig(), clk), WithSendSideClock(clk))
+
+	info := &interceptor.StreamInfo{
+		SSRC: 0x1234,
+		RTPHeaderExtensions: []interceptor.RTPHeaderExtension{
+			{URI: TransportCCURI, ID: 5},
+		},
+	}
+	writer := i.BindLocalStream(info, &capturingRTPWriter{extID: 5})
+	rtcpSource := &queuedRTCPReader{}
+	rtcpReader := i.BindRTCPReader(rtcpSource)
+
+	// Two seconds of simulated time: packets sent every 10ms arrive 10ms
+	// apart. Send times from the wall clock would read as a growing queue,
+	// and feedback times from it would keep the target from ramping up.
+	initial := i.GetTargetBitrate()
+	recorder := twcc.NewRecorder(1)
+	buf := make([]byte, 1500)
+	seq := uint16(0)
+	for report := 0; report < 20; report++ {
+		for j := 0; j < 10; j++ {
+			_, err := writer.Write(&rtp.Header{Version: 2, SSRC: 0x1234}, make([]byte, 1000), nil)
+			require.NoError(t, err)
+			recorder.Record(0x1234, seq, int64(seq)*10_000)
+			seq++
+			clk.Advance(10 * time.Millisecond)
+		}
+		rtcpSource.push(recorder.BuildFeedbackPacket())
+		_, _, err := rtcpReader.Read(buf, nil)
+		require.NoError(t, err)
+		require.Equal(t, bwe.BwNormal, i.Estimator().GetCongestionState(), "report %d", report)
+	}
+
+	assert.InDelta(t, 0, i.Estimator().GetDelayFilterOutput(), 0.5, "send times should be 10ms apart, like arrivals")
+	assert.Greater(t, i.GetTargetBitrate(), initial*11/10, "the target should ramp over 2s")
+}
+
+func TestSendSideInterceptorFactory(t *testing.T) {
+	config := bwe.DefaultSendSideConfig()
+	config.RateControllerConfig.InitialBitrate = 800_000
+
+	factory, err := NewSendSideInterceptorFactory(WithSendSideConfig(config))
+	require.NoError(t, err)
+
+	var created *SendSideInterceptor
+	factory.OnNewInterceptor(func(id string, i *SendSideInterceptor) {
+		assert.Equal(t, "pc-1", id)
+		created = i
+	})
+
+	i, err := factory.NewInterceptor("pc-1")
+	require.NoError(t, err)
+	require.NotNil(t, created)
+	assert.Equal(t, i, created)
+	assert.Equal(t, int64(800_000), created.GetTargetBitrate())
+
+	clk := clock.NewVirtual(time.Time{})
+	factory, err = NewSendSideInterceptorFactory(WithSendSideFactoryClock(clk))
+	require.NoError(t, err)
+	i, err = factory.NewInterceptor("pc-2")
+	require.NoError(t, err)
+	assert.Equal(t, clk, i.(*SendSideInterceptor).clock)
+
+	var _ interceptor.Factory = factory
+}
@@ -3,6 +3,8 @@ package interceptor
 import (
 	"sync/atomic"
 	"time"
+
+	"github.com/thesyncim/bwe/pkg/bwe"
 )
 
 // streamState tracks per-stream state for the BWE interceptor.
@@ -10,23 +12,33 @@ import (
 // This type is unexported and used internally to track:
 // - Stream SSRC for identification
 // - Last packet arrival time for timeout detection
+// - The RTP timestamp converter, when send times fall back to RTP timestamps
+// - The last abs-capture-time, for packets of a frame that lack it
 //
 // The lastPacketTime uses atomic.Value for thread-safe access because:
 // - BindRemoteStream reader updates it on every incoming packet
 // - cleanupLoop reads it periodically to detect inactive streams
 // - Both operations happen concurrently without explicit locking
+//
+// rtpTime and capture are set up at bind time and only used by the stream's
+// reader, which processes packets sequentially. When the cleanup loop removes
+// a paused stream it sets removed, and the reader tracks the same state again
+// on its next packet, so the stream resumes with its send time sources.
 type streamState struct {
 	ssrc           uint32
 	lastPacketTime atomic.Value // stores time.Time
+	removed        atomic.Bool
+	rtpTime        *bwe.RTPTimestampConverter
+	capture        captureTimeInterpolator
 }
 
 // newStreamState creates a new stream state for the given SSRC.
-// The lastPacketTime is initialized to the current time.
-func newStreamState(ssrc uint32) *streamState {
+// The lastPacketTime is initialized to now.
+func newStreamState(ssrc uint32, now time.Time) *streamState {
 	s := &streamState{
 		ssrc: ssrc,
 	}
-	s.lastPacketTime.Store(time.Now())
+	s.lastPacketTime.Store(now)
 	return s
 }
 
@@ -0,0 +1,99 @@
+package interceptor
+
+import (
+	"sync"
+	"time"
+
+	"github.com/pion/interceptor/pkg/twcc"
+	"github.com/pion/rtcp"
+)
+
+// FeedbackMode selects which RTCP congestion control feedback the
+// interceptor sends to the remote sender.
+type FeedbackMode int
+
+const (
+	// FeedbackREMB sends Receiver Estimated Maximum Bitrate packets carrying
+	// the receiver-side estimate. This is the default.
+	FeedbackREMB FeedbackMode = iota
+
+	// FeedbackTransportCC sends transport-wide congestion control feedback
+	// (rtcp.TransportLayerCC) so the sender can run its own estimator.
+	FeedbackTransportCC
+
+	// FeedbackBoth sends both REMB and transport-cc feedback, allowing one
+	// receiver to serve senders that support either mechanism.
+	FeedbackBoth
+)
+
+// String returns a string representation of the FeedbackMode.
+func (m FeedbackMode) String() string {
+	switch m {
+	case FeedbackREMB:
+		return "REMB"
+	case FeedbackTransportCC:
+		return "TransportCC"
+	case FeedbackBoth:
+		return "Both"
+	default:
+		return "Unknown"
+	}
+}
+
+// sendsREMB reports whether the mode includes REMB feedback.
+func (m FeedbackMode) sendsREMB() bool {
+	return m == FeedbackREMB || m == FeedbackBoth
+}
+
+// sendsTransportCC reports whether the mode includes transport-cc feedback.
+func (m FeedbackMode) sendsTransportCC() bool {
+	return m == FeedbackTransportCC || m == FeedbackBoth
+}
+
+// valid reports whether the mode is one of the defined values.
+func (m FeedbackMode) valid() bool {
+	return m >= FeedbackREMB && m <= FeedbackBoth
+}
+
+// defaultTWCCInterval is the default transport-cc feedback cadence.
+// This matches the 100ms interval used by libwebrtc and Pion's twcc package.
+const defaultTWCCInterval = 100 * time.Millisecond
+
+// twccFeedback records transport-wide sequence numbers and arrival times and
+// builds rtcp.TransportLayerCC feedback from them.
+//
+// The underlying twcc.Recorder is not safe for concurrent use, so all
+// access goes through mu: the RTP readers record packets while the
+// feedback loop builds reports.
+type twccFeedback struct {
+	mu        sync.Mutex
+	recorder  *twcc.Recorder
+	startTime time.Time
+}
+
+// newTWCCFeedback creates a transport-cc recorder that uses senderSSRC in
+// the generated feedback packets.
+func newTWCCFeedback(senderSSRC uint32, startTime time.Time) *twccFeedback {
+	return &twccFeedback{
+		recorder:  twcc.NewRecorder(senderSSRC),
+		startTime: startTime,
+	}
+}
+
+// Record marks the packet with the given transport-wide sequence number as
+// received at arrival.
+func (f *twccFeedback) Record(mediaSSRC uint32, seq uint16, arrival time.Time) {
+	arrivalUs := arrival.Sub(f.startTime).Microseconds()
+
+	f.mu.Lock()
+	f.recorder.Record(mediaSSRC, seq, arrivalUs)
+	f.mu.Unlock()
+}
+
+// BuildFeedback returns the feedback packets for everything recorded since
+// the previous call. Returns nil if nothing new was recorded.
+func (f *twccFeedback) BuildFeedback() []rtcp.Packet {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.recorder.BuildFeedbackPacket()
+}
@@ -0,0 +1,205 @@
+package interceptor
+
+import (
+	"testing"
+	"time"
+
+	"github.com/pion/interceptor"
+	"github.com/pion/rtcp"
+	"github.com/pion/rtp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/thesyncim/bwe/pkg/bwe"
+)
+
+// makeRTPWithTransportCC creates an RTP packet carrying both the abs-send-time
+// and transport-wide sequence number extensions.
+func makeRTPWithTransportCC(ssrc uint32, absID, twccID uint8, sendTime uint32, transportSeq uint16) []byte {
+	pkt := &rtp.Packet{
+		Header: rtp.Header{
+			Version:        2,
+			PayloadType:    96,
+			SequenceNumber: transportSeq,
+			Timestamp:      12345678,
+			SSRC:           ssrc,
+		},
+		Payload: []byte{0x00, 0x01, 0x02, 0x03},
+	}
+
+	_ = pkt.Header.SetExtension(absID, []byte{
+		byte(sendTime >> 16),
+		byte(sendTime >> 8),
+		byte(sendTime),
+	})
+
+	tcc := rtp.TransportCCExtension{TransportSequence: transportSeq}
+	tccData, _ := tcc.Marshal()
+	_ = pkt.Header.SetExtension(twccID, tccData)
+
+	data, _ := pkt.Marshal()
+	return data
+}
+
+// transportCCStreamInfo returns a StreamInfo negotiating abs-send-time (ID 3)
+// and transport-cc (ID 5).
+func transportCCStreamInfo(ssrc uint32) *interceptor.StreamInfo {
+	return &interceptor.StreamInfo{
+		SSRC: ssrc,
+		RTPHeaderExtensions: []interceptor.RTPHeaderExtension{
+			{URI: AbsSendTimeURI, ID: 3},
+			{URI: TransportCCURI, ID: 5},
+		},
+	}
+}
+
+func TestFeedbackMode_String(t *testing.T) {
+	assert.Equal(t, "REMB", FeedbackREMB.String())
+	assert.Equal(t, "TransportCC", FeedbackTransportCC.String())
+	assert.Equal(t, "Both", FeedbackBoth.String())
+	assert.Equal(t, "Unknown", FeedbackMode(42).String())
+}
+
+func TestFindTransportCCID(t *testing.T) {
+	exts := []interceptor.RTPHeaderExtension{
+		{URI: AbsSendTimeURI, ID: 3},
+		{URI: TransportCCURI, ID: 5},
+	}
+	assert.Equal(t, uint8(5), FindTransportCCID(exts))
+	assert.Equal(t, uint8(0), FindTransportCCID(exts[:1]))
+}
+
+func TestTransportCC_FeedbackSent(t *testing.T) {
+	estimator := bwe.NewBandwidthEstimator(bwe.DefaultBandwidthEstimatorConfig(), nil)
+	i := NewBWEInterceptor(estimator,
+		WithFeedbackMode(FeedbackTransportCC),
+		WithTWCCInterval(20*time.Millisecond),
+		WithREMBInterval(20*time.Millisecond),
+	)
+	defer i.Close()
+
+	testSSRC := uint32(0x11223344)
+	var packets [][]byte
+	for j := 0; j < 10; j++ {
+		packets = append(packets, makeRTPWithTransportCC(testSSRC, 3, 5, uint32(j*0x1000), uint16(100+j)))
+	}
+	reader := i.BindRemoteStream(transportCCStreamInfo(testSSRC), &mockRTPReader{packets: packets})
+
+	buf := make([]byte, 1500)
+	for range packets {
+		_, _, err := reader.Read(buf, nil)
+		require.NoError(t, err)
+	}
+
+	mockWriter := &mockRTCPWriter{}
+	i.BindRTCPWriter(mockWriter)
+	time.Sleep(100 * time.Millisecond)
+
+	var tccPackets []*rtcp.TransportLayerCC
+	for _, pkt := range mockWriter.getPackets() {
+		switch p := pkt.(type) {
+		case *rtcp.TransportLayerCC:
+			tccPackets = append(tccPackets, p)
+		case *rtcp.ReceiverEstimatedMaximumBitrate:
+			t.Fatalf("REMB should not be sent in transport-cc mode")
+		}
+	}
+	require.NotEmpty(t, tccPackets, "expected transport-cc feedback")
+
+	fb := tccPackets[0]
+	assert.Equal(t, uint16(100), fb.BaseSequenceNumber, "feedback should start at first transport seq")
+	assert.Equal(t, uint16(10), fb.PacketStatusCount, "feedback should cover all received packets")
+	assert.Equal(t, testSSRC, fb.MediaSSRC)
+}
+
+func TestTransportCC_BothModes(t *testing.T) {
+	estimator := bwe.NewBandwidthEstimator(bwe.DefaultBandwidthEstimatorConfig(), nil)
+	i := NewBWEInterceptor(estimator,
+		WithFeedbackMode(FeedbackBoth),
+		WithTWCCInterval(20*time.Millisecond),
+		WithREMBInterval(20*time.Millisecond),
+	)
+	defer i.Close()
+
+	testSSRC := uint32(0x11223344)
+	var packets [][]byte
+	for j := 0; j < 20; j++ {
+		packets = append(packets, makeRTPWithTransportCC(testSSRC, 3, 5, uint32(j*0x1000), uint16(j)))
+	}
+	reader := i.BindRemoteStream(transportCCStreamInfo(testSSRC), &mockRTPReader{packets: packets})
+
+	buf := make([]byte, 1500)
+	for range packets {
+		_, _, err := reader.Read(buf, nil)
+		require.NoError(t, err)
+		time.Sleep(2 * time.Millisecond)
+	}
+
+	mockWriter := &mockRTCPWriter{}
+	i.BindRTCPWriter(mockWriter)
+	time.Sleep(100 * time.Millisecond)
+
+	var foundREMB, foundTCC bool
+	for _, pkt := range mockWriter.getPackets() {
+		switch pkt.(type) {
+		case *rtcp.TransportLayerCC:
+			foundTCC = true
+		case *rtcp.ReceiverEstimatedMaximumBitrate:
+			foundREMB = true
+		}
+	}
+	assert.True(t, foundREMB, "expected REMB feedback")
+	assert.True(t, foundTCC, "expected transport-cc feedback")
+}
+
+func TestTransportCC_REMBModeDoesNotRecord(t *testing.T) {
+	estimator := bwe.NewBandwidthEstimator(bwe.DefaultBandwidthEstimatorConfig(), nil)
+	i := NewBWEInterceptor(estimator)
+	defer i.Close()
+
+	assert.Nil(t, i.twcc, "transport-cc recorder should not be created in REMB mode")
+}
+
+func TestTransportCC_NoFeedbackWithoutPackets(t *testing.T) {
+	estimator := bwe.NewBandwidthEstimator(bwe.DefaultBandwidthEstimatorConfig(), nil)
+	i := NewBWEInterceptor(estimator,
+		WithFeedbackMode(FeedbackTransportCC),
+		WithTWCCInterval(10*time.Millisecond),
+	)
+	defer i.Close()
+
+	mockWriter := &mockRTCPWriter{}
+	i.BindRTCPWriter(mockWriter)
+	time.Sleep(50 * time.Millisecond)
+
+	assert.Empty(t, mockWriter.getPackets(), "no feedback should be sent before packets arrive")
+}
+
+func TestFactory_FeedbackModeOptions(t *testing.T) {
+	factory, err := NewBWEInterceptorFactory(
+		WithFactoryFeedbackMode(FeedbackBoth),
+		WithFactoryTWCCInterval(50*time.Millisecond),
+	)
+	require.NoError(t, err)
+	assert.Equal(t, FeedbackBoth, factory.feedbackMode)
+	assert.Equal(t, 50*time.Millisecond, factory.twccInterval)
+
+	i, err := factory.NewInterceptor("test")
+	require.NoError(t, err)
+	defer i.Close()
+
+	bweInt := i.(*BWEInterceptor)
+	assert.Equal(t, FeedbackBoth, bweInt.feedbackMode)
+	assert.Equal(t, 50*time.Millisecond, bweInt.twccInterval)
+	assert.NotNil(t, bweInt.twcc)
+}
+
+func TestFactory_InvalidFeedbackOptions(t *testing.T) {
+	_, err := NewBWEInterceptorFactory(WithFactoryFeedbackMode(FeedbackMode(9)))
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "feedback mode")
+
+	_, err = NewBWEInterceptorFactory(WithFactoryTWCCInterval(0))
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "transport-cc interval")
+}
@@ -95,6 +95,11 @@ func (k *KalmanFilter) Estimate() float64 {
 	return k.estimate
 }
 
+// NoiseVariance returns the adaptive measurement noise variance in ms².
+func (k *KalmanFilter) NoiseVariance() float64 {
+	return k.measureNoise
+}
+
 // Reset reinitializes the filter state to initial conditions.
 // This can be used when switching streams or after long gaps.
 func (k *KalmanFilter) Reset() {
@@ -0,0 +1,229 @@
+// Package bwe implements Google Congestion Control (GCC) receiver-side
+// bandwidth estimation for WebRTC.
+package bwe
+
+import "math"
+
+// CapacityKalmanConfig holds tunable parameters for the two-state Kalman filter.
+// Default values follow the original GCC draft (draft-alvestrand-rmcat-congestion-02)
+// and libwebrtc's OveruseEstimator.
+type CapacityKalmanConfig struct {
+	// SlopeProcessNoise is the state noise variance of the inverse capacity
+	// slope 1/C, in (ms/byte)^2.
+	// Default: 1e-13
+	SlopeProcessNoise float64
+
+	// OffsetProcessNoise is the state noise variance of the offset m, in ms^2.
+	// Default: 1e-3
+	OffsetProcessNoise float64
+
+	// InitialSlope is the initial inverse capacity in ms per byte.
+	// Default: 8/512 (512 kbps)
+	InitialSlope float64
+
+	// InitialSlopeError is the initial error variance of the slope.
+	// Default: 100
+	InitialSlopeError float64
+
+	// InitialOffsetError is the initial error variance of the offset.
+	// Default: 0.1
+	InitialOffsetError float64
+
+	// InitialNoise is the initial measurement noise variance in ms^2.
+	// Default: 50
+	InitialNoise float64
+}
+
+// DefaultCapacityKalmanConfig returns the default two-state Kalman configuration.
+func DefaultCapacityKalmanConfig() CapacityKalmanConfig {
+	return CapacityKalmanConfig{
+		SlopeProcessNoise:  1e-13,
+		OffsetProcessNoise: 1e-3,
+		InitialSlope:       8.0 / 512.0,
+		InitialSlopeError:  100,
+		InitialOffsetError: 0.1,
+		InitialNoise:       50,
+	}
+}
+
+// Noise estimator constants (from libwebrtc overuse_estimator.cc).
+const (
+	// capacityKalmanNoiseAlpha is the smoothing factor for the measurement
+	// noise while the filter is warming up.
+	capacityKalmanNoiseAlpha = 0.01
+
+	// capacityKalmanSteadyNoiseAlpha is used once capacityKalmanWarmupDeltas
+	// measurements have been seen.
+	capacityKalmanSteadyNoiseAlpha = 0.002
+	capacityKalmanWarmupDeltas     = 300
+
+	// capacityKalmanMaxDeltas caps the measurement counter.
+	capacityKalmanMaxDeltas = 1000
+)
+
+// CapacityKalmanFilter implements the two-state Kalman filter of the original
+// GCC draft. The state is the inverse capacity slope 1/C and the queuing
+// offset m, with the measurement model
+//
+//	d(i) = dL(i) / C + m(i) + v(i)
+//
+// where d(i) is the delay variation between two packet groups, dL(i) the
+// difference in their sizes and v(i) measurement noise. The offset m is the
+// queuing delay gradient used for overuse detection, like the output of the
+// scalar KalmanFilter; the slope additionally yields a direct estimate of the
+// link capacity.
+//
+// The measurement noise is only adapted while the link is in the normal
+// state, and the offset covariance is inflated when the offset moves against
+// the detected congestion, so the filter reacts quickly to a change in trend.
+type CapacityKalmanFilter struct {
+	config CapacityKalmanConfig
+
+	slope      float64       // 1/C in ms per byte
+	offset     float64       // m in ms
+	prevOffset float64       // Offset before the last update
+	errorCov   [2][2]float64 // State error covariance E
+
+	avgNoise  float64 // Mean of the residual
+	varNoise  float64 // Measurement noise variance
+	numDeltas int     // Measurements seen, capped at capacityKalmanMaxDeltas
+}
+
+// NewCapacityKalmanFilter creates a new two-state Kalman filter.
+// Non-positive noise and initial values take the libwebrtc values from
+// DefaultCapacityKalmanConfig, e.g. an InitialSlope of 8/512.
+func NewCapacityKalmanFilter(config CapacityKalmanConfig) *CapacityKalmanFilter {
+	defaults := DefaultCapacityKalmanConfig()
+	if config.SlopeProcessNoise <= 0 {
+		config.SlopeProcessNoise = defaults.SlopeProcessNoise
+	}
+	if config.OffsetProcessNoise <= 0 {
+		config.OffsetProcessNoise = defaults.OffsetProcessNoise
+	}
+	if config.InitialSlope <= 0 {
+		config.InitialSlope = defaults.InitialSlope
+	}
+	if config.InitialSlopeError <= 0 {
+		config.InitialSlopeError = defaults.InitialSlopeError
+	}
+	if config.InitialOffsetError <= 0 {
+		config.InitialOffsetError = defaults.InitialOffsetError
+	}
+	if config.InitialNoise <= 0 {
+		config.InitialNoise = defaults.InitialNoise
+	}
+
+	k := &CapacityKalmanFilter{config: config}
+	k.Reset()
+	return k
+}
+
+// Update processes a delay variation measurement in milliseconds together
+// with the size difference in bytes between the two packet groups, and
+// returns the updated offset estimate in milliseconds.
+//
+// usage is the current state of the overuse detector; the measurement noise
+// is only adapted while it is BwNormal.
+func (k *CapacityKalmanFilter) Update(delayMs float64, sizeDelta int, usage BandwidthUsage) float64 {
+	k.numDeltas = min(k.numDeltas+1, capacityKalmanMaxDeltas)
+
+	// Predict: add process noise
+	k.errorCov[0][0] += k.config.SlopeProcessNoise
+	k.errorCov[1][1] += k.config.OffsetProcessNoise
+
+	// An offset moving against the detected congestion suggests the trend
+	// is changing: trust the prediction less
+	if (usage == BwOverusing && k.offset < k.prevOffset) ||
+		(usage == BwUnderusing && k.offset > k.prevOffset) {
+		k.errorCov[1][1] += 10 * k.config.OffsetProcessNoise
+	}
+
+	h := [2]float64{float64(sizeDelta), 1.0}
+	eh := [2]float64{
+		k.errorCov[0][0]*h[0] + k.errorCov[0][1]*h[1],
+		k.errorCov[1][0]*h[0] + k.errorCov[1][1]*h[1],
+	}
+
+	residual := delayMs - k.slope*h[0] - k.offset
+
+	// Cap outliers at 3 standard deviations for the noise estimate
+	maxResidual := 3 * math.Sqrt(k.varNoise)
+	k.updateNoise(math.Max(-maxResidual, math.Min(residual, maxResidual)), usage == BwNormal)
+
+	denom := k.varNoise + h[0]*eh[0] + h[1]*eh[1]
+	gain := [2]float64{eh[0] / denom, eh[1] / denom}
+
+	// E = (I - K h^T) E
+	ikh := [2][2]float64{
+		{1 - gain[0]*h[0], -gain[0] * h[1]},
+		{-gain[1] * h[0], 1 - gain[1]*h[1]},
+	}
+	e := k.errorCov
+	k.errorCov[0][0] = e[0][0]*ikh[0][0] + e[1][0]*ikh[0][1]
+	k.errorCov[0][1] = e[0][1]*ikh[0][0] + e[1][1]*ikh[0][1]
+	k.errorCov[1][0] = e[0][0]*ikh[1][0] + e[1][0]*ikh[1][1]
+	k.errorCov[1][1] = e[0][1]*ikh[1][0] + e[1][1]*ikh[1][1]
+
+	k.slope += gain[0] * residual
+	k.prevOffset = k.offset
+	k.offset += gain[1] * residual
+
+	return k.offset
+}
+
+// updateNoise folds a capped residual into the measurement noise estimate.
+// The noise is only learned in the stable state, where the residual is not
+// dominated by a queuing trend.
+func (k *CapacityKalmanFilter) updateNoise(residual float64, stable bool) {
+	if !stable {
+		return
+	}
+
+	alpha := capacityKalmanNoiseAlpha
+	if k.numDeltas > capacityKalmanWarmupDeltas {
+		alpha = capacityKalmanSteadyNoiseAlpha
+	}
+
+	k.avgNoise = (1-alpha)*k.avgNoise + alpha*residual
+	diff := k.avgNoise - residual
+	k.varNoise = math.Max(1.0, (1-alpha)*k.varNoise+alpha*diff*diff)
+}
+
+// Estimate returns the current offset estimate in milliseconds.
+func (k *CapacityKalmanFilter) Estimate() float64 {
+	return k.offset
+}
+
+// NoiseVariance returns the adaptive measurement noise variance in ms².
+func (k *CapacityKalmanFilter) NoiseVariance() float64 {
+	return k.varNoise
+}
+
+// Slope returns the current inverse capacity estimate in ms per byte.
+func (k *CapacityKalmanFilter) Slope() float64 {
+	return k.slope
+}
+
+// Capacity returns the link capacity implied by the slope, in bits per second.
+// Returns (capacity, true) if the slope is positive, (0, false) otherwise.
+func (k *CapacityKalmanFilter) Capacity() (int64, bool) {
+	if k.slope <= 0 {
+		return 0, false
+	}
+	// 1/slope is bytes per millisecond
+	return int64(8000 / k.slope), true
+}
+
+// Reset reinitializes the filter state to initial conditions.
+func (k *CapacityKalmanFilter) Reset() {
+	k.slope = k.config.InitialSlope
+	k.offset = 0
+	k.prevOffset = 0
+	k.errorCov = [2][2]float64{
+		{k.config.InitialSlopeError, 0},
+		{0, k.config.InitialOffsetError},
+	}
+	k.avgNoise = 0
+	k.varNoise = k.config.InitialNoise
+	k.numDeltas = 0
+}
@@ -0,0 +1,121 @@
+package bwe
+
+import (
+	"math/rand"
+	"testing"
+	"time"
+
+	"github.com/thesyncim/bwe/pkg/bwe/clock"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// capacityMeasurement returns the delay variation in ms of a group size
+// difference on a link of the given capacity in bits per second.
+func capacityMeasurement(sizeDelta int, capacity float64) float64 {
+	bytesPerMs := capacity / 8 / 1000
+	return float64(sizeDelta) / bytesPerMs
+}
+
+func TestCapacityKalmanFilter_InitialState(t *testing.T) {
+	k := NewCapacityKalmanFilter(DefaultCapacityKalmanConfig())
+
+	assert.Equal(t, 0.0, k.Estimate())
+	capacity, ok := k.Capacity()
+	require.True(t, ok)
+	assert.Equal(t, int64(512_000), capacity, "initial slope corresponds to 512 kbps")
+}
+
+func TestCapacityKalmanFilter_ConvergesToCapacity(t *testing.T) {
+	k := NewCapacityKalmanFilter(DefaultCapacityKalmanConfig())
+	rng := rand.New(rand.NewSource(1))
+
+	for i := 0; i < 500; i++ {
+		sizeDelta := rng.Intn(10_000) - 5_000
+		noise := rng.NormFloat64() * 0.5
+		k.Update(capacityMeasurement(sizeDelta, 2_000_000)+noise, sizeDelta, BwNormal)
+	}
+
+	capacity, ok := k.Capacity()
+	require.True(t, ok)
+	assert.InDelta(t, 2_000_000, capacity, 200_000, "slope should converge to 1/C")
+	assert.InDelta(t, 0, k.Estimate(), 0.5, "no queuing: offset stays near zero")
+}
+
+func TestCapacityKalmanFilter_TracksOffset(t *testing.T) {
+	k := NewCapacityKalmanFilter(DefaultCapacityKalmanConfig())
+	rng := rand.New(rand.NewSource(2))
+
+	// Queue building by 3ms per group on top of the size effect
+	for i := 0; i < 1000; i++ {
+		sizeDelta := rng.Intn(4_000) - 2_000
+		k.Update(capacityMeasurement(sizeDelta, 1_000_000)+3, sizeDelta, BwOverusing)
+	}
+
+	assert.InDelta(t, 3, k.Estimate(), 0.5, "offset should track the queuing gradient")
+	capacity, ok := k.Capacity()
+	require.True(t, ok)
+	assert.InDelta(t, 1_000_000, capacity, 150_000)
+}
+
+func TestCapacityKalmanFilter_EqualSizesBehaveLikeScalarFilter(t *testing.T) {
+	k := NewCapacityKalmanFilter(DefaultCapacityKalmanConfig())
+
+	// Without size differences only the offset is observable
+	for i := 0; i < 1000; i++ {
+		k.Update(2, 0, BwNormal)
+	}
+	assert.InDelta(t, 2, k.Estimate(), 0.2)
+	assert.InDelta(t, DefaultCapacityKalmanConfig().InitialSlope, k.Slope(), 1e-9,
+		"slope is unchanged when groups have equal size")
+}
+
+func TestCapacityKalmanFilter_Reset(t *testing.T) {
+	k := NewCapacityKalmanFilter(DefaultCapacityKalmanConfig())
+	for i := 0; i < 50; i++ {
+		k.Update(capacityMeasurement(3_000, 4_000_000)+1, 3_000, BwNormal)
+	}
+	require.NotEqual(t, 0.0, k.Estimate())
+
+	k.Reset()
+	assert.Equal(t, 0.0, k.Estimate())
+	assert.Equal(t, DefaultCapacityKalmanConfig().InitialSlope, k.Slope())
+}
+
+func TestNewCapacityKalmanFilter_AppliesDefaults(t *testing.T) {
+	k := NewCapacityKalmanFilter(CapacityKalmanConfig{})
+	assert.Equal(t, DefaultCapacityKalmanConfig(), k.config)
+}
+
+func TestDelayEstimator_KalmanCapacity(t *testing.T) {
+	clock := clock.NewVirtual(time.Time{})
+	config := DefaultDelayEstimatorConfig()
+	config.FilterType = FilterKalmanCapacity
+	e := NewDelayEstimator(config, clock)
+
+	// 1 Mbps link; groups of 500 to 1500 bytes sent every 20ms. Each group
+	// arrives once its bytes have been serialized at the bottleneck, which
+	// keeps arrivals more than the burst threshold apart.
+	rng := rand.New(rand.NewSource(3))
+	start := clock.Now()
+	for i := 0; i < 500; i++ {
+		sendTime := time.Duration(i) * 20 * time.Millisecond
+		size := 500 + rng.Intn(3)*500
+		serialization := time.Duration(capacityMeasurement(size, 1_000_000) * float64(time.Millisecond))
+		e.OnPacket(PacketInfo{
+			ArrivalTime: start.Add(sendTime + serialization),
+			SendTime:    DurationToAbsSendTime(sendTime) % AbsSendTimeMax,
+			Size:        size,
+			SSRC:        0x1234,
+		})
+	}
+
+	capacity, ok := e.KalmanCapacity()
+	require.True(t, ok)
+	assert.InDelta(t, 1_000_000, capacity, 150_000, "slope should match the serialization time of size differences")
+
+	// Other filters do not estimate capacity
+	_, ok = NewDelayEstimator(DefaultDelayEstimatorConfig(), clock).KalmanCapacity()
+	assert.False(t, ok)
+}
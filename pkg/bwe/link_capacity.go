@@ -0,0 +1,117 @@
+// Package bwe implements Google Congestion Control (GCC) receiver-side
+// bandwidth estimation for WebRTC.
+package bwe
+
+import (
+	"math"
+)
+
+// Link capacity estimator constants (from libwebrtc link_capacity_estimator.cc).
+const (
+	// linkCapacityOveruseAlpha is the smoothing factor for samples taken at
+	// overuse, where the incoming rate approximates the bottleneck.
+	linkCapacityOveruseAlpha = 0.05
+
+	// Bounds on the normalized variance, in kbps.
+	linkCapacityMinDeviation = 0.4
+	linkCapacityMaxDeviation = 2.5
+
+	// linkCapacityBoundDeviations is how many standard deviations around the
+	// estimate are still considered the same link capacity.
+	linkCapacityBoundDeviations = 3
+)
+
+// LinkCapacityEstimator tracks the capacity of the bottleneck link as a
+// running average and variance of the incoming rate observed each time
+// congestion is detected.
+//
+// At overuse the incoming rate is what the bottleneck actually delivers, so
+// these samples cluster around the link capacity. The rate controller uses
+// the estimate to switch from multiplicative to additive increase near the
+// capacity, avoiding repeated overshoot on a stable bottleneck, and to bound
+// decreases computed from a noisy incoming rate.
+//
+// The variance is normalized by the estimate and kept in kbps, matching
+// libwebrtc's LinkCapacityEstimator.
+type LinkCapacityEstimator struct {
+	estimateKbps  float64
+	deviationKbps float64
+	hasEstimate   bool
+}
+
+// NewLinkCapacityEstimator creates a new link capacity estimator with no estimate.
+func NewLinkCapacityEstimator() *LinkCapacityEstimator {
+	return &LinkCapacityEstimator{
+		deviationKbps: linkCapacityMinDeviation,
+	}
+}
+
+// OnOveruseDetected adds the incoming rate measured at overuse, in bits per second.
+func (l *LinkCapacityEstimator) OnOveruseDetected(incomingRate int64) {
+	l.update(incomingRate, linkCapacityOveruseAlpha)
+}
+
+// update folds one sample into the running average and normalized variance.
+func (l *LinkCapacityEstimator) update(sample int64, alpha float64) {
+	sampleKbps := float64(sample) / 1000
+	if !l.hasEstimate {
+		l.estimateKbps = sampleKbps
+		l.hasEstimate = true
+	} else {
+		l.estimateKbps = (1-alpha)*l.estimateKbps + alpha*sampleKbps
+	}
+
+	// Variance normalized by the estimate so it scales with the link
+	norm := math.Max(l.estimateKbps, 1.0)
+	errorKbps := l.estimateKbps - sampleKbps
+	l.deviationKbps = (1-alpha)*l.deviationKbps + alpha*errorKbps*errorKbps/norm
+	l.deviationKbps = math.Min(math.Max(l.deviationKbps, linkCapacityMinDeviation), linkCapacityMaxDeviation)
+}
+
+// Estimate returns the link capacity in bits per second.
+// Returns (capacity, true) if an estimate exists, (0, false) otherwise.
+func (l *LinkCapacityEstimator) Estimate() (int64, bool) {
+	if !l.hasEstimate {
+		return 0, false
+	}
+	return int64(l.estimateKbps * 1000), true
+}
+
+// Deviation returns the standard deviation of the capacity in bits per second.
+func (l *LinkCapacityEstimator) Deviation() int64 {
+	if !l.hasEstimate {
+		return 0
+	}
+	return int64(math.Sqrt(l.deviationKbps*l.estimateKbps) * 1000)
+}
+
+// UpperBound returns the highest rate still considered consistent with the
+// estimate, in bits per second. Without an estimate there is no bound.
+func (l *LinkCapacityEstimator) UpperBound() int64 {
+	if !l.hasEstimate {
+		return math.MaxInt64
+	}
+	return int64(l.estimateKbps*1000) + linkCapacityBoundDeviations*l.Deviation()
+}
+
+// LowerBound returns the lowest rate still considered consistent with the
+// estimate, in bits per second.
+func (l *LinkCapacityEstimator) LowerBound() int64 {
+	if !l.hasEstimate {
+		return 0
+	}
+	return max(0, int64(l.estimateKbps*1000)-linkCapacityBoundDeviations*l.Deviation())
+}
+
+// HasEstimate returns whether a capacity estimate exists.
+func (l *LinkCapacityEstimator) HasEstimate() bool {
+	return l.hasEstimate
+}
+
+// Reset discards the estimate. Call this when measurements show the link
+// capacity has changed (rates outside the bounds).
+func (l *LinkCapacityEstimator) Reset() {
+	l.estimateKbps = 0
+	l.deviationKbps = linkCapacityMinDeviation
+	l.hasEstimate = false
+}
@@ -0,0 +1,56 @@
+package bwe
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLinkCapacityEstimator_NoEstimate(t *testing.T) {
+	l := NewLinkCapacityEstimator()
+
+	_, ok := l.Estimate()
+	assert.False(t, ok)
+	assert.False(t, l.HasEstimate())
+	assert.Equal(t, int64(math.MaxInt64), l.UpperBound(), "no upper bound without an estimate")
+	assert.Equal(t, int64(0), l.LowerBound())
+}
+
+func TestLinkCapacityEstimator_FirstSample(t *testing.T) {
+	l := NewLinkCapacityEstimator()
+	l.OnOveruseDetected(2_000_000)
+
+	capacity, ok := l.Estimate()
+	require.True(t, ok)
+	assert.Equal(t, int64(2_000_000), capacity, "first sample is taken as-is")
+
+	// Minimum deviation: sqrt(0.4 * 2000) kbps ~= 28 kbps
+	assert.InDelta(t, 28_284, l.Deviation(), 100)
+	assert.Greater(t, l.UpperBound(), capacity)
+	assert.Less(t, l.LowerBound(), capacity)
+}
+
+func TestLinkCapacityEstimator_Smoothing(t *testing.T) {
+	l := NewLinkCapacityEstimator()
+	l.OnOveruseDetected(2_000_000)
+	l.OnOveruseDetected(1_000_000)
+
+	// 0.95 * 2000 + 0.05 * 1000 = 1950 kbps
+	capacity, _ := l.Estimate()
+	assert.InDelta(t, 1_950_000, capacity, 1)
+
+	// Large error widens the deviation up to its maximum
+	assert.InDelta(t, math.Sqrt(2.5*1950)*1000, l.Deviation(), 100)
+}
+
+func TestLinkCapacityEstimator_Reset(t *testing.T) {
+	l := NewLinkCapacityEstimator()
+	l.OnOveruseDetected(2_000_000)
+	l.Reset()
+
+	_, ok := l.Estimate()
+	assert.False(t, ok)
+	assert.Equal(t, int64(0), l.Deviation())
+}
@@ -0,0 +1,323 @@
+// Package bwe implements Google Congestion Control (GCC) receiver-side
+// bandwidth estimation for WebRTC.
+package bwe
+
+import (
+	"time"
+)
+
+// EstimateBound identifies which controller currently limits the final
+// bandwidth estimate.
+type EstimateBound int
+
+const (
+	// BoundDelay indicates the delay-based AIMD estimate is the active bound.
+	BoundDelay EstimateBound = iota
+	// BoundLoss indicates the loss-based estimate is the active bound.
+	BoundLoss
+)
+
+// String returns a string representation of the EstimateBound.
+func (b EstimateBound) String() string {
+	switch b {
+	case BoundDelay:
+		return "Delay"
+	case BoundLoss:
+		return "Loss"
+	default:
+		return "Unknown"
+	}
+}
+
+// LossControllerConfig configures the loss-based rate controller.
+// Default values follow draft-ietf-rmcat-gcc Section 6.
+type LossControllerConfig struct {
+	// Enabled turns the loss-based controller on. When disabled, the final
+	// estimate is the delay-based estimate alone.
+	// Default: true
+	Enabled bool
+
+	// WindowSize is the duration of the sliding window over which the
+	// fraction of lost packets is computed.
+	// Default: 1 second
+	WindowSize time.Duration
+
+	// UpdateInterval is how often the loss-based estimate is re-evaluated.
+	// This plays the role of the RTCP report interval in the GCC draft.
+	// Default: 1 second
+	UpdateInterval time.Duration
+
+	// MinPackets is the minimum number of expected packets in the window
+	// before a loss fraction is considered meaningful.
+	// Default: 20
+	MinPackets int
+
+	// LowLossThreshold is the loss fraction below which the estimate increases.
+	// Default: 0.02 (2%)
+	LowLossThreshold float64
+
+	// HighLossThreshold is the loss fraction above which the estimate decreases.
+	// Between LowLossThreshold and HighLossThreshold the estimate is held.
+	// Default: 0.10 (10%)
+	HighLossThreshold float64
+
+	// IncreaseFactor is the multiplicative increase applied per update while
+	// loss is below LowLossThreshold.
+	// Default: 1.05
+	IncreaseFactor float64
+}
+
+// DefaultLossControllerConfig returns the default configuration for the
+// loss-based controller.
+func DefaultLossControllerConfig() LossControllerConfig {
+	return LossControllerConfig{
+		Enabled:           true,
+		WindowSize:        time.Second,
+		UpdateInterval:    time.Second,
+		MinPackets:        20,
+		LowLossThreshold:  0.02,
+		HighLossThreshold: 0.10,
+		IncreaseFactor:    1.05,
+	}
+}
+
+// lossSample records how many packets were expected and received when a
+// single packet arrived.
+type lossSample struct {
+	timestamp time.Time
+	expected  int64
+	received  int64
+}
+
+// lossStreamState tracks the extended highest sequence number for one SSRC.
+type lossStreamState struct {
+	highestSeq int64 // Extended (unwrapped) highest sequence number seen
+}
+
+// LossController implements the loss-based part of GCC. It tracks RTP
+// sequence-number gaps per SSRC, computes the fraction of lost packets over
+// a sliding window and maintains a loss-based bound on the estimate:
+//
+//	loss > 10%:       As = As * (1 - 0.5 * loss)
+//	2% <= loss <= 10%: As unchanged
+//	loss < 2%:        As = As * 1.05
+//
+// The final estimate is min(delay-based estimate, As). While loss stays low
+// and As is not the limiting bound, As follows the delay-based estimate so
+// it never throttles a loss-free link.
+type LossController struct {
+	config  LossControllerConfig
+	streams map[uint32]*lossStreamState
+	samples []lossSample
+
+	totalExpected int64
+	totalReceived int64
+
+	estimate     int64 // Loss-based bound, 0 until the first loss evaluation
+	limiting     bool  // True after a loss-driven decrease until the bound recovers
+	fractionLost float64
+	lastUpdate   time.Time
+}
+
+// NewLossController creates a new loss-based controller with the given configuration.
+func NewLossController(config LossControllerConfig) *LossController {
+	// Apply defaults for zero values
+	if config.WindowSize <= 0 {
+		config.WindowSize = time.Second
+	}
+	if config.UpdateInterval <= 0 {
+		config.UpdateInterval = time.Second
+	}
+	if config.MinPackets <= 0 {
+		config.MinPackets = 20
+	}
+	if config.LowLossThreshold <= 0 {
+		config.LowLossThreshold = 0.02
+	}
+	if config.HighLossThreshold <= 0 {
+		config.HighLossThreshold = 0.10
+	}
+	if config.IncreaseFactor <= 1.0 {
+		config.IncreaseFactor = 1.05
+	}
+
+	return &LossController{
+		config:  config,
+		streams: make(map[uint32]*lossStreamState),
+		samples: make([]lossSample, 0, 64), // Pre-allocate for typical packet rates
+	}
+}
+
+// OnPacket records the arrival of a packet with the given sequence number.
+//
+// Sequence numbers are unwrapped per SSRC. A packet that advances the highest
+// sequence number adds the gap to the expected count; reordered or duplicate
+// packets only count as received.
+func (l *LossController) OnPacket(ssrc uint32, seq uint16, now time.Time) {
+	l.removeExpired(now)
+
+	state, ok := l.streams[ssrc]
+	if !ok {
+		// First packet of the stream: it is expected and received
+		l.streams[ssrc] = &lossStreamState{highestSeq: int64(seq)}
+		l.addSample(now, 1, 1)
+		return
+	}
+
+	// Unwrap using half-range comparison (same approach as abs-send-time)
+	delta := int64(int16(seq - uint16(state.highestSeq)))
+	if delta > 0 {
+		state.highestSeq += delta
+		l.addSample(now, delta, 1)
+		return
+	}
+
+	// Reordered or duplicate packet
+	l.addSample(now, 0, 1)
+}
+
+// RemoveSSRC drops the sequence number state of an SSRC. Samples already
+// in the window are kept.
+func (l *LossController) RemoveSSRC(ssrc uint32) {
+	delete(l.streams, ssrc)
+}
+
+// addSample appends a sample to the sliding window.
+func (l *LossController) addSample(now time.Time, expected, received int64) {
+	l.samples = append(l.samples, lossSample{
+		timestamp: now,
+		expected:  expected,
+		received:  received,
+	})
+	l.totalExpected += expected
+	l.totalReceived += received
+}
+
+// FractionLost returns the fraction of packets lost in the current window.
+// Returns (fraction, true) if enough packets are expected for a meaningful
+// value, (0, false) otherwise.
+func (l *LossController) FractionLost(now time.Time) (float64, bool) {
+	l.removeExpired(now)
+
+	if l.totalExpected < int64(l.config.MinPackets) {
+		return 0, false
+	}
+
+	lost := l.totalExpected - l.totalReceived
+	if lost <= 0 {
+		// Duplicates and reordering can make received exceed expected
+		return 0, true
+	}
+	return float64(lost) / float64(l.totalExpected), true
+}
+
+// Update re-evaluates the loss-based bound if UpdateInterval has elapsed
+// since the last evaluation, and returns the current bound.
+//
+// Parameters:
+//   - delayEstimate: The current delay-based estimate in bits per second.
+//     Used as the starting point when the loss-based bound is first set
+//     and as the base for decreases when it is lower than the bound.
+//   - maxBitrate: Upper limit for the loss-based bound in bits per second.
+//   - now: Current time
+//
+// Returns (bound, true) if a loss-based bound is active, (0, false) if not
+// enough loss information has been gathered yet.
+func (l *LossController) Update(delayEstimate, maxBitrate int64, now time.Time) (int64, bool) {
+	if !l.lastUpdate.IsZero() && now.Sub(l.lastUpdate) < l.config.UpdateInterval {
+		// A bound that is not limiting follows the delay-based estimate
+		// between updates, so jumps (e.g. after a probe) are not held back.
+		if !l.limiting && l.estimate > 0 && delayEstimate > l.estimate {
+			l.estimate = delayEstimate
+			if maxBitrate > 0 && l.estimate > maxBitrate {
+				l.estimate = maxBitrate
+			}
+		}
+		return l.estimate, l.estimate > 0
+	}
+
+	fraction, ok := l.FractionLost(now)
+	if !ok {
+		return l.estimate, l.estimate > 0
+	}
+	l.lastUpdate = now
+	l.fractionLost = fraction
+
+	// Decreases are applied to whichever bound is currently active so the
+	// loss controller always reacts to the rate actually being targeted.
+	base := l.estimate
+	if base <= 0 || delayEstimate < base {
+		base = delayEstimate
+	}
+
+	switch {
+	case fraction > l.config.HighLossThreshold:
+		l.estimate = int64(float64(base) * (1 - 0.5*fraction))
+		l.limiting = true
+	case fraction < l.config.LowLossThreshold:
+		// While the bound is not limiting, keep it ahead of the delay-based
+		// estimate so that a loss-free link is governed by delay alone.
+		// Once loss has pulled it below, recover at IncreaseFactor per update.
+		if !l.limiting {
+			l.estimate = max(l.estimate, delayEstimate)
+		}
+		l.estimate = int64(float64(l.estimate) * l.config.IncreaseFactor)
+		if l.estimate >= delayEstimate {
+			l.limiting = false
+		}
+	default:
+		// Hold: keep the bound where it is
+		if l.estimate <= 0 {
+			l.estimate = base
+		}
+	}
+
+	if maxBitrate > 0 && l.estimate > maxBitrate {
+		l.estimate = maxBitrate
+	}
+
+	return l.estimate, true
+}
+
+// Estimate returns the current loss-based bound in bits per second.
+// Returns 0 if no bound has been established yet.
+func (l *LossController) Estimate() int64 {
+	return l.estimate
+}
+
+// LastFractionLost returns the loss fraction used in the most recent update.
+func (l *LossController) LastFractionLost() float64 {
+	return l.fractionLost
+}
+
+// Reset clears all loss tracking state.
+// Call this when switching streams or after extended silence.
+func (l *LossController) Reset() {
+	l.streams = make(map[uint32]*lossStreamState)
+	l.samples = l.samples[:0] // Keep capacity, clear contents
+	l.totalExpected = 0
+	l.totalReceived = 0
+	l.estimate = 0
+	l.limiting = false
+	l.fractionLost = 0
+	l.lastUpdate = time.Time{}
+}
+
+// removeExpired removes all samples older than WindowSize from now.
+func (l *LossController) removeExpired(now time.Time) {
+	cutoff := now.Add(-l.config.WindowSize)
+
+	expiredCount := 0
+	for i, s := range l.samples {
+		if !s.timestamp.Before(cutoff) {
+			break
+		}
+		l.totalExpected -= s.expected
+		l.totalReceived -= s.received
+		expiredCount = i + 1
+	}
+
+	if expiredCount > 0 {
+		l.samples = l.samples[expiredCount:]
+	}
+}
@@ -0,0 +1,266 @@
+package bwe
+
+import (
+	"testing"
+	"time"
+
+	"github.com/thesyncim/bwe/pkg/bwe/clock"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// feedLossPackets feeds count packets at 10ms intervals, dropping every
+// dropEvery-th sequence number (0 = no loss). Returns the next sequence number.
+func feedLossPackets(l *LossController, clock *clock.Virtual, seq uint16, count, dropEvery int) uint16 {
+	for i := 0; i < count; i++ {
+		if dropEvery > 0 && i%dropEvery == 0 {
+			seq++ // Skip this sequence number (lost)
+		}
+		l.OnPacket(0x1234, seq, clock.Now())
+		seq++
+		clock.Advance(10 * time.Millisecond)
+	}
+	return seq
+}
+
+func TestLossController_NoLoss(t *testing.T) {
+	clock := clock.NewVirtual(time.Time{})
+	l := NewLossController(DefaultLossControllerConfig())
+
+	feedLossPackets(l, clock, 0, 50, 0)
+
+	fraction, ok := l.FractionLost(clock.Now())
+	require.True(t, ok, "should have enough packets for loss fraction")
+	assert.Equal(t, 0.0, fraction, "no gaps should mean no loss")
+}
+
+func TestLossController_NotEnoughPackets(t *testing.T) {
+	clock := clock.NewVirtual(time.Time{})
+	l := NewLossController(DefaultLossControllerConfig())
+
+	feedLossPackets(l, clock, 0, 5, 0)
+
+	_, ok := l.FractionLost(clock.Now())
+	assert.False(t, ok, "should not report loss below MinPackets")
+
+	_, ok = l.Update(1_000_000, 30_000_000, clock.Now())
+	assert.False(t, ok, "should not establish a bound without loss data")
+}
+
+func TestLossController_FractionLost(t *testing.T) {
+	clock := clock.NewVirtual(time.Time{})
+	l := NewLossController(DefaultLossControllerConfig())
+
+	// Drop one of every 5 packets: 1 lost per 6 expected
+	feedLossPackets(l, clock, 0, 60, 5)
+
+	fraction, ok := l.FractionLost(clock.Now())
+	require.True(t, ok)
+	assert.InDelta(t, 1.0/6.0, fraction, 0.02, "loss fraction should reflect sequence gaps")
+}
+
+func TestLossController_SequenceWrap(t *testing.T) {
+	clock := clock.NewVirtual(time.Time{})
+	l := NewLossController(DefaultLossControllerConfig())
+
+	// Start just below the wrap point; no loss across the wrap
+	feedLossPackets(l, clock, 65530, 40, 0)
+
+	fraction, ok := l.FractionLost(clock.Now())
+	require.True(t, ok)
+	assert.Equal(t, 0.0, fraction, "sequence wrap should not count as loss")
+}
+
+func TestLossController_ReorderingNotCountedAsLoss(t *testing.T) {
+	clock := clock.NewVirtual(time.Time{})
+	l := NewLossController(DefaultLossControllerConfig())
+
+	// Deliver pairs swapped: 1, 0, 3, 2, ...
+	for i := uint16(0); i < 40; i += 2 {
+		l.OnPacket(0x1234, i+1, clock.Now())
+		l.OnPacket(0x1234, i, clock.Now())
+		clock.Advance(10 * time.Millisecond)
+	}
+
+	fraction, ok := l.FractionLost(clock.Now())
+	require.True(t, ok)
+	assert.Equal(t, 0.0, fraction, "reordered packets should not count as lost")
+}
+
+func TestLossController_WindowExpiry(t *testing.T) {
+	clock := clock.NewVirtual(time.Time{})
+	l := NewLossController(DefaultLossControllerConfig())
+
+	// Heavy loss, then a clean second that pushes the lossy samples out
+	seq := feedLossPackets(l, clock, 0, 50, 2)
+	feedLossPackets(l, clock, seq, 110, 0)
+
+	fraction, ok := l.FractionLost(clock.Now())
+	require.True(t, ok)
+	assert.Equal(t, 0.0, fraction, "loss outside the window should be forgotten")
+}
+
+func TestLossController_Decisions(t *testing.T) {
+	tests := []struct {
+		name      string
+		dropEvery int
+		check     func(t *testing.T, bound, delayEstimate int64)
+	}{
+		{
+			name:      "high loss decreases",
+			dropEvery: 3, // 25% loss
+			check: func(t *testing.T, bound, delayEstimate int64) {
+				// As = A * (1 - 0.5 * 0.25)
+				assert.InDelta(t, float64(delayEstimate)*(1-0.5*0.25), float64(bound), float64(delayEstimate)*0.02)
+			},
+		},
+		{
+			name:      "moderate loss holds",
+			dropEvery: 20, // ~5% loss
+			check: func(t *testing.T, bound, delayEstimate int64) {
+				assert.Equal(t, delayEstimate, bound)
+			},
+		},
+		{
+			name:      "low loss increases",
+			dropEvery: 0,
+			check: func(t *testing.T, bound, delayEstimate int64) {
+				assert.Equal(t, int64(float64(delayEstimate)*1.05), bound)
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clock := clock.NewVirtual(time.Time{})
+			l := NewLossController(DefaultLossControllerConfig())
+			feedLossPackets(l, clock, 0, 90, tt.dropEvery)
+
+			delayEstimate := int64(1_000_000)
+			bound, ok := l.Update(delayEstimate, 30_000_000, clock.Now())
+			require.True(t, ok)
+			tt.check(t, bound, delayEstimate)
+		})
+	}
+}
+
+func TestLossController_UpdateInterval(t *testing.T) {
+	clock := clock.NewVirtual(time.Time{})
+	l := NewLossController(DefaultLossControllerConfig())
+
+	feedLossPackets(l, clock, 0, 50, 0)
+	first, ok := l.Update(1_000_000, 30_000_000, clock.Now())
+	require.True(t, ok)
+
+	// Within the update interval the bound does not change
+	clock.Advance(100 * time.Millisecond)
+	second, _ := l.Update(1_000_000, 30_000_000, clock.Now())
+	assert.Equal(t, first, second, "bound should only change once per UpdateInterval")
+}
+
+func TestLossController_Reset(t *testing.T) {
+	clock := clock.NewVirtual(time.Time{})
+	l := NewLossController(DefaultLossControllerConfig())
+
+	feedLossPackets(l, clock, 0, 50, 3)
+	_, ok := l.Update(1_000_000, 30_000_000, clock.Now())
+	require.True(t, ok)
+
+	l.Reset()
+
+	assert.Equal(t, int64(0), l.Estimate(), "reset should clear the bound")
+	_, ok = l.FractionLost(clock.Now())
+	assert.False(t, ok, "reset should clear the loss window")
+}
+
+func TestBandwidthEstimator_LossBoundLimitsEstimate(t *testing.T) {
+	clock := clock.NewVirtual(time.Time{})
+	config := DefaultBandwidthEstimatorConfig()
+	config.RateControllerConfig.InitialBitrate = 1_000_000
+	e := NewBandwidthEstimator(config, clock)
+
+	// Stable delay but 25% packet loss for three seconds
+	sendTime := uint32(0)
+	seq := uint16(0)
+	for i := 0; i < 300; i++ {
+		if i%4 == 0 {
+			seq++ // lost packet
+		}
+		e.OnPacket(PacketInfo{
+			ArrivalTime:    clock.Now(),
+			SendTime:       sendTime,
+			Size:           1200,
+			SSRC:           0x1234,
+			SequenceNumber: seq,
+		})
+		seq++
+		sendTime += 10 * 262 // ~10ms in abs-send-time units
+		clock.Advance(10 * time.Millisecond)
+	}
+
+	fraction, ok := e.GetLossFraction()
+	require.True(t, ok)
+	assert.Greater(t, fraction, 0.1, "loss fraction should exceed the high threshold")
+
+	lossEstimate, ok := e.GetLossBasedEstimate()
+	require.True(t, ok, "loss-based bound should be active")
+	assert.Equal(t, BoundLoss, e.GetActiveBound(), "loss bound should limit the estimate")
+	assert.Equal(t, lossEstimate, e.GetEstimate(), "final estimate should be the loss bound")
+	assert.Less(t, e.GetEstimate(), e.GetDelayBasedEstimate(),
+		"final estimate should be below the delay-based estimate")
+}
+
+func TestBandwidthEstimator_LossControllerDisabled(t *testing.T) {
+	clock := clock.NewVirtual(time.Time{})
+	config := DefaultBandwidthEstimatorConfig()
+	config.LossControllerConfig.Enabled = false
+	e := NewBandwidthEstimator(config, clock)
+
+	sendTime := uint32(0)
+	for i := 0; i < 300; i++ {
+		e.OnPacket(PacketInfo{
+			ArrivalTime:    clock.Now(),
+			SendTime:       sendTime,
+			Size:           1200,
+			SSRC:           0x1234,
+			SequenceNumber: uint16(i * 2), // 50% loss
+		})
+		sendTime += 10 * 262
+		clock.Advance(10 * time.Millisecond)
+	}
+
+	_, ok := e.GetLossBasedEstimate()
+	assert.False(t, ok, "disabled loss controller should not set a bound")
+	assert.Equal(t, BoundDelay, e.GetActiveBound())
+	assert.Equal(t, e.GetDelayBasedEstimate(), e.GetEstimate())
+}
+
+func TestEstimateBound_String(t *testing.T) {
+	assert.Equal(t, "Delay", BoundDelay.String())
+	assert.Equal(t, "Loss", BoundLoss.String())
+	assert.Equal(t, "Unknown", EstimateBound(99).String())
+}
+
+func TestBandwidthEstimator_LossFreeRampNotLossBound(t *testing.T) {
+	config := DefaultBandwidthEstimatorConfig()
+	e := NewBandwidthEstimator(config, nil)
+
+	// 2 Mbps with stable delay and no loss: AIMD ramps up from the initial
+	// bitrate and the loss bound must never hold it back
+	start := time.Unix(1000, 0)
+	sendTime := uint32(0)
+	for i := 0; i < 2000; i++ {
+		e.OnPacket(PacketInfo{
+			ArrivalTime:    start.Add(time.Duration(i) * 5 * time.Millisecond),
+			SendTime:       sendTime,
+			Size:           1250,
+			SSRC:           0x1234,
+			SequenceNumber: uint16(i),
+		})
+		sendTime += 5 * 262 // ~5ms in abs-send-time units
+		require.Equal(t, BoundDelay, e.GetActiveBound(), "packet %d", i)
+	}
+	assert.Greater(t, e.GetEstimate(), config.RateControllerConfig.InitialBitrate, "estimate should ramp up")
+	assert.Equal(t, e.GetDelayBasedEstimate(), e.GetEstimate())
+}
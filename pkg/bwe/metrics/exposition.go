@@ -0,0 +1,227 @@
+package metrics
+
+import (
+	"bytes"
+	"io"
+	"math"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/thesyncim/bwe/pkg/bwe"
+)
+
+// ContentType is the media type of the Prometheus text exposition format.
+const ContentType = "text/plain; version=0.0.4; charset=utf-8"
+
+// States exported as label values, in counter index order.
+var (
+	usageStates = [...]bwe.BandwidthUsage{bwe.BwNormal, bwe.BwUnderusing, bwe.BwOverusing}
+	rateStates  = [...]bwe.RateControlState{bwe.RateHold, bwe.RateIncrease, bwe.RateDecrease}
+)
+
+// labelValueEscaper escapes label values as the exposition format requires.
+var labelValueEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+// sessionSnapshot is a session's state read for one scrape.
+type sessionSnapshot struct {
+	name     string
+	labeled  bool
+	stats    bwe.Stats
+	counters *counters
+}
+
+// ServeHTTP writes the metrics of all registered sessions in the Prometheus
+// text exposition format.
+func (r *Registry) ServeHTTP(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", ContentType)
+	_, _ = r.WriteTo(w)
+}
+
+// WriteTo writes the metrics of all registered sessions to w in the
+// Prometheus text exposition format.
+func (r *Registry) WriteTo(w io.Writer) (int64, error) {
+	sessions, overflow := r.snapshot()
+
+	var buf bytes.Buffer
+	writeHeader(&buf, "bwe_sessions", "gauge", "Registered estimator sessions.")
+	writeSample(&buf, "bwe_sessions", "", float64(len(sessions)))
+
+	writeHeader(&buf, "bwe_sessions_by_congestion_state", "gauge",
+		"Sessions in each delay-based congestion state.")
+	for _, state := range usageStates {
+		n := 0
+		for _, s := range sessions {
+			if s.stats.CongestionState == state {
+				n++
+			}
+		}
+		writeSample(&buf, "bwe_sessions_by_congestion_state", labels("state", stateLabel(state)), float64(n))
+	}
+
+	gauges := []struct {
+		name, help string
+		value      func(bwe.Stats) float64
+	}{
+		{"bwe_estimate_bps", "Final bandwidth estimate in bits per second.",
+			func(s bwe.Stats) float64 { return float64(s.Estimate) }},
+		{"bwe_incoming_rate_bps", "Measured incoming bitrate in bits per second.",
+			func(s bwe.Stats) float64 { return float64(s.IncomingRate) }},
+		{"bwe_delay_threshold_ms", "Adaptive overuse threshold in milliseconds.",
+			func(s bwe.Stats) float64 { return s.Delay.Threshold }},
+		{"bwe_remb_bitrate_bps", "Bitrate of the last REMB built in bits per second.",
+			func(s bwe.Stats) float64 { return float64(s.REMB.LastBitrate) }},
+	}
+	for _, g := range gauges {
+		writeHeader(&buf, g.name, "gauge", g.help)
+		for _, s := range sessions {
+			if s.labeled {
+				writeSample(&buf, g.name, labels("session", s.name), g.value(s.stats))
+			}
+		}
+	}
+
+	// Counters of sessions beyond MaxSessions are summed under "other"
+	type counterSet struct {
+		session  string
+		counters *counters
+	}
+	var sets []counterSet
+	for _, s := range sessions {
+		if s.labeled {
+			sets = append(sets, counterSet{s.name, s.counters})
+		}
+	}
+	if overflow != nil {
+		sets = append(sets, counterSet{OtherSession, overflow})
+	}
+
+	writeHeader(&buf, "bwe_usage_state_transitions_total", "counter",
+		"Transitions of the delay-based congestion state, by new state.")
+	for _, set := range sets {
+		for _, state := range usageStates {
+			writeSample(&buf, "bwe_usage_state_transitions_total",
+				labels("session", set.session, "state", stateLabel(state)),
+				float64(set.counters.usage[state].Load()))
+		}
+	}
+
+	writeHeader(&buf, "bwe_rate_control_state_transitions_total", "counter",
+		"Transitions of the AIMD rate control state, by new state.")
+	for _, set := range sets {
+		for _, state := range rateStates {
+			writeSample(&buf, "bwe_rate_control_state_transitions_total",
+				labels("session", set.session, "state", stateLabel(state)),
+				float64(set.counters.rateState[state].Load()))
+		}
+	}
+
+	writeHeader(&buf, "bwe_rembs_total", "counter", "REMB packets built.")
+	for _, set := range sets {
+		writeSample(&buf, "bwe_rembs_total", labels("session", set.session), float64(set.counters.rembs.Load()))
+	}
+
+	r.delay.write(&buf, "bwe_delay_variation_ms",
+		"Delay variation between packet groups in milliseconds, over all sessions.")
+
+	return buf.WriteTo(w)
+}
+
+// snapshot reads every session's stats, sorted by name, and returns the
+// overflow counters if any session was registered beyond MaxSessions.
+func (r *Registry) snapshot() ([]sessionSnapshot, *counters) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	sessions := make([]sessionSnapshot, 0, len(r.sessions))
+	for _, s := range r.sessions {
+		sessions = append(sessions, sessionSnapshot{
+			name:     s.name,
+			labeled:  s.labeled,
+			stats:    s.estimator.Stats(),
+			counters: s.counters,
+		})
+	}
+	slices.SortFunc(sessions, func(a, b sessionSnapshot) int {
+		return strings.Compare(a.name, b.name)
+	})
+
+	if !r.overflowed {
+		return sessions, nil
+	}
+	return sessions, r.other
+}
+
+// write writes the histogram's buckets, sum and count.
+func (h *histogram) write(buf *bytes.Buffer, name, help string) {
+	writeHeader(buf, name, "histogram", help)
+	var cumulative int64
+	for i := range h.buckets {
+		cumulative += h.buckets[i].Load()
+		le := math.Inf(1)
+		if i < len(h.bounds) {
+			le = h.bounds[i]
+		}
+		writeSample(buf, name+"_bucket", labels("le", formatFloat(le)), float64(cumulative))
+	}
+	writeSample(buf, name+"_sum", "", math.Float64frombits(h.sumBits.Load()))
+	writeSample(buf, name+"_count", "", float64(cumulative))
+}
+
+// writeHeader writes the HELP and TYPE lines of a metric family.
+func writeHeader(buf *bytes.Buffer, name, typ, help string) {
+	buf.WriteString("# HELP ")
+	buf.WriteString(name)
+	buf.WriteByte(' ')
+	buf.WriteString(help)
+	buf.WriteString("\n# TYPE ")
+	buf.WriteString(name)
+	buf.WriteByte(' ')
+	buf.WriteString(typ)
+	buf.WriteByte('\n')
+}
+
+// writeSample writes one sample line. labels is empty or a {...} set.
+func writeSample(buf *bytes.Buffer, name, labels string, value float64) {
+	buf.WriteString(name)
+	buf.WriteString(labels)
+	buf.WriteByte(' ')
+	buf.WriteString(formatFloat(value))
+	buf.WriteByte('\n')
+}
+
+// labels formats name/value pairs as a label set, escaping the values.
+func labels(pairs ...string) string {
+	var b strings.Builder
+	b.WriteByte('{')
+	for i := 0; i+1 < len(pairs); i += 2 {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		b.WriteString(pairs[i])
+		b.WriteString(`="`)
+		labelValueEscaper.WriteString(&b, pairs[i+1])
+		b.WriteByte('"')
+	}
+	b.WriteByte('}')
+	return b.String()
+}
+
+// stateLabel returns the lower-case label value of a state.
+func stateLabel(state interface{ String() string }) string {
+	return strings.ToLower(state.String())
+}
+
+// formatFloat formats a sample value as the exposition format expects.
+func formatFloat(v float64) string {
+	switch {
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	case math.IsNaN(v):
+		return "NaN"
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
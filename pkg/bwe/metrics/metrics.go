@@ -0,0 +1,225 @@
+// Package metrics exports bandwidth estimator metrics in the Prometheus text
+// exposition format, without third-party dependencies.
+//
+// A Registry tracks any number of BandwidthEstimators, each under a session
+// name, and serves their metrics from ServeHTTP:
+//
+//	registry := metrics.NewRegistry(metrics.DefaultConfig())
+//	registry.Register("call-42", estimator)
+//	http.Handle("/metrics", registry)
+//
+// Gauges are read from BandwidthEstimator.Stats at scrape time. Counters and
+// the delay variation histogram are fed by an Observer the Registry installs
+// on each estimator.
+package metrics
+
+import (
+	"errors"
+	"math"
+	"sync"
+	"sync/atomic"
+
+	"github.com/thesyncim/bwe/pkg/bwe"
+)
+
+// DefaultMaxSessions bounds the session label values a Registry exports.
+const DefaultMaxSessions = 512
+
+// OtherSession is the session label of counters from sessions registered
+// beyond MaxSessions.
+const OtherSession = "other"
+
+// ErrDuplicateSession is returned by Register when the session name is
+// already registered.
+var ErrDuplicateSession = errors.New("metrics: session already registered")
+
+// Config configures a Registry.
+type Config struct {
+	// MaxSessions caps the sessions exported with their own session label.
+	// Sessions registered beyond the cap still count towards the session
+	// gauges and the histogram; their counters are summed under
+	// session="other" and their per-session gauges are not exported.
+	// Default: 512
+	MaxSessions int
+
+	// DelayVariationBuckets are the upper bounds, in milliseconds and in
+	// increasing order, of the delay variation histogram buckets.
+	// Default: -20, -10, -5, -2, -1, 0, 1, 2, 5, 10, 20, 50
+	DelayVariationBuckets []float64
+}
+
+// DefaultConfig returns the default Registry configuration.
+func DefaultConfig() Config {
+	return Config{
+		MaxSessions:           DefaultMaxSessions,
+		DelayVariationBuckets: []float64{-20, -10, -5, -2, -1, 0, 1, 2, 5, 10, 20, 50},
+	}
+}
+
+// withDefaults replaces zero values with defaults.
+func (c Config) withDefaults() Config {
+	defaults := DefaultConfig()
+	if c.MaxSessions <= 0 {
+		c.MaxSessions = defaults.MaxSessions
+	}
+	if len(c.DelayVariationBuckets) == 0 {
+		c.DelayVariationBuckets = defaults.DelayVariationBuckets
+	}
+	return c
+}
+
+// Registry collects the metrics of registered estimators and serves them in
+// the Prometheus text format. It is safe for concurrent use.
+type Registry struct {
+	config Config
+
+	mu         sync.Mutex
+	sessions   map[string]*session
+	labeled    int       // Sessions exported with their own label
+	other      *counters // Counters of sessions beyond MaxSessions
+	overflowed bool      // Whether any session used other
+	delay      *histogram
+}
+
+// NewRegistry creates an empty Registry. If MaxSessions is not positive it
+// defaults to DefaultMaxSessions; without DelayVariationBuckets, the buckets
+// of DefaultConfig are used.
+func NewRegistry(config Config) *Registry {
+	config = config.withDefaults()
+	return &Registry{
+		config:   config,
+		sessions: make(map[string]*session),
+		other:    &counters{},
+		delay:    newHistogram(config.DelayVariationBuckets),
+	}
+}
+
+// Register starts exporting the estimator's metrics under the session name.
+// It installs an Observer on the estimator, replacing any set before.
+// Returns ErrDuplicateSession if name is already registered.
+func (r *Registry) Register(name string, estimator *bwe.BandwidthEstimator) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.sessions[name]; ok {
+		return ErrDuplicateSession
+	}
+	s := &session{
+		name:      name,
+		estimator: estimator,
+		counters:  r.other,
+		delay:     r.delay,
+	}
+	if r.labeled < r.config.MaxSessions {
+		s.labeled = true
+		s.counters = &counters{}
+		r.labeled++
+	} else {
+		r.overflowed = true
+	}
+	r.sessions[name] = s
+	estimator.SetObserver(s)
+	return nil
+}
+
+// Unregister stops exporting a session and removes the Observer from its
+// estimator. Its labelled series disappear from the next scrape; counters
+// summed under session="other" are kept.
+func (r *Registry) Unregister(name string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	s, ok := r.sessions[name]
+	if !ok {
+		return
+	}
+	delete(r.sessions, name)
+	if s.labeled {
+		r.labeled--
+	}
+	s.estimator.SetObserver(nil)
+}
+
+// NumSessions returns the number of registered sessions.
+func (r *Registry) NumSessions() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return len(r.sessions)
+}
+
+// counters holds the event counters of one session, or of all sessions
+// beyond MaxSessions.
+type counters struct {
+	usage     [3]atomic.Int64 // Transitions into each BandwidthUsage
+	rateState [3]atomic.Int64 // Transitions into each RateControlState
+	rembs     atomic.Int64
+}
+
+// session is a registered estimator and the Observer feeding its counters.
+// Its methods run under the estimator's lock and only touch atomics.
+type session struct {
+	bwe.NoOpObserver
+
+	name      string
+	estimator *bwe.BandwidthEstimator
+	labeled   bool // false for sessions beyond MaxSessions
+	counters  *counters
+	delay     *histogram
+}
+
+// OnGroupCompleted implements bwe.Observer.
+func (s *session) OnGroupCompleted(event bwe.GroupEvent) {
+	s.delay.observe(float64(event.DelayVariation.Microseconds()) / 1000.0)
+}
+
+// OnUsageStateChange implements bwe.Observer.
+func (s *session) OnUsageStateChange(event bwe.UsageStateEvent) {
+	if int(event.New) >= 0 && int(event.New) < len(s.counters.usage) {
+		s.counters.usage[event.New].Add(1)
+	}
+}
+
+// OnRateControlStateChange implements bwe.Observer.
+func (s *session) OnRateControlStateChange(event bwe.RateControlStateEvent) {
+	if int(event.New) >= 0 && int(event.New) < len(s.counters.rateState) {
+		s.counters.rateState[event.New].Add(1)
+	}
+}
+
+// OnREMB implements bwe.Observer.
+func (s *session) OnREMB(bwe.REMBEvent) {
+	s.counters.rembs.Add(1)
+}
+
+// histogram is a fixed-bucket histogram updated with atomics.
+type histogram struct {
+	bounds  []float64
+	buckets []atomic.Int64 // Per bucket, not cumulative; last is +Inf
+	sumBits atomic.Uint64  // math.Float64bits of the sum
+}
+
+// newHistogram creates a histogram with the given upper bounds.
+func newHistogram(bounds []float64) *histogram {
+	return &histogram{
+		bounds:  bounds,
+		buckets: make([]atomic.Int64, len(bounds)+1),
+	}
+}
+
+// observe adds a value.
+func (h *histogram) observe(v float64) {
+	idx := len(h.bounds)
+	for i, bound := range h.bounds {
+		if v <= bound {
+			idx = i
+			break
+		}
+	}
+	h.buckets[idx].Add(1)
+	for {
+		old := h.sumBits.Load()
+		if h.sumBits.CompareAndSwap(old, math.Float64bits(math.Float64frombits(old)+v)) {
+			return
+		}
+	}
+}
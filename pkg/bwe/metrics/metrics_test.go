@@ -0,0 +1,195 @@
+package metrics
+
+import (
+	"bufio"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/thesyncim/bwe/pkg/bwe"
+	"github.com/thesyncim/bwe/pkg/bwe/clock"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// scrape serves the registry and returns its samples keyed by name and
+// label set, failing on malformed lines.
+func scrape(t *testing.T, r *Registry) map[string]float64 {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+	assert.Equal(t, ContentType, rec.Header().Get("Content-Type"))
+
+	samples := make(map[string]float64)
+	scanner := bufio.NewScanner(rec.Body)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if strings.HasPrefix(line, "#") {
+			continue
+		}
+		idx := strings.LastIndexByte(line, ' ')
+		require.Positive(t, idx, "malformed line %q", line)
+		value, err := strconv.ParseFloat(line[idx+1:], 64)
+		require.NoError(t, err, "line %q", line)
+		samples[line[:idx]] = value
+	}
+	return samples
+}
+
+// feed sends 1 Mbps on ssrc between from and to (in ms since start), with
+// queuing delay growing by queueGrowth per packet.
+func feed(e *bwe.BandwidthEstimator, clk *clock.Virtual, start time.Time, ssrc uint32, from, to int, queueGrowth time.Duration) {
+	var queue time.Duration
+	for ms := from; ms < to; ms += 10 {
+		arrival := start.Add(time.Duration(ms)*time.Millisecond + queue)
+		clk.Set(arrival)
+		e.OnPacket(bwe.PacketInfo{
+			ArrivalTime: arrival,
+			SendTime:    uint32(ms*262144/1000) & 0xFFFFFF, // 6.18 fixed point
+			Size:        1250,
+			SSRC:        ssrc,
+		})
+		queue += queueGrowth
+	}
+}
+
+func TestRegistry_Exposition(t *testing.T) {
+	start := time.Unix(0, 0)
+	clk := clock.NewVirtual(start)
+	congested := bwe.NewBandwidthEstimator(bwe.DefaultBandwidthEstimatorConfig(), clk)
+	congested.SetREMBScheduler(bwe.NewREMBScheduler(bwe.DefaultREMBSchedulerConfig()))
+	idle := bwe.NewBandwidthEstimator(bwe.DefaultBandwidthEstimatorConfig(), clk)
+
+	r := NewRegistry(DefaultConfig())
+	require.NoError(t, r.Register("congested", congested))
+	require.NoError(t, r.Register("idle", idle))
+
+	feed(congested, clk, start, 1, 0, 1000, 0)
+	feed(congested, clk, start, 1, 1000, 2000, 50*time.Millisecond)
+	_, sent, err := congested.MaybeBuildREMB(clk.Now())
+	require.NoError(t, err)
+	require.True(t, sent)
+
+	samples := scrape(t, r)
+	stats := congested.Stats()
+
+	assert.Equal(t, 2.0, samples["bwe_sessions"])
+	assert.Equal(t, float64(stats.Estimate), samples[`bwe_estimate_bps{session="congested"}`])
+	assert.Equal(t, float64(stats.IncomingRate), samples[`bwe_incoming_rate_bps{session="congested"}`])
+	assert.Equal(t, stats.Delay.Threshold, samples[`bwe_delay_threshold_ms{session="congested"}`])
+	assert.Equal(t, float64(stats.REMB.LastBitrate), samples[`bwe_remb_bitrate_bps{session="congested"}`])
+	assert.Equal(t, float64(idle.GetEstimate()), samples[`bwe_estimate_bps{session="idle"}`])
+
+	var byState float64
+	for _, state := range []string{"normal", "underusing", "overusing"} {
+		byState += samples[`bwe_sessions_by_congestion_state{state="`+state+`"}`]
+	}
+	assert.Equal(t, 2.0, byState)
+
+	assert.Positive(t, samples[`bwe_usage_state_transitions_total{session="congested",state="overusing"}`])
+	assert.Positive(t, samples[`bwe_rate_control_state_transitions_total{session="congested",state="decrease"}`])
+	assert.Equal(t, 0.0, samples[`bwe_usage_state_transitions_total{session="idle",state="overusing"}`])
+	assert.Equal(t, 1.0, samples[`bwe_rembs_total{session="congested"}`])
+	assert.Equal(t, 0.0, samples[`bwe_rembs_total{session="idle"}`])
+
+	count := samples["bwe_delay_variation_ms_count"]
+	assert.Positive(t, count)
+	assert.Equal(t, count, samples[`bwe_delay_variation_ms_bucket{le="+Inf"}`])
+	assert.Positive(t, samples["bwe_delay_variation_ms_sum"], "the queue is building")
+	assert.LessOrEqual(t, samples[`bwe_delay_variation_ms_bucket{le="0"}`], samples[`bwe_delay_variation_ms_bucket{le="1"}`])
+}
+
+func TestRegistry_BoundsSessionLabels(t *testing.T) {
+	start := time.Unix(0, 0)
+	clk := clock.NewVirtual(start)
+	r := NewRegistry(Config{MaxSessions: 2})
+
+	estimators := make([]*bwe.BandwidthEstimator, 4)
+	for i := range estimators {
+		estimators[i] = bwe.NewBandwidthEstimator(bwe.DefaultBandwidthEstimatorConfig(), clk)
+		estimators[i].SetREMBScheduler(bwe.NewREMBScheduler(bwe.DefaultREMBSchedulerConfig()))
+		require.NoError(t, r.Register(string(rune('a'+i)), estimators[i]))
+	}
+	for i, e := range estimators {
+		feed(e, clk, start, uint32(i+1), 0, 100, 0)
+		_, sent, err := e.MaybeBuildREMB(clk.Now())
+		require.NoError(t, err)
+		require.True(t, sent)
+	}
+
+	samples := scrape(t, r)
+	assert.Equal(t, 4.0, samples["bwe_sessions"])
+
+	sessions := make(map[string]bool)
+	for key := range samples {
+		if strings.HasPrefix(key, "bwe_estimate_bps{") {
+			sessions[key] = true
+		}
+	}
+	assert.Equal(t, map[string]bool{
+		`bwe_estimate_bps{session="a"}`: true,
+		`bwe_estimate_bps{session="b"}`: true,
+	}, sessions)
+
+	// Sessions beyond the cap share the "other" counters
+	assert.Equal(t, 1.0, samples[`bwe_rembs_total{session="a"}`])
+	assert.Equal(t, 2.0, samples[`bwe_rembs_total{session="other"}`])
+
+	// Freed labels go to new sessions; "other" counters are kept
+	r.Unregister("a")
+	r.Unregister("c")
+	require.NoError(t, r.Register("e", bwe.NewBandwidthEstimator(bwe.DefaultBandwidthEstimatorConfig(), clk)))
+	samples = scrape(t, r)
+	assert.Contains(t, samples, `bwe_estimate_bps{session="e"}`)
+	assert.NotContains(t, samples, `bwe_estimate_bps{session="a"}`)
+	assert.Equal(t, 2.0, samples[`bwe_rembs_total{session="other"}`])
+}
+
+func TestRegistry_RegisterAndUnregister(t *testing.T) {
+	clk := clock.NewVirtual(time.Time{})
+	e := bwe.NewBandwidthEstimator(bwe.DefaultBandwidthEstimatorConfig(), clk)
+	r := NewRegistry(DefaultConfig())
+
+	require.NoError(t, r.Register("a", e))
+	assert.ErrorIs(t, r.Register("a", e), ErrDuplicateSession)
+	assert.Equal(t, 1, r.NumSessions())
+
+	r.Unregister("a")
+	r.Unregister("a") // No-op
+	assert.Equal(t, 0, r.NumSessions())
+
+	// The observer is removed, so the histogram stops growing
+	feed(e, clk, clk.Now(), 1, 0, 1000, 0)
+	assert.Equal(t, 0.0, scrape(t, r)["bwe_delay_variation_ms_count"])
+}
+
+func TestLabels_Escaping(t *testing.T) {
+	assert.Equal(t, `{session="a\"b\\c\nd",state="normal"}`, labels("session", "a\"b\\c\nd", "state", "normal"))
+}
+
+func TestHistogram_Buckets(t *testing.T) {
+	h := newHistogram([]float64{-1, 0, 1})
+	for _, v := range []float64{-5, -1, 0.5, 1, 3} {
+		h.observe(v)
+	}
+
+	var buf strings.Builder
+	r := &Registry{sessions: map[string]*session{}, delay: h, other: &counters{}}
+	_, err := r.WriteTo(&buf)
+	require.NoError(t, err)
+	out := buf.String()
+	for _, line := range []string{
+		`bwe_delay_variation_ms_bucket{le="-1"} 2`,
+		`bwe_delay_variation_ms_bucket{le="0"} 2`,
+		`bwe_delay_variation_ms_bucket{le="1"} 4`,
+		`bwe_delay_variation_ms_bucket{le="+Inf"} 5`,
+		`bwe_delay_variation_ms_sum -1.5`,
+		`bwe_delay_variation_ms_count 5`,
+		`# TYPE bwe_delay_variation_ms histogram`,
+	} {
+		assert.Contains(t, out, line+"\n")
+	}
+}
@@ -0,0 +1,213 @@
+package bwe
+
+import "time"
+
+// Observer receives events from each stage of the BandwidthEstimator
+// pipeline, for debugging and monitoring. Embed NoOpObserver to implement
+// only the events of interest.
+//
+// Methods are called synchronously with the estimator's lock held, on the
+// goroutine that called OnPacket, CheckIdle or MaybeBuildREMB. They must
+// return quickly and must not call back into the BandwidthEstimator.
+type Observer interface {
+	// OnGroupCompleted is called when a packet group is completed and its
+	// delay variation is fed to the delay filter.
+	OnGroupCompleted(event GroupEvent)
+
+	// OnFilterOutput is called with each new delay filter output.
+	OnFilterOutput(event FilterEvent)
+
+	// OnThresholdUpdate is called when the overuse detector has compared the
+	// filter output with its adaptive threshold and updated the threshold.
+	OnThresholdUpdate(event ThresholdEvent)
+
+	// OnUsageStateChange is called when the delay-based usage state
+	// (Normal, Underusing, Overusing) changes.
+	OnUsageStateChange(event UsageStateEvent)
+
+	// OnRateControlStateChange is called when the AIMD state (Hold,
+	// Increase, Decrease) changes.
+	OnRateControlStateChange(event RateControlStateEvent)
+
+	// OnEstimateChange is called when the final estimate changes.
+	OnEstimateChange(event EstimateEvent)
+
+	// OnREMB is called when a REMB packet is built.
+	OnREMB(event REMBEvent)
+}
+
+// GroupEvent describes a completed packet group.
+type GroupEvent struct {
+	// Time is the arrival time of the packet that completed the group.
+	Time time.Time
+
+	// Group is the completed group.
+	Group PacketGroup
+
+	// DelayVariation is the delay variation sample fed to the filter, with
+	// clock skew removed. Positive means the queue is building.
+	DelayVariation time.Duration
+}
+
+// FilterEvent describes a delay filter update.
+type FilterEvent struct {
+	// Time is the arrival time of the packet that triggered the update.
+	Time time.Time
+
+	// Output is the filter output in milliseconds. See
+	// DelayEstimator.FilterOutput.
+	Output float64
+}
+
+// ThresholdEvent describes an overuse threshold update.
+type ThresholdEvent struct {
+	// Time is the arrival time of the packet that triggered the update.
+	Time time.Time
+
+	// Threshold is the adaptive threshold in milliseconds.
+	Threshold float64
+
+	// FilterOutput is the filter output the threshold was compared with,
+	// in milliseconds.
+	FilterOutput float64
+}
+
+// UsageStateEvent describes a change of the delay-based usage state.
+type UsageStateEvent struct {
+	Time time.Time
+	Old  BandwidthUsage
+	New  BandwidthUsage
+}
+
+// RateControlStateEvent describes a change of the AIMD state.
+type RateControlStateEvent struct {
+	Time time.Time
+	Old  RateControlState
+	New  RateControlState
+}
+
+// EstimateEvent describes a change of the final estimate.
+type EstimateEvent struct {
+	Time time.Time
+
+	// Old and New are the estimates before and after, in bps.
+	Old int64
+	New int64
+
+	// Bound is the estimate that limits New.
+	Bound EstimateBound
+}
+
+// REMBEvent describes a built REMB packet.
+type REMBEvent struct {
+	Time time.Time
+
+	// Bitrate is the estimate sent, in bps.
+	Bitrate int64
+
+	// SSRCs are the SSRCs listed, in ascending order.
+	SSRCs []uint32
+}
+
+// NoOpObserver implements Observer and ignores every event. Embed it in an
+// observer that handles only some events.
+type NoOpObserver struct{}
+
+// OnGroupCompleted implements Observer.
+func (NoOpObserver) OnGroupCompleted(GroupEvent) {}
+
+// OnFilterOutput implements Observer.
+func (NoOpObserver) OnFilterOutput(FilterEvent) {}
+
+// OnThresholdUpdate implements Observer.
+func (NoOpObserver) OnThresholdUpdate(ThresholdEvent) {}
+
+// OnUsageStateChange implements Observer.
+func (NoOpObserver) OnUsageStateChange(UsageStateEvent) {}
+
+// OnRateControlStateChange implements Observer.
+func (NoOpObserver) OnRateControlStateChange(RateControlStateEvent) {}
+
+// OnEstimateChange implements Observer.
+func (NoOpObserver) OnEstimateChange(EstimateEvent) {}
+
+// OnREMB implements Observer.
+func (NoOpObserver) OnREMB(REMBEvent) {}
+
+// SetObserver registers an observer for pipeline events. Pass nil to
+// disable events; without an observer no events are built.
+func (e *BandwidthEstimator) SetObserver(observer Observer) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.observer = observer
+	e.delayEstimator.setObserver(observer)
+}
+
+// observeUsage reports a change of the delay-based usage state.
+// Must be called with mu held.
+func (e *BandwidthEstimator) observeUsage(signal BandwidthUsage, now time.Time) {
+	if signal == e.lastUsage {
+		return
+	}
+	if e.observer != nil {
+		e.observer.OnUsageStateChange(UsageStateEvent{Time: now, Old: e.lastUsage, New: signal})
+	}
+	e.lastUsage = signal
+}
+
+// observeRateState reports a change of the AIMD state.
+// Must be called with mu held.
+func (e *BandwidthEstimator) observeRateState(now time.Time) {
+	state := e.rateController.State()
+	if state == e.lastRateState {
+		return
+	}
+	if e.observer != nil {
+		e.observer.OnRateControlStateChange(RateControlStateEvent{Time: now, Old: e.lastRateState, New: state})
+	}
+	e.lastRateState = state
+}
+
+// observeEstimate reports a change of the final estimate from old.
+// Must be called with mu held.
+func (e *BandwidthEstimator) observeEstimate(old int64, now time.Time) {
+	if e.observer != nil && e.estimate != old {
+		e.observer.OnEstimateChange(EstimateEvent{Time: now, Old: old, New: e.estimate, Bound: e.activeBound})
+	}
+}
+
+// observeGroup reports a completed group of the delay pipeline.
+func (e *DelayEstimator) observeGroup(now time.Time, delayVariation time.Duration) {
+	if e.observer == nil {
+		return
+	}
+	e.observer.OnGroupCompleted(GroupEvent{
+		Time:           now,
+		Group:          *e.interarrival.PreviousGroup(),
+		DelayVariation: delayVariation,
+	})
+}
+
+// observeDetection reports the filter output and the threshold it was
+// compared with.
+func (e *DelayEstimator) observeDetection(now time.Time) {
+	if e.observer == nil {
+		return
+	}
+	e.observer.OnFilterOutput(FilterEvent{Time: now, Output: e.lastEstimate})
+	e.observer.OnThresholdUpdate(ThresholdEvent{Time: now, Threshold: e.Threshold(), FilterOutput: e.lastEstimate})
+}
+
+// setObserver registers an observer for group, filter and threshold events.
+func (e *DelayEstimator) setObserver(observer Observer) {
+	e.observer = observer
+}
+
+// setObserver registers an observer with every stream's pipeline, current
+// and future.
+func (p *PerStreamDelayEstimator) setObserver(observer Observer) {
+	p.observer = observer
+	for _, s := range p.streams {
+		s.estimator.setObserver(observer)
+	}
+}
@@ -0,0 +1,173 @@
+package bwe
+
+import (
+	"testing"
+	"time"
+
+	"github.com/thesyncim/bwe/pkg/bwe/clock"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// recordingObserver records every event.
+type recordingObserver struct {
+	groups     []GroupEvent
+	filters    []FilterEvent
+	thresholds []ThresholdEvent
+	usages     []UsageStateEvent
+	rates      []RateControlStateEvent
+	estimates  []EstimateEvent
+	rembs      []REMBEvent
+}
+
+func (r *recordingObserver) OnGroupCompleted(e GroupEvent) { r.groups = append(r.groups, e) }
+func (r *recordingObserver) OnFilterOutput(e FilterEvent)  { r.filters = append(r.filters, e) }
+func (r *recordingObserver) OnThresholdUpdate(e ThresholdEvent) {
+	r.thresholds = append(r.thresholds, e)
+}
+func (r *recordingObserver) OnUsageStateChange(e UsageStateEvent) { r.usages = append(r.usages, e) }
+func (r *recordingObserver) OnRateControlStateChange(e RateControlStateEvent) {
+	r.rates = append(r.rates, e)
+}
+func (r *recordingObserver) OnEstimateChange(e EstimateEvent) { r.estimates = append(r.estimates, e) }
+func (r *recordingObserver) OnREMB(e REMBEvent)               { r.rembs = append(r.rembs, e) }
+
+// observerRun feeds 1 Mbps on ssrc between from and to (in ms since start),
+// with queuing delay growing by queueGrowth per packet.
+func observerRun(e *BandwidthEstimator, clock *clock.Virtual, start time.Time, ssrc uint32, from, to int, queueGrowth time.Duration) {
+	var queue time.Duration
+	for ms := from; ms < to; ms += 10 {
+		arrival := start.Add(time.Duration(ms)*time.Millisecond + queue)
+		clock.Set(arrival)
+		e.OnPacket(PacketInfo{ArrivalTime: arrival, SendTime: msToAbsSendTime(ms), Size: 1250, SSRC: ssrc})
+		queue += queueGrowth
+	}
+}
+
+func TestObserver_PipelineEvents(t *testing.T) {
+	start := time.Unix(0, 0)
+	clock := clock.NewVirtual(start)
+	e := NewBandwidthEstimator(DefaultBandwidthEstimatorConfig(), clock)
+	rec := &recordingObserver{}
+	e.SetObserver(rec)
+
+	observerRun(e, clock, start, 1, 0, 1000, 0)
+	observerRun(e, clock, start, 1, 1000, 2000, 50*time.Millisecond)
+
+	// Every group reaches the filter and the detector
+	require.NotEmpty(t, rec.groups)
+	assert.Len(t, rec.filters, len(rec.groups))
+	assert.Len(t, rec.thresholds, len(rec.groups))
+	for _, g := range rec.groups {
+		assert.Positive(t, g.Group.NumPackets)
+		assert.Equal(t, uint32(1), g.Group.SSRC)
+	}
+	last := rec.groups[len(rec.groups)-1]
+	assert.Greater(t, last.DelayVariation, time.Duration(0), "queue is building")
+	assert.Equal(t, rec.filters[len(rec.filters)-1].Output, rec.thresholds[len(rec.thresholds)-1].FilterOutput)
+
+	// State changes chain from the initial states
+	require.NotEmpty(t, rec.usages)
+	assert.Equal(t, BwNormal, rec.usages[0].Old)
+	var sawOveruse bool
+	for i, u := range rec.usages {
+		assert.NotEqual(t, u.Old, u.New)
+		if i > 0 {
+			assert.Equal(t, rec.usages[i-1].New, u.Old)
+		}
+		sawOveruse = sawOveruse || u.New == BwOverusing
+	}
+	assert.True(t, sawOveruse)
+
+	require.NotEmpty(t, rec.rates)
+	assert.Equal(t, RateHold, rec.rates[0].Old)
+	var sawDecrease bool
+	for i, r := range rec.rates {
+		if i > 0 {
+			assert.Equal(t, rec.rates[i-1].New, r.Old)
+		}
+		sawDecrease = sawDecrease || r.New == RateDecrease
+	}
+	assert.True(t, sawDecrease)
+	assert.Equal(t, e.GetRateControlState(), rec.rates[len(rec.rates)-1].New)
+
+	require.NotEmpty(t, rec.estimates)
+	assert.Equal(t, DefaultRateControllerConfig().InitialBitrate, rec.estimates[0].Old)
+	for i, est := range rec.estimates {
+		assert.NotEqual(t, est.Old, est.New)
+		if i > 0 {
+			assert.Equal(t, rec.estimates[i-1].New, est.Old)
+		}
+	}
+	assert.Equal(t, e.GetEstimate(), rec.estimates[len(rec.estimates)-1].New)
+
+	// REMB
+	e.SetREMBScheduler(NewREMBScheduler(DefaultREMBSchedulerConfig()))
+	_, sent, err := e.MaybeBuildREMB(clock.Now())
+	require.NoError(t, err)
+	require.True(t, sent)
+	require.Len(t, rec.rembs, 1)
+	assert.Equal(t, e.GetEstimate(), rec.rembs[0].Bitrate)
+	assert.Equal(t, []uint32{1}, rec.rembs[0].SSRCs)
+	assert.Equal(t, clock.Now(), rec.rembs[0].Time)
+}
+
+func TestObserver_PerStream(t *testing.T) {
+	start := time.Unix(0, 0)
+	clock := clock.NewVirtual(start)
+	config := DefaultBandwidthEstimatorConfig()
+	config.PerStreamConfig.Enabled = true
+	e := NewBandwidthEstimator(config, clock)
+
+	// Streams created before and after the observer is set both report
+	observerRun(e, clock, start, 1, 0, 1000, 0)
+	rec := &recordingObserver{}
+	e.SetObserver(rec)
+	for ms := 1000; ms < 2000; ms += 10 {
+		arrival := start.Add(time.Duration(ms) * time.Millisecond)
+		clock.Set(arrival)
+		e.OnPacket(PacketInfo{ArrivalTime: arrival, SendTime: msToAbsSendTime(ms), Size: 1250, SSRC: 1})
+		e.OnPacket(PacketInfo{ArrivalTime: arrival, SendTime: msToAbsSendTime(ms), Size: 1250, SSRC: 2})
+	}
+
+	seen := make(map[uint32]bool)
+	for _, g := range rec.groups {
+		seen[g.Group.SSRC] = true
+	}
+	assert.Equal(t, map[uint32]bool{1: true, 2: true}, seen)
+}
+
+func TestObserver_NilDisables(t *testing.T) {
+	start := time.Unix(0, 0)
+	clock := clock.NewVirtual(start)
+	e := NewBandwidthEstimator(DefaultBandwidthEstimatorConfig(), clock)
+	rec := &recordingObserver{}
+	e.SetObserver(rec)
+	e.SetObserver(nil)
+
+	observerRun(e, clock, start, 1, 0, 2000, 0)
+	assert.Empty(t, rec.groups)
+	assert.Empty(t, rec.estimates)
+}
+
+func TestObserver_NoOpAddsNoAllocations(t *testing.T) {
+	allocs := func(observer Observer) float64 {
+		clock := clock.NewVirtual(time.Time{})
+		e := NewBandwidthEstimator(DefaultBandwidthEstimatorConfig(), clock)
+		e.SetObserver(observer)
+		sendTime := uint32(0)
+		feed := func() {
+			for i := 0; i < 1000; i++ {
+				e.OnPacket(PacketInfo{ArrivalTime: clock.Now(), SendTime: sendTime, Size: 1200, SSRC: 1})
+				sendTime += 262 * 10
+				clock.Advance(10 * time.Millisecond)
+			}
+		}
+		feed() // Warm up
+		return testing.AllocsPerRun(10, feed)
+	}
+
+	var noop Observer = NoOpObserver{}
+	assert.Equal(t, allocs(nil), allocs(noop))
+}
@@ -6,7 +6,7 @@ import (
 	"math"
 	"time"
 
-	"github.com/thesyncim/bwe/pkg/bwe/internal"
+	"github.com/thesyncim/bwe/pkg/bwe/clock"
 )
 
 // StateChangeCallback is called when bandwidth usage state changes.
@@ -71,7 +71,7 @@ func DefaultOveruseConfig() OveruseConfig {
 //   - State change callbacks for application notification
 type OveruseDetector struct {
 	config           OveruseConfig
-	clock            internal.Clock
+	clock            clock.Clock
 	threshold        float64            // Current adaptive threshold
 	lastUpdateTime   time.Time          // For threshold adaptation timing
 	overuseStart     time.Time          // When current overuse period started
@@ -83,14 +83,14 @@ type OveruseDetector struct {
 }
 
 // NewOveruseDetector creates a new OveruseDetector with the given configuration
-// and clock. If clock is nil, a default MonotonicClock is used.
-func NewOveruseDetector(config OveruseConfig, clock internal.Clock) *OveruseDetector {
-	if clock == nil {
-		clock = internal.MonotonicClock{}
+// and clock. If clk is nil, clock.Monotonic is used.
+func NewOveruseDetector(config OveruseConfig, clk clock.Clock) *OveruseDetector {
+	if clk == nil {
+		clk = clock.Monotonic{}
 	}
 	return &OveruseDetector{
 		config:     config,
-		clock:      clock,
+		clock:      clk,
 		threshold:  config.InitialThreshold,
 		hypothesis: BwNormal,
 		// lastUpdateTime is zero - will be set on first update
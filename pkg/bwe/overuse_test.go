@@ -4,11 +4,11 @@ import (
 	"testing"
 	"time"
 
-	"github.com/thesyncim/bwe/pkg/bwe/internal"
+	"github.com/thesyncim/bwe/pkg/bwe/clock"
 )
 
 func TestOveruseDetector_InitialState(t *testing.T) {
-	clock := internal.NewMockClock(time.Time{})
+	clock := clock.NewVirtual(time.Time{})
 	config := DefaultOveruseConfig()
 	detector := NewOveruseDetector(config, clock)
 
@@ -33,7 +33,7 @@ func TestOveruseDetector_NilClock(t *testing.T) {
 }
 
 func TestOveruseDetector_NormalOperation(t *testing.T) {
-	clock := internal.NewMockClock(time.Time{})
+	clock := clock.NewVirtual(time.Time{})
 	config := DefaultOveruseConfig()
 	detector := NewOveruseDetector(config, clock)
 
@@ -50,7 +50,7 @@ func TestOveruseDetector_NormalOperation(t *testing.T) {
 }
 
 func TestOveruseDetector_SustainedOveruse(t *testing.T) {
-	clock := internal.NewMockClock(time.Time{})
+	clock := clock.NewVirtual(time.Time{})
 	config := DefaultOveruseConfig()
 	detector := NewOveruseDetector(config, clock)
 
@@ -95,7 +95,7 @@ func TestOveruseDetector_SustainedOveruse(t *testing.T) {
 }
 
 func TestOveruseDetector_SignalSuppression(t *testing.T) {
-	clock := internal.NewMockClock(time.Time{})
+	clock := clock.NewVirtual(time.Time{})
 	config := DefaultOveruseConfig()
 	detector := NewOveruseDetector(config, clock)
 
@@ -126,7 +126,7 @@ func TestOveruseDetector_SignalSuppression(t *testing.T) {
 }
 
 func TestOveruseDetector_Underuse(t *testing.T) {
-	clock := internal.NewMockClock(time.Time{})
+	clock := clock.NewVirtual(time.Time{})
 	config := DefaultOveruseConfig()
 	detector := NewOveruseDetector(config, clock)
 
@@ -166,7 +166,7 @@ func TestOveruseDetector_Underuse(t *testing.T) {
 }
 
 func TestOveruseDetector_AdaptiveThresholdIncrease(t *testing.T) {
-	clock := internal.NewMockClock(time.Time{})
+	clock := clock.NewVirtual(time.Time{})
 	config := DefaultOveruseConfig()
 	detector := NewOveruseDetector(config, clock)
 
@@ -190,7 +190,7 @@ func TestOveruseDetector_AdaptiveThresholdIncrease(t *testing.T) {
 }
 
 func TestOveruseDetector_AdaptiveThresholdDecrease(t *testing.T) {
-	clock := internal.NewMockClock(time.Time{})
+	clock := clock.NewVirtual(time.Time{})
 	config := DefaultOveruseConfig()
 	detector := NewOveruseDetector(config, clock)
 
@@ -219,7 +219,7 @@ func TestOveruseDetector_AdaptiveThresholdDecrease(t *testing.T) {
 }
 
 func TestOveruseDetector_ThresholdClamping(t *testing.T) {
-	clock := internal.NewMockClock(time.Time{})
+	clock := clock.NewVirtual(time.Time{})
 	config := DefaultOveruseConfig()
 	detector := NewOveruseDetector(config, clock)
 
@@ -251,7 +251,7 @@ func TestOveruseDetector_ThresholdClamping(t *testing.T) {
 }
 
 func TestOveruseDetector_StateTransitionToNormal(t *testing.T) {
-	clock := internal.NewMockClock(time.Time{})
+	clock := clock.NewVirtual(time.Time{})
 	config := DefaultOveruseConfig()
 	detector := NewOveruseDetector(config, clock)
 
@@ -291,7 +291,7 @@ func TestOveruseDetector_StateTransitionToNormal(t *testing.T) {
 }
 
 func TestOveruseDetector_CallbackNil(t *testing.T) {
-	clock := internal.NewMockClock(time.Time{})
+	clock := clock.NewVirtual(time.Time{})
 	config := DefaultOveruseConfig()
 	detector := NewOveruseDetector(config, clock)
 
@@ -321,7 +321,7 @@ func TestOveruseDetector_CallbackNil(t *testing.T) {
 }
 
 func TestOveruseDetector_CallbackCorrectStates(t *testing.T) {
-	clock := internal.NewMockClock(time.Time{})
+	clock := clock.NewVirtual(time.Time{})
 	config := DefaultOveruseConfig()
 	detector := NewOveruseDetector(config, clock)
 
@@ -377,7 +377,7 @@ func TestOveruseDetector_CallbackCorrectStates(t *testing.T) {
 }
 
 func TestOveruseDetector_Reset(t *testing.T) {
-	clock := internal.NewMockClock(time.Time{})
+	clock := clock.NewVirtual(time.Time{})
 	config := DefaultOveruseConfig()
 	detector := NewOveruseDetector(config, clock)
 
@@ -405,7 +405,7 @@ func TestOveruseDetector_Reset(t *testing.T) {
 }
 
 func TestOveruseDetector_CustomConfig(t *testing.T) {
-	clock := internal.NewMockClock(time.Time{})
+	clock := clock.NewVirtual(time.Time{})
 	config := OveruseConfig{
 		InitialThreshold:  20.0,
 		MinThreshold:      10.0,
@@ -441,7 +441,7 @@ func TestOveruseDetector_CustomConfig(t *testing.T) {
 }
 
 func TestOveruseDetector_EdgeCases(t *testing.T) {
-	clock := internal.NewMockClock(time.Time{})
+	clock := clock.NewVirtual(time.Time{})
 	config := DefaultOveruseConfig()
 	detector := NewOveruseDetector(config, clock)
 
@@ -471,7 +471,7 @@ func TestOveruseDetector_EdgeCases(t *testing.T) {
 }
 
 func TestOveruseDetector_OveruseRequiresSustainedPeriod(t *testing.T) {
-	clock := internal.NewMockClock(time.Time{})
+	clock := clock.NewVirtual(time.Time{})
 	config := DefaultOveruseConfig()
 	detector := NewOveruseDetector(config, clock)
 
@@ -500,7 +500,7 @@ func TestOveruseDetector_OveruseRequiresSustainedPeriod(t *testing.T) {
 }
 
 func TestOveruseDetector_OveruseCounterRequired(t *testing.T) {
-	clock := internal.NewMockClock(time.Time{})
+	clock := clock.NewVirtual(time.Time{})
 	config := DefaultOveruseConfig()
 	detector := NewOveruseDetector(config, clock)
 
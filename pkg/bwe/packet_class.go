@@ -0,0 +1,118 @@
+package bwe
+
+// PacketClass is the kind of RTP packet, for PacketPolicyConfig.
+type PacketClass int
+
+const (
+	// PacketMedia is a regular media packet. This is the zero value.
+	PacketMedia PacketClass = iota
+
+	// PacketPadding is a padding-only packet (padding bit set, no payload),
+	// as senders use for bandwidth probes.
+	PacketPadding
+
+	// PacketRTX is an RTX retransmission (RFC 4588). Its send time can be
+	// that of the original packet, long before it was resent.
+	PacketRTX
+
+	// PacketFEC is a ULPFEC or FlexFEC packet.
+	PacketFEC
+)
+
+// String returns a string representation of the PacketClass.
+func (c PacketClass) String() string {
+	switch c {
+	case PacketMedia:
+		return "Media"
+	case PacketPadding:
+		return "Padding"
+	case PacketRTX:
+		return "RTX"
+	case PacketFEC:
+		return "FEC"
+	default:
+		return "Unknown"
+	}
+}
+
+// PacketPolicy selects how BandwidthEstimator uses a class of packets.
+type PacketPolicy int
+
+const (
+	// PolicyDefault uses the class's entry in DefaultPacketPolicyConfig.
+	PolicyDefault PacketPolicy = iota
+
+	// PolicyRateAndDelay counts the packet toward the incoming rate and
+	// feeds it to the delay-based detector and probe detection, like media.
+	PolicyRateAndDelay
+
+	// PolicyRateOnly counts the packet toward the incoming rate and loss
+	// tracking, but keeps it out of delay measurement and probe detection.
+	PolicyRateOnly
+
+	// PolicyIgnore keeps the packet out of the rate and delay measurements.
+	// Its sequence number is still tracked for loss.
+	PolicyIgnore
+)
+
+// String returns a string representation of the PacketPolicy.
+func (p PacketPolicy) String() string {
+	switch p {
+	case PolicyDefault:
+		return "Default"
+	case PolicyRateAndDelay:
+		return "RateAndDelay"
+	case PolicyRateOnly:
+		return "RateOnly"
+	case PolicyIgnore:
+		return "Ignore"
+	default:
+		return "Unknown"
+	}
+}
+
+// PacketPolicyConfig selects a PacketPolicy per packet class. Media packets
+// always count toward rate and delay.
+type PacketPolicyConfig struct {
+	// Padding applies to padding-only packets. Probe detection needs them
+	// in delay measurement.
+	// Default: PolicyRateAndDelay
+	Padding PacketPolicy
+
+	// RTX applies to retransmissions, whose send times may be stale.
+	// Default: PolicyRateOnly
+	RTX PacketPolicy
+
+	// FEC applies to ULPFEC and FlexFEC packets.
+	// Default: PolicyRateAndDelay
+	FEC PacketPolicy
+}
+
+// DefaultPacketPolicyConfig returns the default per-class policies.
+func DefaultPacketPolicyConfig() PacketPolicyConfig {
+	return PacketPolicyConfig{
+		Padding: PolicyRateAndDelay,
+		RTX:     PolicyRateOnly,
+		FEC:     PolicyRateAndDelay,
+	}
+}
+
+// Policy returns the policy for a packet class, resolving PolicyDefault.
+func (c PacketPolicyConfig) Policy(class PacketClass) PacketPolicy {
+	defaults := DefaultPacketPolicyConfig()
+	var policy, fallback PacketPolicy
+	switch class {
+	case PacketPadding:
+		policy, fallback = c.Padding, defaults.Padding
+	case PacketRTX:
+		policy, fallback = c.RTX, defaults.RTX
+	case PacketFEC:
+		policy, fallback = c.FEC, defaults.FEC
+	default:
+		return PolicyRateAndDelay
+	}
+	if policy == PolicyDefault {
+		return fallback
+	}
+	return policy
+}
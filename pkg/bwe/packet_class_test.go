@@ -0,0 +1,92 @@
+package bwe
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/thesyncim/bwe/pkg/bwe/clock"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPacketPolicyConfig_Policy(t *testing.T) {
+	var zero PacketPolicyConfig
+	assert.Equal(t, PolicyRateAndDelay, zero.Policy(PacketMedia))
+	assert.Equal(t, PolicyRateAndDelay, zero.Policy(PacketPadding))
+	assert.Equal(t, PolicyRateOnly, zero.Policy(PacketRTX), "zero value resolves to the default")
+	assert.Equal(t, PolicyRateAndDelay, zero.Policy(PacketFEC))
+
+	config := PacketPolicyConfig{RTX: PolicyIgnore, FEC: PolicyRateOnly}
+	assert.Equal(t, PolicyIgnore, config.Policy(PacketRTX))
+	assert.Equal(t, PolicyRateOnly, config.Policy(PacketFEC))
+}
+
+// runWithRTX feeds 5s of media every 20ms plus an RTX packet every 100ms
+// resending a packet from 1s earlier, and returns the estimator.
+func runWithRTX(policies PacketPolicyConfig) *BandwidthEstimator {
+	start := time.Unix(0, 0)
+	clock := clock.NewVirtual(start)
+	config := DefaultBandwidthEstimatorConfig()
+	config.PacketPolicyConfig = policies
+	e := NewBandwidthEstimator(config, clock)
+
+	for i := 0; i < 250; i++ {
+		arrival := start.Add(time.Duration(i) * 20 * time.Millisecond)
+		clock.Set(arrival)
+		e.OnPacket(PacketInfo{ArrivalTime: arrival, SendTime: msToAbsSendTime(1000 + i*20), Size: 1200, SSRC: 1})
+		if i%5 == 0 {
+			e.OnPacket(PacketInfo{ArrivalTime: arrival, SendTime: msToAbsSendTime(i * 20), Size: 1200, SSRC: 2, Class: PacketRTX})
+		}
+	}
+	return e
+}
+
+func TestBandwidthEstimator_PacketPolicies(t *testing.T) {
+	rateOnly := runWithRTX(DefaultPacketPolicyConfig())
+	ignored := runWithRTX(PacketPolicyConfig{RTX: PolicyIgnore})
+	asMedia := runWithRTX(PacketPolicyConfig{RTX: PolicyRateAndDelay})
+
+	// Stale RTX send times only reach the delay pipeline as media, where they
+	// fold into the media groups and skew the delay gradient
+	assert.Equal(t, InterArrivalStats{}, rateOnly.GetInterArrivalStats())
+	assert.Equal(t, BwNormal, rateOnly.GetCongestionState())
+	assert.Less(t, math.Abs(rateOnly.GetDelayFilterOutput()), 1.0)
+	assert.Greater(t, math.Abs(asMedia.GetDelayFilterOutput()), 10.0)
+
+	// RTX bytes count toward the incoming rate unless ignored
+	withRTX, ok := rateOnly.GetIncomingRate()
+	assert.True(t, ok)
+	withoutRTX, ok := ignored.GetIncomingRate()
+	assert.True(t, ok)
+	assert.InEpsilon(t, 1.2, float64(withRTX)/float64(withoutRTX), 0.05)
+
+	assert.ElementsMatch(t, []uint32{1, 2}, rateOnly.GetSSRCs())
+	assert.ElementsMatch(t, []uint32{1}, ignored.GetSSRCs())
+}
+
+func TestBandwidthEstimator_IgnoredPaddingIsNotLoss(t *testing.T) {
+	start := time.Unix(0, 0)
+	clock := clock.NewVirtual(start)
+	config := DefaultBandwidthEstimatorConfig()
+	config.PacketPolicyConfig = PacketPolicyConfig{Padding: PolicyIgnore}
+	e := NewBandwidthEstimator(config, clock)
+
+	// Every third packet on the media SSRC is padding, sharing its sequence
+	// numbers
+	for i := 0; i < 300; i++ {
+		arrival := start.Add(time.Duration(i) * 10 * time.Millisecond)
+		clock.Set(arrival)
+		class := PacketMedia
+		if i%3 == 2 {
+			class = PacketPadding
+		}
+		e.OnPacket(PacketInfo{ArrivalTime: arrival, SendTime: msToAbsSendTime(i * 10), Size: 1200, SSRC: 1, SequenceNumber: uint16(i), Class: class})
+	}
+
+	fraction, ok := e.GetLossFraction()
+	require.True(t, ok)
+	assert.Zero(t, fraction, "ignored padding should fill the sequence number gaps")
+	assert.Equal(t, int64(200), e.Stats().SSRCs[0].Packets, "padding is still kept out of the counters")
+}
@@ -0,0 +1,298 @@
+package bwe
+
+import (
+	"time"
+
+	"github.com/thesyncim/bwe/pkg/bwe/clock"
+)
+
+// DefaultStreamIdleTimeout is how long a per-stream delay pipeline may go
+// without packets before it is removed.
+const DefaultStreamIdleTimeout = 5 * time.Second
+
+// streamSweepInterval is how often idle per-stream pipelines are looked for.
+const streamSweepInterval = time.Second
+
+// streamQuietAfter is how long a stream may go without packets before its
+// state stops counting towards the combined state. A paused stream's last
+// state, e.g. Overusing, would otherwise hold until IdleTimeout.
+const streamQuietAfter = 500 * time.Millisecond
+
+// PerStreamConfig configures independent delay pipelines per stream.
+type PerStreamConfig struct {
+	// Enabled runs a separate InterArrivalCalculator, delay filter and
+	// overuse detector for each stream instead of one shared pipeline.
+	// Use it when streams are paced differently (audio and video). It is
+	// required when streams come from different senders (an SFU forwarding
+	// several publishers): a shared pipeline only checks send times within
+	// one SSRC, so interleaved send times from unrelated clocks still
+	// corrupt each other's group deltas.
+	// Default: false
+	Enabled bool
+
+	// StreamKey maps a packet to its pipeline. SSRCs that share a sender
+	// clock domain (e.g. the audio and video of one publisher) may share a
+	// key. If nil, each SSRC gets its own pipeline.
+	StreamKey func(pkt PacketInfo) uint32
+
+	// IdleTimeout is how long a pipeline may go without packets before its
+	// state is discarded.
+	// Default: 5s
+	IdleTimeout time.Duration
+}
+
+// DefaultPerStreamConfig returns the default per-stream configuration.
+// Per-stream pipelines are disabled by default.
+func DefaultPerStreamConfig() PerStreamConfig {
+	return PerStreamConfig{
+		Enabled:     false,
+		IdleTimeout: DefaultStreamIdleTimeout,
+	}
+}
+
+// delayDetector is the delay-based stage of BandwidthEstimator: either a
+// single shared DelayEstimator or a PerStreamDelayEstimator.
+type delayDetector interface {
+	OnPacket(pkt PacketInfo) BandwidthUsage
+	State() BandwidthUsage
+	FilterOutput() float64
+	Threshold() float64
+	InterArrivalStats() InterArrivalStats
+	KalmanCapacity() (int64, bool)
+	ClockSkewPPM() (float64, bool)
+	Stats() DelayStats
+	Reset()
+	setObserver(observer Observer)
+}
+
+// delayStream is one per-stream pipeline and when it last saw a packet.
+type delayStream struct {
+	estimator  *DelayEstimator
+	lastPacket time.Time
+}
+
+// PerStreamDelayEstimator runs an independent DelayEstimator per stream and
+// combines their usage signals with a worst-of policy: any overusing stream
+// makes the combined state Overusing, and the combined state is Underusing
+// only when every stream is underusing. Only streams with a packet in the
+// last 500ms take part.
+//
+// Streams that stay idle for IdleTimeout are removed.
+type PerStreamDelayEstimator struct {
+	config        DelayEstimatorConfig
+	streamCfg     PerStreamConfig
+	clock         clock.Clock
+	streams       map[uint32]*delayStream
+	lastSweep     time.Time
+	removedStat   InterArrivalStats // Counters of removed streams
+	removedGroups int64             // Groups completed by removed streams
+
+	// Stream that determined the combined state, for monitoring
+	dominant *DelayEstimator
+	state    BandwidthUsage
+	callback StateChangeCallback
+	observer Observer
+}
+
+// NewPerStreamDelayEstimator creates a new PerStreamDelayEstimator. Every
+// stream's pipeline is built from config. If clk is nil,
+// clock.Monotonic is used.
+func NewPerStreamDelayEstimator(config DelayEstimatorConfig, streamCfg PerStreamConfig, clk clock.Clock) *PerStreamDelayEstimator {
+	if clk == nil {
+		clk = clock.Monotonic{}
+	}
+	if streamCfg.IdleTimeout <= 0 {
+		streamCfg.IdleTimeout = DefaultStreamIdleTimeout
+	}
+	return &PerStreamDelayEstimator{
+		config:    config,
+		streamCfg: streamCfg,
+		clock:     clk,
+		streams:   make(map[uint32]*delayStream),
+		state:     BwNormal,
+	}
+}
+
+// OnPacket feeds the packet to its stream's pipeline and returns the
+// combined bandwidth usage state of all streams.
+func (p *PerStreamDelayEstimator) OnPacket(pkt PacketInfo) BandwidthUsage {
+	key := pkt.SSRC
+	if p.streamCfg.StreamKey != nil {
+		key = p.streamCfg.StreamKey(pkt)
+	}
+
+	// A stream back from IdleTimeout of silence starts a fresh pipeline,
+	// even if no sweep has removed it yet
+	s, ok := p.streams[key]
+	if ok && pkt.ArrivalTime.Sub(s.lastPacket) >= p.streamCfg.IdleTimeout {
+		p.retireStats(s)
+		ok = false
+	}
+	if !ok {
+		s = &delayStream{estimator: NewDelayEstimator(p.config, p.clock)}
+		s.estimator.setObserver(p.observer)
+		p.streams[key] = s
+	}
+	s.lastPacket = pkt.ArrivalTime
+	s.estimator.OnPacket(pkt)
+
+	if pkt.ArrivalTime.Sub(p.lastSweep) >= streamSweepInterval {
+		p.removeIdle(pkt.ArrivalTime)
+		p.lastSweep = pkt.ArrivalTime
+	}
+
+	p.combine(pkt.ArrivalTime)
+	return p.state
+}
+
+// removeIdle discards pipelines that have not seen a packet for IdleTimeout,
+// keeping their inter-arrival counters.
+func (p *PerStreamDelayEstimator) removeIdle(now time.Time) {
+	for key, s := range p.streams {
+		if now.Sub(s.lastPacket) < p.streamCfg.IdleTimeout {
+			continue
+		}
+		p.retireStats(s)
+		delete(p.streams, key)
+	}
+}
+
+// retireStats folds a discarded stream's counters into removedStat and
+// removedGroups.
+func (p *PerStreamDelayEstimator) retireStats(s *delayStream) {
+	stats := s.estimator.InterArrivalStats()
+	stats.ConsecutiveNegativeDeltas = 0
+	p.removedStat = addInterArrivalStats(p.removedStat, stats)
+	p.removedGroups += s.estimator.groups
+}
+
+// combine applies the worst-of policy to the states of the streams that
+// are not quiet and notifies the callback on a change. Among streams in the
+// worst state, the one with the highest filter output is dominant.
+func (p *PerStreamDelayEstimator) combine(now time.Time) {
+	old := p.state
+	p.dominant = nil
+	p.state = BwNormal
+
+	allUnderusing := true
+	for _, s := range p.streams {
+		if now.Sub(s.lastPacket) > streamQuietAfter {
+			continue
+		}
+		state := s.estimator.State()
+		if state != BwUnderusing {
+			allUnderusing = false
+		}
+		if p.dominant == nil || usageSeverity(state) > usageSeverity(p.dominant.State()) ||
+			(state == p.dominant.State() && s.estimator.FilterOutput() > p.dominant.FilterOutput()) {
+			p.dominant = s.estimator
+		}
+	}
+
+	switch {
+	case p.dominant != nil && p.dominant.State() == BwOverusing:
+		p.state = BwOverusing
+	case p.dominant != nil && allUnderusing:
+		p.state = BwUnderusing
+	}
+
+	if p.state != old && p.callback != nil {
+		p.callback(old, p.state)
+	}
+}
+
+// usageSeverity orders states from best (Underusing) to worst (Overusing).
+func usageSeverity(u BandwidthUsage) int {
+	switch u {
+	case BwUnderusing:
+		return 0
+	case BwOverusing:
+		return 2
+	default:
+		return 1
+	}
+}
+
+// addInterArrivalStats sums two sets of counters. ConsecutiveNegativeDeltas
+// reports the longest current run.
+func addInterArrivalStats(a, b InterArrivalStats) InterArrivalStats {
+	a.ReorderedPackets += b.ReorderedPackets
+	a.NegativeArrivalDeltas += b.NegativeArrivalDeltas
+	a.ReorderResets += b.ReorderResets
+	a.ClockJumpResets += b.ClockJumpResets
+	a.ConsecutiveNegativeDeltas = max(a.ConsecutiveNegativeDeltas, b.ConsecutiveNegativeDeltas)
+	return a
+}
+
+// State returns the combined bandwidth usage state.
+func (p *PerStreamDelayEstimator) State() BandwidthUsage {
+	return p.state
+}
+
+// FilterOutput returns the delay filter output of the stream that
+// determined the combined state, in milliseconds.
+func (p *PerStreamDelayEstimator) FilterOutput() float64 {
+	if p.dominant == nil {
+		return 0
+	}
+	return p.dominant.FilterOutput()
+}
+
+// Threshold returns the adaptive overuse threshold of the stream that
+// determined the combined state, in milliseconds.
+func (p *PerStreamDelayEstimator) Threshold() float64 {
+	if p.dominant == nil {
+		return p.config.OveruseConfig.InitialThreshold
+	}
+	return p.dominant.Threshold()
+}
+
+// InterArrivalStats returns the inter-arrival counters summed over all
+// streams, including removed ones.
+func (p *PerStreamDelayEstimator) InterArrivalStats() InterArrivalStats {
+	stats := p.removedStat
+	for _, s := range p.streams {
+		stats = addInterArrivalStats(stats, s.estimator.InterArrivalStats())
+	}
+	return stats
+}
+
+// KalmanCapacity returns the two-state Kalman capacity of the stream that
+// determined the combined state. See DelayEstimator.KalmanCapacity.
+func (p *PerStreamDelayEstimator) KalmanCapacity() (int64, bool) {
+	if p.dominant == nil {
+		return 0, false
+	}
+	return p.dominant.KalmanCapacity()
+}
+
+// ClockSkewPPM returns the clock skew estimate of the stream that
+// determined the combined state. See DelayEstimator.ClockSkewPPM.
+func (p *PerStreamDelayEstimator) ClockSkewPPM() (float64, bool) {
+	if p.dominant == nil {
+		return 0, false
+	}
+	return p.dominant.ClockSkewPPM()
+}
+
+// NumStreams returns the number of active per-stream pipelines.
+func (p *PerStreamDelayEstimator) NumStreams() int {
+	return len(p.streams)
+}
+
+// SetCallback registers a callback that will be invoked when the combined
+// bandwidth usage state changes. Pass nil to disable callbacks.
+func (p *PerStreamDelayEstimator) SetCallback(cb StateChangeCallback) {
+	p.callback = cb
+}
+
+// Reset discards all per-stream pipelines. Inter-arrival counters are kept.
+func (p *PerStreamDelayEstimator) Reset() {
+	for _, s := range p.streams {
+		p.retireStats(s)
+	}
+	p.streams = make(map[uint32]*delayStream)
+	p.dominant = nil
+	p.state = BwNormal
+	p.lastSweep = time.Time{}
+}
@@ -0,0 +1,166 @@
+package bwe
+
+import (
+	"testing"
+	"time"
+
+	"github.com/thesyncim/bwe/pkg/bwe/clock"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// streamPacket returns packet i of a stream sending every 20ms from
+// sendBase, arriving at start plus extraDelay of queuing delay.
+func streamPacket(ssrc uint32, start time.Time, sendBase uint32, i int, extraDelay time.Duration) PacketInfo {
+	return PacketInfo{
+		ArrivalTime: start.Add(time.Duration(i)*20*time.Millisecond + extraDelay),
+		SendTime:    sendBase + msToAbsSendTime(i*20),
+		Size:        1200,
+		SSRC:        ssrc,
+	}
+}
+
+func TestPerStreamDelayEstimator_IndependentSendClocks(t *testing.T) {
+	start := time.Unix(0, 0)
+	shared := NewDelayEstimator(DefaultDelayEstimatorConfig(), nil)
+	perStream := NewPerStreamDelayEstimator(DefaultDelayEstimatorConfig(), DefaultPerStreamConfig(), nil)
+
+	// Two publishers whose send clocks are 10s apart, both on a stable path
+	for i := 0; i < 200; i++ {
+		for _, pkt := range []PacketInfo{
+			streamPacket(1, start, 0, i, 0),
+			streamPacket(2, start, msToAbsSendTime(10_000), i, time.Millisecond),
+		} {
+			shared.OnPacket(pkt)
+			perStream.OnPacket(pkt)
+		}
+	}
+
+	assert.Positive(t, shared.InterArrivalStats().ClockJumpResets,
+		"interleaved send clocks corrupt a shared pipeline")
+	assert.Equal(t, InterArrivalStats{}, perStream.InterArrivalStats())
+	assert.Equal(t, 2, perStream.NumStreams())
+	assert.NotEqual(t, BwOverusing, perStream.State())
+}
+
+func TestPerStreamDelayEstimator_WorstOf(t *testing.T) {
+	start := time.Unix(0, 0)
+	clock := clock.NewVirtual(start)
+	p := NewPerStreamDelayEstimator(DefaultDelayEstimatorConfig(), DefaultPerStreamConfig(), clock)
+
+	var transitions []BandwidthUsage
+	p.SetCallback(func(_, state BandwidthUsage) {
+		transitions = append(transitions, state)
+	})
+
+	// Both streams arrive every 70ms. Stream 1 was sent every 70ms (stable);
+	// stream 2 was sent every 20ms, so its queue grows 50ms per packet.
+	for i := 0; i < 100; i++ {
+		arrival := start.Add(time.Duration(i) * 70 * time.Millisecond)
+		clock.Set(arrival)
+		p.OnPacket(PacketInfo{ArrivalTime: arrival, SendTime: msToAbsSendTime(i * 70), Size: 1200, SSRC: 1})
+		p.OnPacket(PacketInfo{ArrivalTime: arrival.Add(time.Millisecond), SendTime: msToAbsSendTime(i * 20), Size: 1200, SSRC: 2})
+	}
+
+	assert.Equal(t, BwOverusing, p.State(), "one overusing stream makes the combined state Overusing")
+	assert.Contains(t, transitions, BwOverusing)
+	assert.Positive(t, p.FilterOutput())
+}
+
+func TestPerStreamDelayEstimator_RemovesIdleStreams(t *testing.T) {
+	start := time.Unix(0, 0)
+	p := NewPerStreamDelayEstimator(DefaultDelayEstimatorConfig(), PerStreamConfig{IdleTimeout: 2 * time.Second}, nil)
+
+	for i := 0; i < 10; i++ {
+		p.OnPacket(streamPacket(1, start, 0, i, 0))
+		p.OnPacket(streamPacket(2, start, 0, i, 0))
+	}
+	require.Equal(t, 2, p.NumStreams())
+
+	// Stream 2 goes silent
+	for i := 10; i < 200; i++ {
+		p.OnPacket(streamPacket(1, start, 0, i, 0))
+	}
+	assert.Equal(t, 1, p.NumStreams())
+
+	p.Reset()
+	assert.Equal(t, 0, p.NumStreams())
+}
+
+func TestPerStreamDelayEstimator_StreamKey(t *testing.T) {
+	start := time.Unix(0, 0)
+	p := NewPerStreamDelayEstimator(DefaultDelayEstimatorConfig(), PerStreamConfig{
+		StreamKey: func(pkt PacketInfo) uint32 { return pkt.SSRC / 10 },
+	}, nil)
+
+	// SSRCs 10 and 11 share a sender clock domain, 20 has its own
+	for i := 0; i < 10; i++ {
+		p.OnPacket(streamPacket(10, start, 0, i, 0))
+		p.OnPacket(streamPacket(11, start, 0, i, 0))
+		p.OnPacket(streamPacket(20, start, 0, i, 0))
+	}
+	assert.Equal(t, 2, p.NumStreams())
+}
+
+func TestBandwidthEstimator_PerStream(t *testing.T) {
+	clock := clock.NewVirtual(time.Unix(0, 0))
+	config := DefaultBandwidthEstimatorConfig()
+	config.PerStreamConfig.Enabled = true
+	e := NewBandwidthEstimator(config, clock)
+
+	_, ok := e.delayEstimator.(*PerStreamDelayEstimator)
+	require.True(t, ok, "PerStreamConfig.Enabled should select per-stream pipelines")
+
+	for i := 0; i < 100; i++ {
+		e.OnPacket(streamPacket(1, time.Unix(0, 0), 0, i, 0))
+		e.OnPacket(streamPacket(2, time.Unix(0, 0), msToAbsSendTime(10_000), i, 0))
+	}
+	assert.Equal(t, InterArrivalStats{}, e.GetInterArrivalStats())
+	assert.NotEqual(t, BwOverusing, e.GetCongestionState())
+}
+
+func TestPerStreamDelayEstimator_RestartsAfterIdle(t *testing.T) {
+	start := time.Unix(0, 0)
+	p := NewPerStreamDelayEstimator(DefaultDelayEstimatorConfig(), DefaultPerStreamConfig(), nil)
+
+	for i := 0; i < 50; i++ {
+		p.OnPacket(streamPacket(1, start, msToAbsSendTime(30_000), i, 0))
+	}
+
+	// Back after 10s with a restarted send clock: a fresh pipeline, not a
+	// clock jump in the old one
+	resumed := start.Add(10 * time.Second)
+	for i := 0; i < 50; i++ {
+		p.OnPacket(streamPacket(1, resumed, 0, i, 0))
+	}
+	assert.Equal(t, InterArrivalStats{}, p.InterArrivalStats())
+	assert.Equal(t, 1, p.NumStreams())
+}
+
+func TestPerStreamDelayEstimator_QuietStreamStopsCounting(t *testing.T) {
+	start := time.Unix(0, 0)
+	clock := clock.NewVirtual(start)
+	p := NewPerStreamDelayEstimator(DefaultDelayEstimatorConfig(), DefaultPerStreamConfig(), clock)
+
+	// Stream 2 overuses as in TestPerStreamDelayEstimator_WorstOf
+	i := 0
+	for ; i < 100; i++ {
+		arrival := start.Add(time.Duration(i) * 70 * time.Millisecond)
+		clock.Set(arrival)
+		p.OnPacket(PacketInfo{ArrivalTime: arrival, SendTime: msToAbsSendTime(i * 70), Size: 1200, SSRC: 1})
+		p.OnPacket(PacketInfo{ArrivalTime: arrival.Add(time.Millisecond), SendTime: msToAbsSendTime(i * 20), Size: 1200, SSRC: 2})
+	}
+	require.Equal(t, BwOverusing, p.State())
+
+	// Stream 2 pauses; its last state stops counting well before IdleTimeout
+	var states []BandwidthUsage
+	for end := i + 15; i < end; i++ {
+		arrival := start.Add(time.Duration(i) * 70 * time.Millisecond)
+		clock.Set(arrival)
+		states = append(states, p.OnPacket(PacketInfo{ArrivalTime: arrival, SendTime: msToAbsSendTime(i * 70), Size: 1200, SSRC: 1}))
+	}
+	assert.Equal(t, BwOverusing, states[0], "a recent stream still counts")
+	assert.Equal(t, BwNormal, p.State())
+	assert.Equal(t, 2, p.NumStreams(), "the paused stream is kept until IdleTimeout")
+}
@@ -0,0 +1,283 @@
+// Package bwe implements Google Congestion Control (GCC) receiver-side
+// bandwidth estimation for WebRTC.
+package bwe
+
+import (
+	"time"
+)
+
+// ProbeConfig configures probe cluster detection.
+type ProbeConfig struct {
+	// Enabled turns probe detection on. When disabled, probe packets are
+	// treated like regular media and the estimate ramps via AIMD only.
+	// Default: true
+	Enabled bool
+
+	// MinPackets is the minimum number of packets a cluster must contain
+	// for its result to be trusted.
+	// Default: 5
+	MinPackets int
+
+	// MaxClusterGap ends the current cluster when no probe packet has
+	// arrived for this long, and splits padding-only probes (which carry
+	// no cluster id) into separate clusters when their send times are
+	// further apart than this.
+	// Default: 50ms
+	MaxClusterGap time.Duration
+
+	// MaxProbeInterval rejects clusters whose send or receive interval is
+	// longer than this, since they no longer measure a burst.
+	// Default: 1s
+	MaxProbeInterval time.Duration
+
+	// MaxReceiveSendRatio rejects clusters that were received much faster
+	// than they were sent, which indicates bad timestamps.
+	// Default: 2.0
+	MaxReceiveSendRatio float64
+
+	// MinUnsaturatedRatio is the receive/send rate ratio below which the
+	// link is considered saturated by the probe. A saturated probe measured
+	// the capacity, so the estimate is TargetUtilization * receive rate.
+	// Default: 0.9
+	MinUnsaturatedRatio float64
+
+	// TargetUtilization scales the receive rate of a saturated probe.
+	// Default: 0.95
+	TargetUtilization float64
+
+	// HistorySize is the number of recent probe results kept for stats.
+	// Default: 10
+	HistorySize int
+}
+
+// DefaultProbeConfig returns the default probe detection configuration.
+func DefaultProbeConfig() ProbeConfig {
+	return ProbeConfig{
+		Enabled:             true,
+		MinPackets:          5,
+		MaxClusterGap:       50 * time.Millisecond,
+		MaxProbeInterval:    time.Second,
+		MaxReceiveSendRatio: 2.0,
+		MinUnsaturatedRatio: 0.9,
+		TargetUtilization:   0.95,
+		HistorySize:         10,
+	}
+}
+
+// ProbeResult describes a completed probe cluster.
+type ProbeResult struct {
+	// ClusterID is the sender-assigned cluster id, or 0 for clusters
+	// detected from padding-only packets.
+	ClusterID int
+
+	// Packets and Bytes count the packets in the cluster.
+	Packets int
+	Bytes   int
+
+	// SendRate and ReceiveRate are the measured rates in bits per second.
+	SendRate    int64
+	ReceiveRate int64
+
+	// Estimate is the capacity derived from the probe in bits per second.
+	// Only meaningful when Success is true.
+	Estimate int64
+
+	// Success reports whether the cluster produced a valid estimate.
+	Success bool
+
+	// Time is the arrival time of the last packet of the cluster.
+	Time time.Time
+}
+
+// probeCluster accumulates the packets of the cluster being received.
+type probeCluster struct {
+	id           int
+	firstSend    uint32
+	lastSend     uint32
+	sendSpan     int64 // Unwrapped abs-send-time units from firstSend to lastSend
+	firstArrival time.Time
+	lastArrival  time.Time
+	packets      int
+	bytes        int
+	firstSize    int
+	lastSize     int
+}
+
+// ProbeDetector detects probe clusters in the incoming packet stream and
+// measures their send and receive rates.
+//
+// Senders probe the path by sending a short burst above the current
+// estimate. Probe packets are recognized either by an explicit cluster id
+// (PacketInfo.ProbeClusterID) or as padding-only packets (PacketInfo.PaddingOnly).
+// When the burst arrives as fast as it was sent, the link has at least that
+// much capacity and the estimate can jump there instead of ramping slowly.
+//
+// The rate computation follows libwebrtc's ProbeBitrateEstimator: the send
+// rate excludes the last packet and the receive rate excludes the first,
+// since each interval spans one packet fewer than the cluster.
+type ProbeDetector struct {
+	config  ProbeConfig
+	current probeCluster
+	active  bool
+	results []ProbeResult
+}
+
+// NewProbeDetector creates a new probe detector.
+// If MinPackets is not positive it defaults to 5, and MaxClusterGap to 50ms;
+// the other non-positive fields take their DefaultProbeConfig values.
+func NewProbeDetector(config ProbeConfig) *ProbeDetector {
+	defaults := DefaultProbeConfig()
+	if config.MinPackets <= 0 {
+		config.MinPackets = defaults.MinPackets
+	}
+	if config.MaxClusterGap <= 0 {
+		config.MaxClusterGap = defaults.MaxClusterGap
+	}
+	if config.MaxProbeInterval <= 0 {
+		config.MaxProbeInterval = defaults.MaxProbeInterval
+	}
+	if config.MaxReceiveSendRatio <= 0 {
+		config.MaxReceiveSendRatio = defaults.MaxReceiveSendRatio
+	}
+	if config.MinUnsaturatedRatio <= 0 {
+		config.MinUnsaturatedRatio = defaults.MinUnsaturatedRatio
+	}
+	if config.TargetUtilization <= 0 {
+		config.TargetUtilization = defaults.TargetUtilization
+	}
+	if config.HistorySize <= 0 {
+		config.HistorySize = defaults.HistorySize
+	}
+
+	return &ProbeDetector{
+		config:  config,
+		results: make([]ProbeResult, 0, config.HistorySize),
+	}
+}
+
+// OnPacket processes a received packet. When the packet completes a probe
+// cluster, the cluster's result is returned with true.
+//
+// A cluster completes when a probe packet of a different cluster arrives,
+// or when any packet arrives more than MaxClusterGap after the cluster's
+// last packet. Non-probe packets interleaved with a cluster are ignored.
+func (d *ProbeDetector) OnPacket(pkt PacketInfo) (ProbeResult, bool) {
+	var result ProbeResult
+	completed := false
+
+	if d.active && pkt.ArrivalTime.Sub(d.current.lastArrival) > d.config.MaxClusterGap {
+		result, completed = d.finish(), true
+	}
+
+	if pkt.ProbeClusterID == 0 && !pkt.PaddingOnly() {
+		return result, completed
+	}
+
+	if d.active && !d.belongsToCluster(pkt) {
+		result, completed = d.finish(), true
+	}
+
+	if !d.active {
+		d.current = probeCluster{
+			id:           pkt.ProbeClusterID,
+			firstSend:    pkt.SendTime,
+			lastSend:     pkt.SendTime,
+			firstArrival: pkt.ArrivalTime,
+			firstSize:    pkt.Size,
+		}
+		d.active = true
+	}
+
+	c := &d.current
+	if delta := UnwrapAbsSendTime(c.lastSend, pkt.SendTime); delta > 0 {
+		c.sendSpan += delta
+		c.lastSend = pkt.SendTime
+	}
+	c.lastArrival = pkt.ArrivalTime
+	c.packets++
+	c.bytes += pkt.Size
+	c.lastSize = pkt.Size
+
+	return result, completed
+}
+
+// belongsToCluster reports whether a probe packet continues the current cluster.
+func (d *ProbeDetector) belongsToCluster(pkt PacketInfo) bool {
+	if pkt.ProbeClusterID != d.current.id {
+		return false
+	}
+	if pkt.ProbeClusterID != 0 {
+		return true
+	}
+	// Padding-only probes: split on send time gaps
+	gap := UnwrapAbsSendTimeDuration(d.current.lastSend, pkt.SendTime)
+	return gap <= d.config.MaxClusterGap
+}
+
+// finish closes the current cluster, evaluates it and records the result.
+func (d *ProbeDetector) finish() ProbeResult {
+	c := d.current
+	d.active = false
+
+	result := ProbeResult{
+		ClusterID: c.id,
+		Packets:   c.packets,
+		Bytes:     c.bytes,
+		Time:      c.lastArrival,
+	}
+	d.evaluate(c, &result)
+
+	if len(d.results) == d.config.HistorySize {
+		copy(d.results, d.results[1:])
+		d.results = d.results[:len(d.results)-1]
+	}
+	d.results = append(d.results, result)
+	return result
+}
+
+// evaluate computes the rates of a cluster and its capacity estimate.
+func (d *ProbeDetector) evaluate(c probeCluster, result *ProbeResult) {
+	if c.packets < d.config.MinPackets {
+		return
+	}
+
+	sendInterval := time.Duration(float64(c.sendSpan) * AbsSendTimeResolution * float64(time.Second))
+	recvInterval := c.lastArrival.Sub(c.firstArrival)
+	if sendInterval <= 0 || recvInterval <= 0 ||
+		sendInterval > d.config.MaxProbeInterval || recvInterval > d.config.MaxProbeInterval {
+		return
+	}
+
+	sendRate := float64(c.bytes-c.lastSize) * 8 / sendInterval.Seconds()
+	recvRate := float64(c.bytes-c.firstSize) * 8 / recvInterval.Seconds()
+	result.SendRate = int64(sendRate)
+	result.ReceiveRate = int64(recvRate)
+
+	if recvRate > d.config.MaxReceiveSendRatio*sendRate {
+		return // Received faster than sent: timestamps are unreliable
+	}
+
+	estimate := min(sendRate, recvRate)
+	if recvRate < d.config.MinUnsaturatedRatio*sendRate {
+		// The probe saturated the link; the receive rate is the capacity
+		estimate = d.config.TargetUtilization * recvRate
+	}
+
+	result.Estimate = int64(estimate)
+	result.Success = result.Estimate > 0
+}
+
+// Results returns the most recent probe results, oldest first.
+// The returned slice is a copy.
+func (d *ProbeDetector) Results() []ProbeResult {
+	out := make([]ProbeResult, len(d.results))
+	copy(out, d.results)
+	return out
+}
+
+// Reset discards the cluster in progress and the result history.
+func (d *ProbeDetector) Reset() {
+	d.current = probeCluster{}
+	d.active = false
+	d.results = d.results[:0]
+}
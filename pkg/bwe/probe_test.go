@@ -0,0 +1,269 @@
+package bwe
+
+import (
+	"testing"
+	"time"
+
+	"github.com/thesyncim/bwe/pkg/bwe/clock"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// sendProbeCluster feeds count probe packets of size bytes sent at sendRate
+// and received at recvRate (bits per second). Returns the send time after
+// the cluster and any completed results.
+func sendProbeCluster(d *ProbeDetector, clock *clock.Virtual, sendTime uint32, clusterID, count, size int, sendRate, recvRate float64) (uint32, []ProbeResult) {
+	sendGap := time.Duration(float64(size*8) / sendRate * float64(time.Second))
+	recvGap := time.Duration(float64(size*8) / recvRate * float64(time.Second))
+
+	var results []ProbeResult
+	arrival := clock.Now()
+	sendOffset := time.Duration(0)
+	for i := 0; i < count; i++ {
+		pkt := PacketInfo{
+			ArrivalTime:    arrival,
+			SendTime:       (sendTime + DurationToAbsSendTime(sendOffset)) % AbsSendTimeMax,
+			Size:           size,
+			SSRC:           0x1234,
+			ProbeClusterID: clusterID,
+			Padding:        clusterID == 0,
+		}
+		if pkt.Padding {
+			pkt.PaddingSize = size
+		}
+		if r, ok := d.OnPacket(pkt); ok {
+			results = append(results, r)
+		}
+		arrival = arrival.Add(recvGap)
+		sendOffset += sendGap
+	}
+	clock.Set(arrival)
+	return (sendTime + DurationToAbsSendTime(sendOffset)) % AbsSendTimeMax, results
+}
+
+// flushProbe delivers a media packet after the cluster gap to complete the cluster.
+func flushProbe(d *ProbeDetector, clock *clock.Virtual, sendTime uint32) (ProbeResult, bool) {
+	clock.Advance(100 * time.Millisecond)
+	return d.OnPacket(PacketInfo{ArrivalTime: clock.Now(), SendTime: sendTime, Size: 1200, SSRC: 0x1234})
+}
+
+func TestProbeDetector_UnsaturatedProbe(t *testing.T) {
+	clock := clock.NewVirtual(time.Time{})
+	d := NewProbeDetector(DefaultProbeConfig())
+
+	sendTime, results := sendProbeCluster(d, clock, 0, 1, 10, 1200, 2_000_000, 2_000_000)
+	assert.Empty(t, results, "cluster should not complete while packets keep arriving")
+
+	result, ok := flushProbe(d, clock, sendTime)
+	require.True(t, ok, "gap should complete the cluster")
+	assert.True(t, result.Success)
+	assert.Equal(t, 1, result.ClusterID)
+	assert.Equal(t, 10, result.Packets)
+	assert.Equal(t, 12_000, result.Bytes)
+	assert.InDelta(t, 2_000_000, result.SendRate, 20_000)
+	assert.InDelta(t, 2_000_000, result.ReceiveRate, 20_000)
+	assert.InDelta(t, 2_000_000, result.Estimate, 20_000, "unsaturated probe estimate is min(send, receive)")
+}
+
+func TestProbeDetector_SaturatedProbe(t *testing.T) {
+	clock := clock.NewVirtual(time.Time{})
+	d := NewProbeDetector(DefaultProbeConfig())
+
+	// Sent at 4 Mbps, link delivers only 2 Mbps
+	sendTime, _ := sendProbeCluster(d, clock, 0, 1, 10, 1200, 4_000_000, 2_000_000)
+	result, ok := flushProbe(d, clock, sendTime)
+	require.True(t, ok)
+	require.True(t, result.Success)
+	assert.InDelta(t, 0.95*2_000_000, result.Estimate, 20_000,
+		"saturated probe estimate is TargetUtilization * receive rate")
+}
+
+func TestProbeDetector_TooFewPackets(t *testing.T) {
+	clock := clock.NewVirtual(time.Time{})
+	d := NewProbeDetector(DefaultProbeConfig())
+
+	sendTime, _ := sendProbeCluster(d, clock, 0, 1, 3, 1200, 2_000_000, 2_000_000)
+	result, ok := flushProbe(d, clock, sendTime)
+	require.True(t, ok)
+	assert.False(t, result.Success, "clusters below MinPackets are not trusted")
+	assert.Equal(t, 3, result.Packets)
+}
+
+func TestProbeDetector_InvalidRatio(t *testing.T) {
+	clock := clock.NewVirtual(time.Time{})
+	d := NewProbeDetector(DefaultProbeConfig())
+
+	// Received 3x faster than sent: bad timestamps
+	sendTime, _ := sendProbeCluster(d, clock, 0, 1, 10, 1200, 1_000_000, 3_000_000)
+	result, ok := flushProbe(d, clock, sendTime)
+	require.True(t, ok)
+	assert.False(t, result.Success)
+}
+
+func TestProbeDetector_ClusterIDChange(t *testing.T) {
+	clock := clock.NewVirtual(time.Time{})
+	d := NewProbeDetector(DefaultProbeConfig())
+
+	sendTime, _ := sendProbeCluster(d, clock, 0, 1, 10, 1200, 2_000_000, 2_000_000)
+	_, results := sendProbeCluster(d, clock, sendTime, 2, 10, 1200, 4_000_000, 4_000_000)
+
+	require.Len(t, results, 1, "a new cluster id should complete the previous cluster")
+	assert.Equal(t, 1, results[0].ClusterID)
+	assert.True(t, results[0].Success)
+}
+
+func TestProbeDetector_PaddingClusters(t *testing.T) {
+	clock := clock.NewVirtual(time.Time{})
+	d := NewProbeDetector(DefaultProbeConfig())
+
+	// Padding-marked probes without a cluster id
+	sendTime, _ := sendProbeCluster(d, clock, 0, 0, 10, 1200, 3_000_000, 3_000_000)
+	result, ok := flushProbe(d, clock, sendTime)
+	require.True(t, ok)
+	assert.True(t, result.Success)
+	assert.Equal(t, 0, result.ClusterID)
+	assert.InDelta(t, 3_000_000, result.Estimate, 30_000)
+}
+
+func TestProbeDetector_IgnoresInterleavedMedia(t *testing.T) {
+	clock := clock.NewVirtual(time.Time{})
+	d := NewProbeDetector(DefaultProbeConfig())
+
+	for i := 0; i < 10; i++ {
+		send := DurationToAbsSendTime(time.Duration(i) * 4 * time.Millisecond)
+		_, ok := d.OnPacket(PacketInfo{ArrivalTime: clock.Now(), SendTime: send, Size: 1000, ProbeClusterID: 7})
+		assert.False(t, ok)
+		// Media packet in between probes does not end the cluster
+		_, ok = d.OnPacket(PacketInfo{ArrivalTime: clock.Now().Add(time.Millisecond), SendTime: send, Size: 500})
+		assert.False(t, ok)
+		clock.Advance(4 * time.Millisecond)
+	}
+
+	result, ok := flushProbe(d, clock, DurationToAbsSendTime(40*time.Millisecond))
+	require.True(t, ok)
+	assert.Equal(t, 10, result.Packets)
+	assert.Equal(t, 10_000, result.Bytes)
+	assert.True(t, result.Success)
+}
+
+func TestProbeDetector_SendTimeWrap(t *testing.T) {
+	clock := clock.NewVirtual(time.Time{})
+	d := NewProbeDetector(DefaultProbeConfig())
+
+	start := uint32(AbsSendTimeMax - 1000) // Wraps within the cluster
+	sendTime, _ := sendProbeCluster(d, clock, start, 1, 10, 1200, 2_000_000, 2_000_000)
+	result, ok := flushProbe(d, clock, sendTime)
+	require.True(t, ok)
+	assert.True(t, result.Success)
+	assert.InDelta(t, 2_000_000, result.SendRate, 20_000)
+}
+
+func TestProbeDetector_HistoryAndReset(t *testing.T) {
+	clock := clock.NewVirtual(time.Time{})
+	config := DefaultProbeConfig()
+	config.HistorySize = 3
+	d := NewProbeDetector(config)
+
+	sendTime := uint32(0)
+	for id := 1; id <= 5; id++ {
+		sendTime, _ = sendProbeCluster(d, clock, sendTime, id, 10, 1200, 2_000_000, 2_000_000)
+		_, ok := flushProbe(d, clock, sendTime)
+		require.True(t, ok)
+	}
+
+	results := d.Results()
+	require.Len(t, results, 3, "history should be bounded")
+	assert.Equal(t, 3, results[0].ClusterID, "oldest results are dropped first")
+	assert.Equal(t, 5, results[2].ClusterID)
+
+	d.Reset()
+	assert.Empty(t, d.Results())
+}
+
+func TestNewProbeDetector_AppliesDefaults(t *testing.T) {
+	d := NewProbeDetector(ProbeConfig{})
+	assert.Equal(t, DefaultProbeConfig().MinPackets, d.config.MinPackets)
+	assert.Equal(t, DefaultProbeConfig().MaxClusterGap, d.config.MaxClusterGap)
+	assert.Equal(t, DefaultProbeConfig().HistorySize, d.config.HistorySize)
+}
+
+func TestBandwidthEstimator_ProbeRampUp(t *testing.T) {
+	clock := clock.NewVirtual(time.Time{})
+	e := NewBandwidthEstimator(DefaultBandwidthEstimatorConfig(), clock)
+
+	// 2 seconds of 300 kbps media: 1250-byte packets every ~33ms
+	sendTime := uint32(0)
+	seq := uint16(0)
+	media := func(d time.Duration) {
+		for elapsed := time.Duration(0); elapsed < d; elapsed += 33 * time.Millisecond {
+			e.OnPacket(PacketInfo{ArrivalTime: clock.Now(), SendTime: sendTime, Size: 1250, SSRC: 0x1234, SequenceNumber: seq})
+			seq++
+			sendTime = (sendTime + DurationToAbsSendTime(33*time.Millisecond)) % AbsSendTimeMax
+			clock.Advance(33 * time.Millisecond)
+		}
+	}
+	media(2 * time.Second)
+	before := e.GetEstimate()
+	assert.Less(t, before, int64(1_000_000))
+
+	// 5 Mbps probe cluster of padding packets, delivered at full rate
+	for i := 0; i < 15; i++ {
+		e.OnPacket(PacketInfo{ArrivalTime: clock.Now(), SendTime: sendTime, Size: 1200, PaddingSize: 1200, SSRC: 0x5678, SequenceNumber: uint16(i), Padding: true})
+		gap := time.Duration(float64(1200*8) / 5_000_000 * float64(time.Second))
+		sendTime = (sendTime + DurationToAbsSendTime(gap)) % AbsSendTimeMax
+		clock.Advance(gap)
+	}
+	media(500 * time.Millisecond)
+
+	results := e.GetProbeResults()
+	require.Len(t, results, 1)
+	assert.True(t, results[0].Success)
+	assert.InDelta(t, 5_000_000, e.GetEstimate(), 750_000, "estimate should jump to the probed capacity")
+}
+
+func TestBandwidthEstimator_PaddedMediaIsNotAProbe(t *testing.T) {
+	clock := clock.NewVirtual(time.Time{})
+	e := NewBandwidthEstimator(DefaultBandwidthEstimatorConfig(), clock)
+
+	// 2 seconds of 300 kbps media, then a 5 Mbps burst of media packets
+	// that carry 4 bytes of alignment padding
+	sendTime := uint32(0)
+	send := func(count int, gap time.Duration, padding bool) {
+		for i := 0; i < count; i++ {
+			pkt := PacketInfo{ArrivalTime: clock.Now(), SendTime: sendTime, Size: 1250, HeaderSize: 12, SSRC: 0x1234}
+			if padding {
+				pkt.Padding, pkt.PaddingSize = true, 4
+			}
+			e.OnPacket(pkt)
+			sendTime = (sendTime + DurationToAbsSendTime(gap)) % AbsSendTimeMax
+			clock.Advance(gap)
+		}
+	}
+	send(60, 33*time.Millisecond, false)
+	before := e.GetEstimate()
+
+	send(15, 2*time.Millisecond, true)
+	send(15, 33*time.Millisecond, false)
+
+	assert.Empty(t, e.GetProbeResults(), "padded media should not form a probe cluster")
+	assert.Less(t, e.GetEstimate(), before+before/10, "the estimate should not jump")
+}
+
+func TestBandwidthEstimator_ProbeDisabled(t *testing.T) {
+	clock := clock.NewVirtual(time.Time{})
+	config := DefaultBandwidthEstimatorConfig()
+	config.ProbeConfig.Enabled = false
+	e := NewBandwidthEstimator(config, clock)
+
+	sendTime := uint32(0)
+	for i := 0; i < 30; i++ {
+		e.OnPacket(PacketInfo{ArrivalTime: clock.Now(), SendTime: sendTime, Size: 1200, PaddingSize: 1200, SSRC: 0x1234, Padding: true})
+		sendTime += DurationToAbsSendTime(2 * time.Millisecond)
+		clock.Advance(2 * time.Millisecond)
+	}
+	clock.Advance(time.Second)
+	e.OnPacket(PacketInfo{ArrivalTime: clock.Now(), SendTime: sendTime, Size: 1200, SSRC: 0x1234})
+
+	assert.Empty(t, e.GetProbeResults(), "disabled detector should not record probes")
+}
@@ -72,8 +72,10 @@ func DefaultRateControllerConfig() RateControllerConfig {
 //
 // The controller maintains three states:
 //   - Hold: Maintain current rate (transition buffer)
-//   - Increase: Multiplicatively increase rate (1.08^elapsed)
-//   - Decrease: Multiplicatively decrease rate (beta * incoming_rate)
+//   - Increase: Multiplicatively increase rate (1.08^elapsed), or additively
+//     (about one packet per response time) once the link capacity is known
+//   - Decrease: Multiplicatively decrease rate (beta * incoming rate, bounded
+//     by beta * link capacity when it would exceed the estimate)
 //
 // State transitions follow the GCC specification:
 //
@@ -91,8 +93,55 @@ type RateController struct {
 	state       RateControlState
 	currentRate int64
 	lastUpdate  time.Time
+
+	// Most recent probe result applied via SetEstimate
+	probeRate int64
+	probeTime time.Time
+
+	// Set while the sender is application-limited (see ALRDetector)
+	applicationLimited bool
+
+	// Bottleneck capacity learned from the incoming rate at overuse
+	linkCapacity *LinkCapacityEstimator
+
+	// Round-trip time used to size additive increases and pace decreases
+	rtt time.Duration
+
+	// When the last multiplicative decrease was applied
+	lastDecrease time.Time
 }
 
+// probeRateValidity is how long a probed rate may stand in for the incoming
+// rate in the ratio constraint. It gives the sender time to ramp its media
+// up to the probed capacity before the estimate is tied to the incoming rate.
+const probeRateValidity = 5 * time.Second
+
+// Additive increase constants (from libwebrtc aimd_rate_control.cc).
+const (
+	// defaultRTT is assumed until a round-trip time measurement is available.
+	defaultRTT = 200 * time.Millisecond
+
+	// additiveResponseDelay is added to the RTT to get the time it takes
+	// the sender to react to a new estimate.
+	additiveResponseDelay = 100 * time.Millisecond
+
+	// additiveFrameInterval and additivePacketSize model the media stream
+	// used to size one packet of additive increase.
+	additiveFrameInterval = time.Second / 30
+	additivePacketSize    = 1200 * 8 // bits
+
+	// minAdditiveIncrease is the minimum additive increase in bits per second.
+	minAdditiveIncrease = 4000
+
+	// Bounds on the interval between consecutive decreases. Within one RTT
+	// the sender has not yet reacted to the previous decrease.
+	minReduceInterval = 10 * time.Millisecond
+	maxReduceInterval = 200 * time.Millisecond
+
+	// maxRTT bounds RTT measurements; larger values are treated as bogus.
+	maxRTT = 10 * time.Second
+)
+
 // NewRateController creates a new rate controller with the given configuration.
 func NewRateController(config RateControllerConfig) *RateController {
 	// Apply defaults for zero values
@@ -110,10 +159,12 @@ func NewRateController(config RateControllerConfig) *RateController {
 	}
 
 	return &RateController{
-		config:      config,
-		state:       RateHold, // Start in Hold state
-		currentRate: config.InitialBitrate,
-		lastUpdate:  time.Time{}, // Zero time indicates first update
+		config:       config,
+		state:        RateHold, // Start in Hold state
+		currentRate:  config.InitialBitrate,
+		lastUpdate:   time.Time{}, // Zero time indicates first update
+		linkCapacity: NewLinkCapacityEstimator(),
+		rtt:          defaultRTT,
 	}
 }
 
@@ -141,9 +192,17 @@ func (c *RateController) Update(signal BandwidthUsage, incomingRate int64, now t
 	c.clampRate()
 
 	// Enforce ratio constraint: estimate <= 1.5 * incomingRate
-	// This prevents estimate from diverging too far from actual incoming rate
-	if incomingRate > 0 {
-		maxByRatio := int64(1.5 * float64(incomingRate))
+	// This prevents estimate from diverging too far from actual incoming rate.
+	// A recent successful probe demonstrated more capacity than the media
+	// rate, so it raises the reference rate until the sender catches up.
+	// Skipped while application-limited, where the low incoming rate says
+	// nothing about the link.
+	if incomingRate > 0 && !c.applicationLimited {
+		referenceRate := incomingRate
+		if c.probeRate > referenceRate && now.Sub(c.probeTime) < probeRateValidity {
+			referenceRate = c.probeRate
+		}
+		maxByRatio := int64(1.5 * float64(referenceRate))
 		if c.currentRate > maxByRatio {
 			c.currentRate = maxByRatio
 		}
@@ -155,6 +214,45 @@ func (c *RateController) Update(signal BandwidthUsage, incomingRate int64, now t
 	return c.currentRate
 }
 
+// SetEstimate sets the estimate directly from an external measurement, such
+// as a successful probe cluster. The rate is clamped to the configured bounds
+// and stands in for the incoming rate in the ratio constraint for a while.
+//
+// Returns the new bandwidth estimate in bits per second.
+func (c *RateController) SetEstimate(bitrate int64, now time.Time) int64 {
+	// A probe above the capacity bounds means the link has changed
+	if bitrate > c.linkCapacity.UpperBound() {
+		c.linkCapacity.Reset()
+	}
+	c.currentRate = bitrate
+	c.clampRate()
+	c.probeRate = c.currentRate
+	c.probeTime = now
+	c.lastUpdate = now
+	return c.currentRate
+}
+
+// SetApplicationLimited marks whether the sender is application-limited.
+//
+// On entering the application-limited region the estimate is restored to
+// capacity, the last-known-good capacity. While limited, the estimate
+// neither grows (there is no traffic to validate the growth) nor follows
+// the incoming rate down via the ratio constraint. Decreases on overuse
+// still apply.
+func (c *RateController) SetApplicationLimited(limited bool, capacity int64) {
+	if limited && !c.applicationLimited && capacity > 0 {
+		c.currentRate = capacity
+		c.clampRate()
+	}
+	c.applicationLimited = limited
+}
+
+// ApplicationLimited returns whether the controller is in the
+// application-limited region.
+func (c *RateController) ApplicationLimited() bool {
+	return c.applicationLimited
+}
+
 // transitionState applies the GCC state transition table.
 //
 //	Signal     | Hold     | Increase | Decrease
@@ -200,19 +298,56 @@ func (c *RateController) transitionState(signal BandwidthUsage) {
 func (c *RateController) adjustRate(incomingRate int64, now time.Time) {
 	switch c.state {
 	case RateDecrease:
+		// The sender needs about one RTT to react to a decrease; reducing
+		// again before then would count the same congestion twice. A
+		// collapse of the incoming rate still cuts immediately.
+		if !c.timeToReduceFurther(incomingRate, now) {
+			return
+		}
+		c.lastDecrease = now
+
 		// CRITICAL: Use incomingRate, NOT currentRate
 		// This handles the case where sender has already reduced rate
-		c.currentRate = int64(c.config.Beta * float64(incomingRate))
+		decreased := int64(c.config.Beta * float64(incomingRate))
+
+		// A noisy sample above the estimate is bounded by the averaged
+		// capacity, as libwebrtc does
+		if decreased > c.currentRate {
+			if capacity, ok := c.linkCapacity.Estimate(); ok {
+				decreased = min(decreased, int64(c.config.Beta*float64(capacity)))
+			}
+		}
+		c.currentRate = decreased
+
+		// The incoming rate at overuse samples the bottleneck; a sample far
+		// below the learned capacity means the link itself got slower
+		if incomingRate < c.linkCapacity.LowerBound() {
+			c.linkCapacity.Reset()
+		}
+		c.linkCapacity.OnOveruseDetected(incomingRate)
 
 	case RateIncrease:
-		// Multiplicative increase: rate = rate * 1.08^elapsed
-		// Cap elapsed at 1 second to prevent excessive jumps after idle periods
-		if !c.lastUpdate.IsZero() {
+		// Cap elapsed at 1 second to prevent excessive jumps after idle periods.
+		// No growth while application-limited: the link is not being tested.
+		if !c.lastUpdate.IsZero() && !c.applicationLimited {
+			// An incoming rate above the capacity bounds means the link has
+			// more room than learned: go back to multiplicative probing
+			if incomingRate > c.linkCapacity.UpperBound() {
+				c.linkCapacity.Reset()
+			}
+
 			elapsed := now.Sub(c.lastUpdate).Seconds()
 			elapsed = math.Min(elapsed, 1.0) // Cap at 1 second
 			if elapsed > 0 {
-				eta := math.Pow(1.08, elapsed)
-				c.currentRate = int64(eta * float64(c.currentRate))
+				if c.linkCapacity.HasEstimate() {
+					// Near the known capacity: additive increase of about
+					// one packet per response time
+					c.currentRate += int64(c.additiveIncreaseRate() * elapsed)
+				} else {
+					// Multiplicative increase: rate = rate * 1.08^elapsed
+					eta := math.Pow(1.08, elapsed)
+					c.currentRate = int64(eta * float64(c.currentRate))
+				}
 			}
 		}
 
@@ -221,6 +356,48 @@ func (c *RateController) adjustRate(incomingRate int64, now time.Time) {
 	}
 }
 
+// timeToReduceFurther reports whether another decrease may be applied: at
+// least one RTT (clamped to [10ms, 200ms]) has passed since the last
+// decrease, or the incoming rate has fallen below half the estimate.
+func (c *RateController) timeToReduceFurther(incomingRate int64, now time.Time) bool {
+	if c.lastDecrease.IsZero() {
+		return true
+	}
+	interval := min(max(c.rtt, minReduceInterval), maxReduceInterval)
+	if now.Sub(c.lastDecrease) >= interval {
+		return true
+	}
+	return incomingRate > 0 && incomingRate < c.currentRate/2
+}
+
+// additiveIncreaseRate returns the near-max additive increase in bits per
+// second per second: one average packet per response time, where packets
+// are sized from the current rate at 30 frames per second.
+func (c *RateController) additiveIncreaseRate() float64 {
+	frameSize := float64(c.currentRate) * additiveFrameInterval.Seconds()
+	packetsPerFrame := math.Ceil(frameSize / additivePacketSize)
+	avgPacketSize := frameSize / packetsPerFrame
+
+	responseTime := (c.rtt + additiveResponseDelay).Seconds()
+	return math.Max(minAdditiveIncrease, avgPacketSize/responseTime)
+}
+
+// UpdateRTT sets the round-trip time used for response-time-based increase
+// and for pacing consecutive decreases. Non-positive values are ignored and
+// values above 10s are clamped.
+func (c *RateController) UpdateRTT(rtt time.Duration) {
+	if rtt <= 0 {
+		return
+	}
+	c.rtt = min(rtt, maxRTT)
+}
+
+// RTT returns the round-trip time in use. Until UpdateRTT is called this is
+// the 200ms default.
+func (c *RateController) RTT() time.Duration {
+	return c.rtt
+}
+
 // clampRate enforces min/max bitrate bounds.
 func (c *RateController) clampRate() {
 	if c.currentRate < c.config.MinBitrate {
@@ -242,10 +419,35 @@ func (c *RateController) Estimate() int64 {
 	return c.currentRate
 }
 
+// LinkCapacity returns the estimated bottleneck capacity in bits per second.
+// Returns (capacity, true) if an estimate exists, (0, false) otherwise.
+func (c *RateController) LinkCapacity() (int64, bool) {
+	return c.linkCapacity.Estimate()
+}
+
+// restartAt sets the estimate and restarts the controller from Hold, as
+// after a long idle period: the learned link capacity and the
+// application-limited state are dropped.
+// Returns the new bandwidth estimate in bits per second.
+func (c *RateController) restartAt(bitrate int64) int64 {
+	c.state = RateHold
+	c.currentRate = bitrate
+	c.clampRate()
+	c.lastUpdate = time.Time{}
+	c.applicationLimited = false
+	c.linkCapacity.Reset()
+	return c.currentRate
+}
+
 // Reset resets the controller to initial state.
 // Call this when switching streams or after extended silence.
 func (c *RateController) Reset() {
 	c.state = RateHold
 	c.currentRate = c.config.InitialBitrate
 	c.lastUpdate = time.Time{}
+	c.probeRate = 0
+	c.probeTime = time.Time{}
+	c.applicationLimited = false
+	c.lastDecrease = time.Time{}
+	c.linkCapacity.Reset()
 }
@@ -5,6 +5,7 @@ import (
 	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestRateController_InitialState(t *testing.T) {
@@ -359,3 +360,203 @@ func TestRateController_RecoverySequence(t *testing.T) {
 	rc.Update(BwNormal, incomingRate, baseTime.Add(200*time.Millisecond))
 	assert.Equal(t, RateIncrease, rc.State(), "step 3: should be Increase")
 }
+
+func TestRateController_SetEstimate(t *testing.T) {
+	config := DefaultRateControllerConfig()
+	rc := NewRateController(config)
+	now := time.Now()
+
+	assert.Equal(t, int64(5_000_000), rc.SetEstimate(5_000_000, now))
+	assert.Equal(t, int64(5_000_000), rc.Estimate())
+
+	// Clamped to bounds
+	assert.Equal(t, config.MaxBitrate, rc.SetEstimate(100_000_000, now))
+	assert.Equal(t, config.MinBitrate, rc.SetEstimate(1, now))
+}
+
+func TestRateController_ProbeRaisesRatioReference(t *testing.T) {
+	config := DefaultRateControllerConfig()
+	rc := NewRateController(config)
+	now := time.Now()
+
+	rc.SetEstimate(5_000_000, now)
+
+	// Media is still at 1 Mbps, but the probe proved 5 Mbps of capacity
+	estimate := rc.Update(BwNormal, 1_000_000, now.Add(100*time.Millisecond))
+	assert.GreaterOrEqual(t, estimate, int64(5_000_000),
+		"a fresh probe should not be clamped to 1.5 * incoming rate")
+
+	// Once the probe is stale, the incoming rate governs again
+	estimate = rc.Update(BwNormal, 1_000_000, now.Add(probeRateValidity+time.Second))
+	assert.LessOrEqual(t, estimate, int64(1_500_000))
+
+	// Reset forgets the probe
+	rc.Reset()
+	rc.Update(BwNormal, 100_000, now)
+	assert.LessOrEqual(t, rc.Estimate(), int64(150_000))
+}
+
+func TestRateController_ApplicationLimited(t *testing.T) {
+	config := DefaultRateControllerConfig()
+	rc := NewRateController(config)
+	now := time.Now()
+
+	rc.SetApplicationLimited(true, 2_000_000)
+	assert.True(t, rc.ApplicationLimited())
+	assert.Equal(t, int64(2_000_000), rc.Estimate(), "entering ALR restores the capacity")
+
+	// Neither grows nor collapses to 1.5 * the trickle rate
+	rc.Update(BwNormal, 100_000, now)
+	rc.Update(BwNormal, 100_000, now.Add(time.Second))
+	assert.Equal(t, int64(2_000_000), rc.Estimate())
+
+	// Overuse still decreases
+	rc.Update(BwOverusing, 100_000, now.Add(2*time.Second))
+	assert.Equal(t, int64(85_000), rc.Estimate())
+
+	// Leaving ALR re-enables the ratio constraint
+	rc.SetApplicationLimited(false, 0)
+	rc.SetEstimate(2_000_000, now.Add(-time.Hour)) // Stale probe
+	rc.Update(BwNormal, 100_000, now.Add(3*time.Second))
+	assert.LessOrEqual(t, rc.Estimate(), int64(150_000))
+
+	rc.SetApplicationLimited(true, 1_000_000)
+	rc.Reset()
+	assert.False(t, rc.ApplicationLimited())
+}
+
+func TestRateController_LinkCapacity(t *testing.T) {
+	config := DefaultRateControllerConfig()
+	rc := NewRateController(config)
+	now := time.Now()
+
+	_, ok := rc.LinkCapacity()
+	assert.False(t, ok, "no capacity before the first overuse")
+
+	rc.Update(BwOverusing, 2_000_000, now)
+	capacity, ok := rc.LinkCapacity()
+	require.True(t, ok)
+	assert.Equal(t, int64(2_000_000), capacity)
+
+	// The decrease follows the measured incoming rate
+	estimate := rc.Update(BwOverusing, 1_950_000, now.Add(200*time.Millisecond))
+	assert.Equal(t, int64(config.Beta*1_950_000), estimate)
+	capacity, _ = rc.LinkCapacity()
+	assert.InDelta(t, 1_997_500, capacity, 1)
+
+	// A sample whose decrease would exceed the estimate is bounded by
+	// beta * the averaged capacity
+	estimate = rc.Update(BwOverusing, 2_300_000, now.Add(400*time.Millisecond))
+	assert.Equal(t, int64(config.Beta*float64(capacity)), estimate)
+
+	// A sample far below the capacity means the link got slower
+	rc.Update(BwOverusing, 500_000, now.Add(600*time.Millisecond))
+	capacity, _ = rc.LinkCapacity()
+	assert.Equal(t, int64(500_000), capacity, "capacity should be re-learned")
+
+	rc.Reset()
+	_, ok = rc.LinkCapacity()
+	assert.False(t, ok)
+}
+
+func TestRateController_AdditiveIncreaseNearCapacity(t *testing.T) {
+	config := DefaultRateControllerConfig()
+	rc := NewRateController(config)
+	now := time.Now()
+
+	// Learn a 2 Mbps capacity, then recover: Decrease -> Hold -> Increase
+	rc.Update(BwOverusing, 2_000_000, now)
+	rc.Update(BwNormal, 1_700_000, now.Add(100*time.Millisecond))
+	rc.Update(BwNormal, 1_700_000, now.Add(200*time.Millisecond))
+	require.Equal(t, RateIncrease, rc.State())
+
+	before := rc.Estimate()
+	after := rc.Update(BwNormal, 1_700_000, now.Add(1200*time.Millisecond))
+
+	// One 1200-byte packet per (200ms RTT + 100ms) = 32 kbps per second,
+	// far below the 8% (136 kbps) multiplicative step
+	assert.InDelta(t, 32_000, after-before, 1_000, "near capacity the increase should be additive")
+}
+
+func TestRateController_MultiplicativeAboveCapacity(t *testing.T) {
+	config := DefaultRateControllerConfig()
+	rc := NewRateController(config)
+	now := time.Now()
+
+	rc.Update(BwOverusing, 1_000_000, now)
+	rc.Update(BwNormal, 850_000, now.Add(100*time.Millisecond))
+	rc.Update(BwNormal, 850_000, now.Add(200*time.Millisecond))
+
+	// Incoming rate well above the capacity bounds: the link got faster
+	rc.Update(BwNormal, 3_000_000, now.Add(1200*time.Millisecond))
+	_, ok := rc.LinkCapacity()
+	assert.False(t, ok, "rates above the upper bound should reset the capacity")
+
+	before := rc.Estimate()
+	after := rc.Update(BwNormal, 3_000_000, now.Add(2200*time.Millisecond))
+	assert.InDelta(t, 1.08*float64(before), after, 100, "without a capacity the increase is multiplicative")
+}
+
+func TestRateController_UpdateRTT(t *testing.T) {
+	rc := NewRateController(DefaultRateControllerConfig())
+	assert.Equal(t, 200*time.Millisecond, rc.RTT(), "default RTT before any measurement")
+
+	rc.UpdateRTT(50 * time.Millisecond)
+	assert.Equal(t, 50*time.Millisecond, rc.RTT())
+
+	rc.UpdateRTT(0)
+	rc.UpdateRTT(-time.Second)
+	assert.Equal(t, 50*time.Millisecond, rc.RTT(), "non-positive RTTs are ignored")
+
+	rc.UpdateRTT(time.Minute)
+	assert.Equal(t, 10*time.Second, rc.RTT(), "RTT should be clamped")
+}
+
+func TestRateController_AdditiveIncreaseUsesRTT(t *testing.T) {
+	config := DefaultRateControllerConfig()
+	rc := NewRateController(config)
+	rc.UpdateRTT(20 * time.Millisecond)
+	now := time.Now()
+
+	rc.Update(BwOverusing, 2_000_000, now)
+	rc.Update(BwNormal, 1_700_000, now.Add(100*time.Millisecond))
+	rc.Update(BwNormal, 1_700_000, now.Add(200*time.Millisecond))
+	require.Equal(t, RateIncrease, rc.State())
+
+	before := rc.Estimate()
+	after := rc.Update(BwNormal, 1_700_000, now.Add(1200*time.Millisecond))
+
+	// One 1200-byte packet per (20ms RTT + 100ms) = 80 kbps per second
+	assert.InDelta(t, 80_000, after-before, 1_000, "a shorter RTT should increase faster")
+}
+
+func TestRateController_DecreaseOncePerRTT(t *testing.T) {
+	config := DefaultRateControllerConfig()
+	rc := NewRateController(config)
+	rc.UpdateRTT(100 * time.Millisecond)
+	now := time.Now()
+
+	first := rc.Update(BwOverusing, 2_000_000, now)
+	assert.Equal(t, int64(1_700_000), first)
+
+	// Still overusing within one RTT: the sender has not reacted yet
+	held := rc.Update(BwOverusing, 1_800_000, now.Add(50*time.Millisecond))
+	assert.Equal(t, first, held, "no second decrease within one RTT")
+	capacity, _ := rc.LinkCapacity()
+	assert.Equal(t, int64(2_000_000), capacity, "held samples are not folded into the capacity")
+
+	// After one RTT the next decrease applies
+	second := rc.Update(BwOverusing, 1_800_000, now.Add(100*time.Millisecond))
+	assert.Less(t, second, first)
+}
+
+func TestRateController_DecreaseOnIncomingCollapse(t *testing.T) {
+	rc := NewRateController(DefaultRateControllerConfig())
+	now := time.Now()
+
+	rc.Update(BwOverusing, 2_000_000, now)
+
+	// Incoming rate below half the estimate cuts immediately
+	estimate := rc.Update(BwOverusing, 400_000, now.Add(10*time.Millisecond))
+	assert.Equal(t, int64(340_000), estimate)
+}
@@ -0,0 +1,91 @@
+package bwe
+
+import "time"
+
+// DefaultSingleStreamTimeout is how long a stream may go without packets
+// before its state is discarded in single-stream mode (kStreamTimeOutMs).
+const DefaultSingleStreamTimeout = 2 * time.Second
+
+// RTPTimestampConverter converts the RTP timestamps of one stream into
+// abs-send-time values, so streams without the abs-send-time or
+// abs-capture-time extensions can still drive the delay-based estimator.
+// This is the send time used by libwebrtc's RemoteBitrateEstimatorSingleStream.
+//
+// RTP timestamps are sampling instants, not send times: all packets of a
+// frame share one timestamp, and each stream starts at a random offset.
+// Send times from different converters are therefore not comparable, so
+// the estimator needs one delay pipeline per SSRC (see DefaultSingleStreamConfig).
+type RTPTimestampConverter struct {
+	clockRate uint32
+
+	// Unwrapped timestamp of the newest packet, relative to the first
+	last      uint32
+	unwrapped int64
+	started   bool
+}
+
+// NewRTPTimestampConverter creates a converter for a stream whose RTP clock
+// runs at clockRate Hz (e.g. 90000 for video, 48000 for Opus).
+// Returns nil if clockRate is zero.
+func NewRTPTimestampConverter(clockRate uint32) *RTPTimestampConverter {
+	if clockRate == 0 {
+		return nil
+	}
+	return &RTPTimestampConverter{clockRate: clockRate}
+}
+
+// SendTime returns the abs-send-time value (6.18 fixed point, modulo 64
+// seconds) of an RTP timestamp. The first timestamp maps to 0.
+//
+// RTP timestamps wrap at 2^32; they are unwrapped with half-range
+// comparison, so a timestamp behind the newest one (a reordered packet or
+// an earlier frame) maps to an earlier send time without moving the
+// converter back.
+func (c *RTPTimestampConverter) SendTime(timestamp uint32) uint32 {
+	if !c.started {
+		c.last = timestamp
+		c.started = true
+	}
+
+	ticks := c.unwrapped + int64(int32(timestamp-c.last))
+	if ticks > c.unwrapped {
+		c.unwrapped = ticks
+		c.last = timestamp
+	}
+
+	// Work in abs-send-time units to keep precision; the mask takes the
+	// value modulo 64 seconds, also for ticks before the first timestamp
+	units := ticks * (1 << 18) / int64(c.clockRate)
+	return uint32(units & (AbsSendTimeMax - 1))
+}
+
+// ClockRate returns the RTP clock rate in Hz.
+func (c *RTPTimestampConverter) ClockRate() uint32 {
+	return c.clockRate
+}
+
+// DefaultSingleStreamConfig returns a configuration tuned for send times
+// derived from RTP timestamps with RTPTimestampConverter, matching
+// libwebrtc's RemoteBitrateEstimatorSingleStream:
+//   - one delay pipeline per SSRC, since each stream has its own timestamp
+//     offset, dropped after 2s without packets
+//   - grouping by send time, so all packets of a frame (same RTP timestamp)
+//     and frames within 5ms form one group, independent of network jitter
+//   - the Kalman filter (libwebrtc's OveruseEstimator)
+//   - ALR and probe detection disabled: both compare send times across
+//     streams, and RTP timestamps carry no pacing information
+func DefaultSingleStreamConfig() BandwidthEstimatorConfig {
+	config := DefaultBandwidthEstimatorConfig()
+
+	config.DelayConfig.FilterType = FilterKalman
+	config.DelayConfig.GroupingMode = GroupBySendTime
+	config.DelayConfig.BurstThreshold = DefaultBurstThreshold
+
+	config.PerStreamConfig.Enabled = true
+	config.PerStreamConfig.IdleTimeout = DefaultSingleStreamTimeout
+
+	config.ALRConfig.Enabled = false
+	config.ProbeConfig.Enabled = false
+
+	return config
+}
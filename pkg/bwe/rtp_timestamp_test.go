@@ -0,0 +1,82 @@
+package bwe
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRTPTimestampConverter_ClockRate(t *testing.T) {
+	c := NewRTPTimestampConverter(90000)
+	require.NotNil(t, c)
+
+	assert.Equal(t, uint32(0), c.SendTime(1_000_000))
+	// 90000 ticks = 1s = 1<<18 abs-send-time units
+	assert.Equal(t, uint32(1<<18), c.SendTime(1_090_000))
+	// 3000 ticks = 33.3ms
+	assert.InDelta(t, float64(33333*time.Microsecond),
+		float64(UnwrapAbsSendTimeDuration(c.SendTime(1_090_000), c.SendTime(1_093_000))), float64(10*time.Microsecond))
+
+	audio := NewRTPTimestampConverter(48000)
+	audio.SendTime(0)
+	assert.InDelta(t, float64(20*time.Millisecond), float64(AbsSendTimeToDuration(audio.SendTime(960))), float64(5*time.Microsecond))
+
+	assert.Nil(t, NewRTPTimestampConverter(0), "unknown clock rate")
+}
+
+func TestRTPTimestampConverter_Wraparound(t *testing.T) {
+	c := NewRTPTimestampConverter(90000)
+
+	prev := c.SendTime(0xFFFF_FFFF - 1500)
+	curr := c.SendTime(1500) // 3001 ticks later, across the 32-bit wrap
+	assert.InDelta(t, float64(33344*time.Microsecond),
+		float64(UnwrapAbsSendTimeDuration(prev, curr)), float64(10*time.Microsecond))
+}
+
+func TestRTPTimestampConverter_FramesAndReordering(t *testing.T) {
+	c := NewRTPTimestampConverter(90000)
+
+	frame1 := c.SendTime(9000)
+	frame2 := c.SendTime(12000)
+	assert.Equal(t, frame2, c.SendTime(12000), "packets of a frame share a send time")
+
+	// A late packet of frame 1 maps to frame 1's send time
+	assert.Equal(t, frame1, c.SendTime(9000))
+	assert.Equal(t, frame2, c.SendTime(12000), "reordering does not move the converter back")
+}
+
+func TestDefaultSingleStreamConfig_FallbackStream(t *testing.T) {
+	config := DefaultSingleStreamConfig()
+	require.True(t, config.PerStreamConfig.Enabled)
+	assert.Equal(t, GroupBySendTime, config.DelayConfig.GroupingMode)
+
+	e := NewBandwidthEstimator(config, nil)
+	video := NewRTPTimestampConverter(90000)
+	audio := NewRTPTimestampConverter(48000)
+	start := time.Unix(0, 0)
+
+	// 30fps video with 3 packets per frame, 20ms audio frames, both with
+	// random timestamp offsets
+	for i := 0; i < 150; i++ {
+		frameTime := start.Add(time.Duration(i) * 33 * time.Millisecond)
+		for p := 0; p < 3; p++ {
+			e.OnPacket(PacketInfo{
+				ArrivalTime: frameTime.Add(time.Duration(p) * time.Millisecond),
+				SendTime:    video.SendTime(uint32(3_000_000_000 + i*2970)),
+				Size:        1200,
+				SSRC:        1,
+			})
+		}
+		e.OnPacket(PacketInfo{
+			ArrivalTime: frameTime.Add(10 * time.Millisecond),
+			SendTime:    audio.SendTime(uint32(77_777 + i*1584)),
+			Size:        100,
+			SSRC:        2,
+		})
+	}
+
+	assert.Equal(t, InterArrivalStats{}, e.GetInterArrivalStats())
+	assert.NotEqual(t, BwOverusing, e.GetCongestionState())
+}
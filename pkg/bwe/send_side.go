@@ -0,0 +1,491 @@
+// Package bwe implements Google Congestion Control (GCC) receiver-side
+// bandwidth estimation for WebRTC.
+package bwe
+
+import (
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/thesyncim/bwe/pkg/bwe/clock"
+)
+
+// sendHistorySize is the number of sent packets remembered while waiting for
+// transport-cc feedback. Must be a power of two. At 1000 packets per second
+// this covers about 8 seconds, well beyond typical feedback delays.
+const sendHistorySize = 1 << 13
+
+// SentPacket describes an outgoing RTP packet tagged with a transport-wide
+// sequence number. This is the sender-side input to SendSideEstimator.
+type SentPacket struct {
+	// TransportSequenceNumber is the transport-wide sequence number written
+	// into the transport-cc header extension.
+	TransportSequenceNumber uint16
+
+	// SendTime is the local monotonic time the packet was sent.
+	SendTime time.Time
+
+	// Size is the size of the packet in bytes.
+	Size int
+
+	// SSRC is the synchronization source identifier of the media stream.
+	SSRC uint32
+}
+
+// PacketFeedback is the per-packet status reported by the remote end in
+// transport-cc feedback.
+type PacketFeedback struct {
+	// TransportSequenceNumber identifies the packet.
+	TransportSequenceNumber uint16
+
+	// Received is true if the remote end received the packet.
+	Received bool
+
+	// ArrivalTime is the arrival time at the remote end, in the remote clock
+	// domain. Only meaningful if Received is true.
+	ArrivalTime time.Time
+}
+
+// PacketResult pairs a sent packet with its reported arrival.
+type PacketResult struct {
+	SentPacket
+
+	// Received is true if the remote end received the packet.
+	Received bool
+
+	// ArrivalTime is the arrival time at the remote end (remote clock domain).
+	ArrivalTime time.Time
+}
+
+// sentRecord is one slot of the send history ring.
+type sentRecord struct {
+	seq    int64 // Unwrapped transport sequence number
+	packet SentPacket
+	valid  bool
+}
+
+// SendSideConfig configures the sender-side estimator.
+type SendSideConfig struct {
+	// DelayConfig configures the delay-based detector.
+	DelayConfig DelayEstimatorConfig
+
+	// RateControllerConfig configures the AIMD rate controller.
+	RateControllerConfig RateControllerConfig
+
+	// LossControllerConfig configures the loss-based controller.
+	LossControllerConfig LossControllerConfig
+
+	// AckedBitrateConfig configures the acknowledged bitrate estimator.
+	AckedBitrateConfig AckedBitrateConfig
+
+	// ALRConfig configures application-limited region detection.
+	ALRConfig ALRDetectorConfig
+}
+
+// DefaultSendSideConfig returns default configuration for the sender-side estimator.
+func DefaultSendSideConfig() SendSideConfig {
+	return SendSideConfig{
+		DelayConfig:          DefaultDelayEstimatorConfig(),
+		RateControllerConfig: DefaultRateControllerConfig(),
+		LossControllerConfig: DefaultLossControllerConfig(),
+		AckedBitrateConfig:   DefaultAckedBitrateConfig(),
+		ALRConfig:            DefaultALRDetectorConfig(),
+	}
+}
+
+// SendSideEstimator runs the GCC pipeline on the sending side, driven by
+// transport-cc feedback instead of locally observed arrivals.
+//
+// It remembers every sent packet by transport-wide sequence number, and when
+// feedback arrives rebuilds per-packet send/arrival pairs. Those pairs feed:
+//   - DelayEstimator (InterArrivalCalculator, delay filter, OveruseDetector)
+//   - AckedBitrateEstimator for the delivered throughput
+//   - LossController for the loss-based bound
+//
+// The resulting target bitrate is min(delay-based, loss-based) and is meant
+// to drive the local encoders.
+//
+// SendSideEstimator is safe for concurrent use from multiple goroutines.
+type SendSideEstimator struct {
+	config         SendSideConfig
+	clock          clock.Clock
+	delayEstimator *DelayEstimator
+	rateController *RateController
+	lossController *LossController
+	ackedBitrate   *AckedBitrateEstimator
+	alrDetector    *ALRDetector
+	sentRate       *RateStats // Transmit rate, for ALR detection
+
+	mu sync.Mutex
+
+	// Send history (protected by mu)
+	history     []sentRecord
+	lastSentSeq int64
+	hasSent     bool
+	sendOrigin  time.Time
+
+	// Scratch buffer reused across feedback reports (protected by mu)
+	results  []PacketResult
+	feedback []PacketFeedback
+
+	// transport-cc reference time unwrapping (protected by mu)
+	lastRefTime   uint32
+	refTimeCycles int64
+	hasRefTime    bool
+
+	// Current state (protected by mu)
+	target        int64
+	delayEstimate int64
+	lossEstimate  int64
+	activeBound   EstimateBound
+}
+
+// NewSendSideEstimator creates a new sender-side estimator.
+// If clk is nil, clock.Monotonic is used.
+func NewSendSideEstimator(config SendSideConfig, clk clock.Clock) *SendSideEstimator {
+	if clk == nil {
+		clk = clock.Monotonic{}
+	}
+
+	rateController := NewRateController(config.RateControllerConfig)
+
+	return &SendSideEstimator{
+		config:         config,
+		clock:          clk,
+		delayEstimator: NewDelayEstimator(config.DelayConfig, clk),
+		rateController: rateController,
+		lossController: NewLossController(config.LossControllerConfig),
+		ackedBitrate:   NewAckedBitrateEstimator(config.AckedBitrateConfig),
+		alrDetector:    NewALRDetector(config.ALRConfig),
+		sentRate:       newSendRateStats(config.ALRConfig),
+		history:        make([]sentRecord, sendHistorySize),
+		results:        make([]PacketResult, 0, 64),
+		target:         rateController.Estimate(),
+		delayEstimate:  rateController.Estimate(),
+	}
+}
+
+// OnPacketSent records an outgoing packet so it can be matched with feedback.
+// Call this for every packet that carries a transport-wide sequence number.
+func (e *SendSideEstimator) OnPacketSent(pkt SentPacket) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	var seq int64
+	if e.hasSent {
+		seq = e.unwrapSeq(pkt.TransportSequenceNumber)
+	} else {
+		seq = int64(pkt.TransportSequenceNumber)
+		e.lastSentSeq = seq
+		e.sendOrigin = pkt.SendTime
+		e.hasSent = true
+	}
+	if seq > e.lastSentSeq {
+		e.lastSentSeq = seq
+	}
+
+	e.history[seq&(sendHistorySize-1)] = sentRecord{
+		seq:    seq,
+		packet: pkt,
+		valid:  true,
+	}
+	e.sentRate.Update(int64(pkt.Size), pkt.SendTime)
+}
+
+// lastSendTime returns the send time of the most recently sent packet.
+// Must be called with mu held.
+func (e *SendSideEstimator) lastSendTime() time.Time {
+	return e.history[e.lastSentSeq&(sendHistorySize-1)].packet.SendTime
+}
+
+// hasSentBefore reports whether the history holds a record for seq.
+// Must be called with mu held.
+func (e *SendSideEstimator) hasSentBefore(seq int64) bool {
+	rec := &e.history[seq&(sendHistorySize-1)]
+	return rec.valid && rec.seq == seq
+}
+
+// unwrapSeq extends a 16-bit transport sequence number relative to the
+// highest sequence number sent so far. Must be called with mu held.
+func (e *SendSideEstimator) unwrapSeq(seq uint16) int64 {
+	delta := int64(int16(seq - uint16(e.lastSentSeq)))
+	return e.lastSentSeq + delta
+}
+
+// OnFeedback processes per-packet feedback from the remote end and returns
+// the new target bitrate in bits per second.
+//
+// Feedback for packets that are not in the send history (never sent, or too
+// old) is ignored. The feedback slice is not retained.
+func (e *SendSideEstimator) OnFeedback(feedback []PacketFeedback, now time.Time) int64 {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.onFeedbackLocked(feedback, now)
+}
+
+// onFeedbackLocked implements OnFeedback. Must be called with mu held.
+func (e *SendSideEstimator) onFeedbackLocked(feedback []PacketFeedback, now time.Time) int64 {
+	if !e.hasSent {
+		return e.target
+	}
+
+	// Rebuild send/arrival pairs. Feedback is in sequence-number order, which
+	// is also the order the loss controller expects.
+	e.results = e.results[:0]
+	for _, fb := range feedback {
+		seq := e.unwrapSeq(fb.TransportSequenceNumber)
+		if !e.hasSentBefore(seq) {
+			continue
+		}
+		if !fb.Received {
+			continue // Lost: shows up as a sequence gap in the loss controller
+		}
+
+		if e.config.LossControllerConfig.Enabled {
+			e.lossController.OnPacket(0, fb.TransportSequenceNumber, now)
+		}
+
+		e.results = append(e.results, PacketResult{
+			SentPacket:  e.history[seq&(sendHistorySize-1)].packet,
+			Received:    true,
+			ArrivalTime: fb.ArrivalTime,
+		})
+	}
+
+	if len(e.results) == 0 {
+		return e.target
+	}
+
+	// The delay pipeline consumes packets in arrival order
+	slices.SortStableFunc(e.results, func(a, b PacketResult) int {
+		return a.ArrivalTime.Compare(b.ArrivalTime)
+	})
+
+	sawOveruse := false
+	for i := range e.results {
+		r := &e.results[i]
+		e.ackedBitrate.OnPacketAcked(r.Size, r.ArrivalTime)
+
+		signal := e.delayEstimator.OnPacket(PacketInfo{
+			ArrivalTime:    r.ArrivalTime,
+			SendTime:       DurationToAbsSendTime(r.SendTime.Sub(e.sendOrigin)),
+			Size:           r.Size,
+			SSRC:           r.SSRC,
+			SequenceNumber: r.TransportSequenceNumber,
+		})
+		if signal == BwOverusing {
+			sawOveruse = true
+		}
+	}
+
+	// Any overuse within one report counts as overuse for the report
+	signal := e.delayEstimator.State()
+	if sawOveruse {
+		signal = BwOverusing
+	}
+
+	ackedRate, ok := e.ackedBitrate.Bitrate()
+	if !ok {
+		return e.target
+	}
+
+	// Hold the estimate while the sender is application-limited
+	if e.config.ALRConfig.Enabled {
+		if signal == BwOverusing {
+			e.alrDetector.Reset()
+			e.sentRate.Reset()
+			e.rateController.SetApplicationLimited(false, 0)
+		}
+		if sentRate, ok := e.sentRate.Rate(e.lastSendTime()); ok {
+			limited := e.alrDetector.Update(sentRate, e.delayEstimate, now)
+			e.rateController.SetApplicationLimited(limited, e.alrDetector.Capacity())
+		}
+	}
+
+	e.delayEstimate = e.rateController.Update(signal, ackedRate, now)
+	e.updateTarget(now)
+
+	return e.target
+}
+
+// updateTarget combines the delay-based estimate with the loss-based bound.
+// Must be called with mu held.
+func (e *SendSideEstimator) updateTarget(now time.Time) {
+	e.target = e.delayEstimate
+	e.activeBound = BoundDelay
+
+	if !e.config.LossControllerConfig.Enabled {
+		return
+	}
+
+	lossEstimate, ok := e.lossController.Update(e.delayEstimate, e.rateController.config.MaxBitrate, now)
+	if !ok {
+		e.lossEstimate = 0
+		return
+	}
+	e.lossEstimate = lossEstimate
+
+	if lossEstimate < e.target {
+		e.target = lossEstimate
+		e.activeBound = BoundLoss
+	}
+	if e.target < e.rateController.config.MinBitrate {
+		e.target = e.rateController.config.MinBitrate
+	}
+}
+
+// TargetBitrate returns the current target bitrate in bits per second.
+func (e *SendSideEstimator) TargetBitrate() int64 {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.target
+}
+
+// GetDelayBasedEstimate returns the delay-based AIMD estimate in bits per second.
+func (e *SendSideEstimator) GetDelayBasedEstimate() int64 {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.delayEstimate
+}
+
+// GetLossBasedEstimate returns the loss-based bound in bits per second.
+// Returns (bound, true) if a bound is active, (0, false) otherwise.
+func (e *SendSideEstimator) GetLossBasedEstimate() (int64, bool) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.lossEstimate, e.lossEstimate > 0
+}
+
+// GetActiveBound returns which controller currently limits the target.
+func (e *SendSideEstimator) GetActiveBound() EstimateBound {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.activeBound
+}
+
+// GetAckedBitrate returns the acknowledged bitrate in bits per second.
+// Returns (rate, true) if available, (0, false) otherwise.
+func (e *SendSideEstimator) GetAckedBitrate() (int64, bool) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.ackedBitrate.Bitrate()
+}
+
+// GetCongestionState returns the current congestion state.
+func (e *SendSideEstimator) GetCongestionState() BandwidthUsage {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.delayEstimator.State()
+}
+
+// GetLossFraction returns the fraction of packets reported lost over the loss window.
+// Returns (fraction, true) if enough packets have been reported, (0, false) otherwise.
+func (e *SendSideEstimator) GetLossFraction() (float64, bool) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.lossController.FractionLost(e.clock.Now())
+}
+
+// GetDelayFilterOutput returns the most recent delay filter output in milliseconds.
+func (e *SendSideEstimator) GetDelayFilterOutput() float64 {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.delayEstimator.FilterOutput()
+}
+
+// GetThreshold returns the current adaptive overuse threshold in milliseconds.
+func (e *SendSideEstimator) GetThreshold() float64 {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.delayEstimator.Threshold()
+}
+
+// GetInterArrivalStats returns the reordering and clock-jump counters of the
+// delay-based estimator's packet grouping.
+func (e *SendSideEstimator) GetInterArrivalStats() InterArrivalStats {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.delayEstimator.InterArrivalStats()
+}
+
+// IsApplicationLimited returns whether the sender is currently
+// application-limited, in which case the estimate is held at the
+// last-known-good capacity.
+func (e *SendSideEstimator) IsApplicationLimited() bool {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.alrDetector.InALR()
+}
+
+// GetALRStartTime returns when the current application-limited period began.
+// Returns the zero time if the sender is not application-limited.
+func (e *SendSideEstimator) GetALRStartTime() time.Time {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.alrDetector.StartTime()
+}
+
+// GetLinkCapacity returns the estimated bottleneck capacity in bits per second,
+// learned from the incoming rate at each overuse.
+// Returns (capacity, true) if an estimate exists, (0, false) otherwise.
+func (e *SendSideEstimator) GetLinkCapacity() (int64, bool) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.rateController.LinkCapacity()
+}
+
+// GetKalmanCapacity returns the link capacity estimated by the two-state
+// Kalman filter, in bits per second, for comparison with the AIMD estimate.
+// Returns (capacity, true) only when the delay filter is FilterKalmanCapacity.
+func (e *SendSideEstimator) GetKalmanCapacity() (int64, bool) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.delayEstimator.KalmanCapacity()
+}
+
+// UpdateRTT feeds a round-trip time measurement to the rate controller,
+// which uses it to size additive increases and pace decreases.
+// Non-positive values are ignored.
+func (e *SendSideEstimator) UpdateRTT(rtt time.Duration) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.rateController.UpdateRTT(rtt)
+}
+
+// GetRTT returns the round-trip time used by the rate controller.
+// Until UpdateRTT is called this is the 200ms default.
+func (e *SendSideEstimator) GetRTT() time.Duration {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.rateController.RTT()
+}
+
+// GetRateControlState returns the current AIMD rate control state.
+func (e *SendSideEstimator) GetRateControlState() RateControlState {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.rateController.State()
+}
+
+// Reset resets the estimator to its initial state, including the send history.
+func (e *SendSideEstimator) Reset() {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.delayEstimator.Reset()
+	e.rateController.Reset()
+	e.lossController.Reset()
+	e.ackedBitrate.Reset()
+	e.alrDetector.Reset()
+	clear(e.history)
+	e.lastSentSeq = 0
+	e.hasSent = false
+	e.sendOrigin = time.Time{}
+	e.results = e.results[:0]
+	e.hasRefTime = false
+	e.refTimeCycles = 0
+	e.lastRefTime = 0
+	e.target = e.rateController.Estimate()
+	e.delayEstimate = e.target
+	e.lossEstimate = 0
+	e.activeBound = BoundDelay
+}
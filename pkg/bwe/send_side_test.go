@@ -0,0 +1,203 @@
+package bwe
+
+import (
+	"testing"
+	"time"
+
+	"github.com/thesyncim/bwe/pkg/bwe/clock"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// sendSideSim drives a SendSideEstimator with a simulated link.
+type sendSideSim struct {
+	clock    *clock.Virtual
+	e        *SendSideEstimator
+	seq      uint16
+	pending  []PacketFeedback
+	queueMs  float64 // Current one-way queuing delay in ms
+	sent     int
+	size     int // Packet size in bytes; 0 means 1200
+	dropNext func(i int) bool
+}
+
+func newSendSideSim(config SendSideConfig, startSeq uint16) *sendSideSim {
+	clock := clock.NewVirtual(time.Time{})
+	return &sendSideSim{
+		clock: clock,
+		e:     NewSendSideEstimator(config, clock),
+		seq:   startSeq,
+	}
+}
+
+// run sends one packet (1200 bytes unless size is set) every 10ms for the given duration, adding
+// queueGrowthMs of queuing delay per packet, and delivers feedback every 100ms.
+func (s *sendSideSim) run(d time.Duration, queueGrowthMs float64) int64 {
+	remoteBase := time.Unix(0, 0)
+	var target int64
+	for elapsed := time.Duration(0); elapsed < d; elapsed += 10 * time.Millisecond {
+		now := s.clock.Now()
+		size := s.size
+		if size == 0 {
+			size = 1200
+		}
+		s.e.OnPacketSent(SentPacket{
+			TransportSequenceNumber: s.seq,
+			SendTime:                now,
+			Size:                    size,
+			SSRC:                    0x1234,
+		})
+
+		s.queueMs += queueGrowthMs
+		fb := PacketFeedback{TransportSequenceNumber: s.seq}
+		if s.dropNext == nil || !s.dropNext(s.sent) {
+			fb.Received = true
+			fb.ArrivalTime = remoteBase.Add(now.Sub(time.Unix(1000000000, 0)) +
+				20*time.Millisecond + time.Duration(s.queueMs*float64(time.Millisecond)))
+		}
+		s.pending = append(s.pending, fb)
+		s.seq++
+		s.sent++
+
+		s.clock.Advance(10 * time.Millisecond)
+		if len(s.pending) == 10 {
+			target = s.e.OnFeedback(s.pending, s.clock.Now())
+			s.pending = s.pending[:0]
+		}
+	}
+	return target
+}
+
+func TestSendSideEstimator_InitialTarget(t *testing.T) {
+	config := DefaultSendSideConfig()
+	e := NewSendSideEstimator(config, nil)
+	assert.Equal(t, config.RateControllerConfig.InitialBitrate, e.TargetBitrate())
+}
+
+func TestSendSideEstimator_StableLink(t *testing.T) {
+	sim := newSendSideSim(DefaultSendSideConfig(), 0)
+	target := sim.run(5*time.Second, 0)
+
+	acked, ok := sim.e.GetAckedBitrate()
+	require.True(t, ok, "acked bitrate should be available")
+	assert.InDelta(t, 960_000, acked, 100_000, "acked bitrate should match the send rate")
+
+	assert.Equal(t, BwNormal, sim.e.GetCongestionState())
+	assert.GreaterOrEqual(t, target, DefaultRateControllerConfig().InitialBitrate,
+		"stable link should not decrease the target")
+}
+
+func TestSendSideEstimator_Congestion(t *testing.T) {
+	sim := newSendSideSim(DefaultSendSideConfig(), 0)
+	before := sim.run(3*time.Second, 0)
+
+	// Queue grows 50ms per packet: heavy congestion
+	after := sim.run(2*time.Second, 50)
+
+	assert.Less(t, after, before, "growing queuing delay should reduce the target")
+	assert.Equal(t, BoundDelay, sim.e.GetActiveBound())
+}
+
+func TestSendSideEstimator_LossBound(t *testing.T) {
+	sim := newSendSideSim(DefaultSendSideConfig(), 0)
+	sim.dropNext = func(i int) bool { return i%4 == 0 } // 25% loss
+
+	sim.run(4*time.Second, 0)
+
+	_, ok := sim.e.GetLossBasedEstimate()
+	require.True(t, ok, "loss-based bound should be active")
+	assert.Equal(t, BoundLoss, sim.e.GetActiveBound())
+	assert.Less(t, sim.e.TargetBitrate(), sim.e.GetDelayBasedEstimate())
+}
+
+func TestSendSideEstimator_SequenceWrap(t *testing.T) {
+	sim := newSendSideSim(DefaultSendSideConfig(), 65000)
+	sim.run(3*time.Second, 0) // 300 packets: wraps past 65535
+
+	acked, ok := sim.e.GetAckedBitrate()
+	require.True(t, ok, "feedback across the wrap should be matched")
+	assert.Greater(t, acked, int64(0))
+	assert.Equal(t, BwNormal, sim.e.GetCongestionState())
+}
+
+func TestSendSideEstimator_IgnoresUnknownFeedback(t *testing.T) {
+	clock := clock.NewVirtual(time.Time{})
+	e := NewSendSideEstimator(DefaultSendSideConfig(), clock)
+
+	// Feedback before anything was sent is ignored
+	target := e.OnFeedback([]PacketFeedback{
+		{TransportSequenceNumber: 1, Received: true, ArrivalTime: clock.Now()},
+	}, clock.Now())
+	assert.Equal(t, DefaultRateControllerConfig().InitialBitrate, target)
+
+	e.OnPacketSent(SentPacket{TransportSequenceNumber: 10, SendTime: clock.Now(), Size: 1200})
+
+	// Feedback for a sequence number that was never sent is ignored
+	e.OnFeedback([]PacketFeedback{
+		{TransportSequenceNumber: 20, Received: true, ArrivalTime: clock.Now()},
+	}, clock.Now())
+	_, ok := e.GetAckedBitrate()
+	assert.False(t, ok, "unknown packets should not count as acknowledged")
+}
+
+func TestSendSideEstimator_ApplicationLimited(t *testing.T) {
+	sim := newSendSideSim(DefaultSendSideConfig(), 0)
+	busy := sim.run(10*time.Second, 0)
+	require.False(t, sim.e.IsApplicationLimited())
+
+	// Sender falls to a trickle: 100 bytes every 10ms = 80 kbps
+	sim.size = 100
+	held := sim.run(5*time.Second, 0)
+
+	assert.True(t, sim.e.IsApplicationLimited())
+	assert.False(t, sim.e.GetALRStartTime().IsZero())
+	assert.InDelta(t, busy, held, float64(busy)*0.1, "target should hold the last-known-good capacity")
+}
+
+func TestSendSideEstimator_Reset(t *testing.T) {
+	sim := newSendSideSim(DefaultSendSideConfig(), 0)
+	sim.run(2*time.Second, 0)
+
+	sim.e.Reset()
+
+	assert.Equal(t, DefaultRateControllerConfig().InitialBitrate, sim.e.TargetBitrate())
+	_, ok := sim.e.GetAckedBitrate()
+	assert.False(t, ok, "reset should clear the acknowledged bitrate")
+	assert.Equal(t, RateHold, sim.e.GetRateControlState())
+}
+
+func TestAckedBitrateEstimator(t *testing.T) {
+	a := NewAckedBitrateEstimator(DefaultAckedBitrateConfig())
+	base := time.Unix(0, 0)
+
+	_, ok := a.Bitrate()
+	assert.False(t, ok, "no rate before any packets")
+
+	// 1000 bytes every 10ms = 800 kbps
+	for i := 0; i < 100; i++ {
+		a.OnPacketAcked(1000, base.Add(time.Duration(i)*10*time.Millisecond))
+	}
+
+	rate, ok := a.Bitrate()
+	require.True(t, ok)
+	assert.InDelta(t, 800_000, rate, 50_000)
+
+	// Out-of-order arrival does not move time backwards
+	a.OnPacketAcked(1000, base)
+	rate, ok = a.Bitrate()
+	require.True(t, ok)
+	assert.Greater(t, rate, int64(0))
+}
+
+func TestDurationToAbsSendTime(t *testing.T) {
+	assert.Equal(t, uint32(0), DurationToAbsSendTime(0))
+	assert.Equal(t, uint32(1<<18), DurationToAbsSendTime(time.Second))
+
+	// Wraps at 64 seconds
+	assert.Equal(t, uint32(1<<18), DurationToAbsSendTime(65*time.Second))
+
+	// Round-trips within the 64-second range
+	d := 12*time.Second + 345*time.Millisecond
+	assert.InDelta(t, float64(d), float64(AbsSendTimeToDuration(DurationToAbsSendTime(d))), float64(5*time.Microsecond))
+}
@@ -0,0 +1,271 @@
+package bwe
+
+import (
+	"math"
+	"time"
+)
+
+// DefaultSilenceTimeout is how long an SSRC or the whole session may go
+// without packets before it is considered silent (libwebrtc's
+// kStreamTimeOutMs).
+const DefaultSilenceTimeout = 2 * time.Second
+
+// SilenceConfig configures how BandwidthEstimator handles mute, silence and
+// long gaps between packets.
+type SilenceConfig struct {
+	// Enabled turns silence handling on. After a session gap the delay
+	// pipeline, probe and ALR state are reset, since abs-send-time deltas
+	// across more than 32s are ambiguous; the estimate is kept.
+	// Default: true
+	Enabled bool
+
+	// StreamTimeout is how long one SSRC may go without packets before it
+	// is reported idle.
+	// Default: 2s
+	StreamTimeout time.Duration
+
+	// SessionTimeout is how long the session may go without packets from
+	// any SSRC before it is idle and the next packet restarts the delay
+	// pipeline.
+	// Default: 2s
+	SessionTimeout time.Duration
+
+	// DecayAfter is how long the session must be idle before the estimate
+	// starts to decay toward the initial bitrate. Zero keeps the estimate
+	// for any gap.
+	// Default: 0 (disabled)
+	DecayAfter time.Duration
+
+	// DecayHalfLife is the time in which the decaying estimate halves its
+	// distance to the initial bitrate.
+	// Default: 10s
+	DecayHalfLife time.Duration
+}
+
+// DefaultSilenceConfig returns the default silence configuration.
+func DefaultSilenceConfig() SilenceConfig {
+	return SilenceConfig{
+		Enabled:        true,
+		StreamTimeout:  DefaultSilenceTimeout,
+		SessionTimeout: DefaultSilenceTimeout,
+		DecayAfter:     0,
+		DecayHalfLife:  10 * time.Second,
+	}
+}
+
+// withDefaults replaces zero durations with defaults.
+func (c SilenceConfig) withDefaults() SilenceConfig {
+	defaults := DefaultSilenceConfig()
+	if c.StreamTimeout <= 0 {
+		c.StreamTimeout = defaults.StreamTimeout
+	}
+	if c.SessionTimeout <= 0 {
+		c.SessionTimeout = defaults.SessionTimeout
+	}
+	if c.DecayHalfLife <= 0 {
+		c.DecayHalfLife = defaults.DecayHalfLife
+	}
+	return c
+}
+
+// SilenceEventType identifies a silence transition.
+type SilenceEventType int
+
+const (
+	// SilenceStreamIdle means an SSRC sent nothing for StreamTimeout.
+	SilenceStreamIdle SilenceEventType = iota
+
+	// SilenceStreamResumed means an idle SSRC sent a packet again.
+	SilenceStreamResumed
+
+	// SilenceSessionIdle means no SSRC sent anything for SessionTimeout.
+	SilenceSessionIdle
+
+	// SilenceSessionResumed means a packet arrived after a session gap and
+	// the delay pipeline was restarted.
+	SilenceSessionResumed
+
+	// SilenceDecayStarted means the session was idle for DecayAfter and
+	// the estimate started to decay.
+	SilenceDecayStarted
+)
+
+// String returns a string representation of the SilenceEventType.
+func (t SilenceEventType) String() string {
+	switch t {
+	case SilenceStreamIdle:
+		return "StreamIdle"
+	case SilenceStreamResumed:
+		return "StreamResumed"
+	case SilenceSessionIdle:
+		return "SessionIdle"
+	case SilenceSessionResumed:
+		return "SessionResumed"
+	case SilenceDecayStarted:
+		return "DecayStarted"
+	default:
+		return "Unknown"
+	}
+}
+
+// SilenceEvent describes a silence transition.
+type SilenceEvent struct {
+	// Type is the kind of transition.
+	Type SilenceEventType
+
+	// SSRC is the stream for stream events, zero for session events.
+	SSRC uint32
+
+	// Time is when the transition was detected.
+	Time time.Time
+
+	// Gap is how long the stream or session had been without packets.
+	Gap time.Duration
+
+	// Estimate is the bandwidth estimate after the transition, in bps.
+	Estimate int64
+}
+
+// SilenceCallback is invoked on each silence transition. It is called
+// without the estimator's lock held, so it may query the estimator.
+type SilenceCallback func(event SilenceEvent)
+
+// silenceState is the session-level silence state of BandwidthEstimator.
+type silenceState struct {
+	lastArrival  time.Time
+	idle         bool
+	decaying     bool
+	heldEstimate int64 // Estimate when the session went idle
+
+	callback SilenceCallback
+	events   []SilenceEvent // Pending, delivered after unlocking
+}
+
+// CheckIdle detects idle SSRCs and an idle session at now, decays the
+// estimate if SilenceConfig.DecayAfter is set, and removes SSRCs silent for
+// SSRCConfig.Timeout. Call it periodically (e.g.
+// before each REMB) so silence is noticed while no packets arrive; OnPacket
+// checks on its own when packets resume.
+//
+// Returns the current bandwidth estimate in bits per second.
+func (e *BandwidthEstimator) CheckIdle(now time.Time) int64 {
+	e.mu.Lock()
+	e.sweep(now)
+	estimate := e.estimate
+	cb, events := e.takeSilenceEvents()
+	e.mu.Unlock()
+
+	for _, event := range events {
+		cb(event)
+	}
+	return estimate
+}
+
+// SetSilenceCallback registers a callback for silence transitions.
+// Pass nil to disable callbacks.
+func (e *BandwidthEstimator) SetSilenceCallback(cb SilenceCallback) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.silence.callback = cb
+}
+
+// checkIdle marks SSRCs and the session idle and applies decay.
+// Must be called with mu held.
+func (e *BandwidthEstimator) checkIdle(now time.Time) {
+	for ssrc, activity := range e.ssrcs {
+		if gap := now.Sub(activity.lastPacket); !activity.idle && gap >= e.silenceConfig.StreamTimeout {
+			activity.idle = true
+			e.ssrcs[ssrc] = activity
+			e.addSilenceEvent(SilenceStreamIdle, ssrc, now, gap)
+		}
+	}
+
+	s := &e.silence
+	if s.lastArrival.IsZero() {
+		return
+	}
+	gap := now.Sub(s.lastArrival)
+	if !s.idle && gap >= e.silenceConfig.SessionTimeout {
+		s.idle = true
+		s.heldEstimate = e.estimate
+		e.addSilenceEvent(SilenceSessionIdle, 0, now, gap)
+	}
+	if s.idle && e.silenceConfig.DecayAfter > 0 && gap >= e.silenceConfig.DecayAfter {
+		e.decay(gap-e.silenceConfig.DecayAfter, now)
+		if !s.decaying {
+			s.decaying = true
+			e.addSilenceEvent(SilenceDecayStarted, 0, now, gap)
+		}
+	}
+}
+
+// decay moves the estimate from the held estimate toward the initial
+// bitrate (never up), halving the distance every DecayHalfLife. The learned
+// link capacity is dropped, as it may no longer hold.
+// Must be called with mu held.
+func (e *BandwidthEstimator) decay(elapsed time.Duration, now time.Time) {
+	held := e.silence.heldEstimate
+	floor := min(held, e.config.RateControllerConfig.InitialBitrate)
+	factor := math.Pow(0.5, elapsed.Seconds()/e.silenceConfig.DecayHalfLife.Seconds())
+	estimate := floor + int64(float64(held-floor)*factor)
+
+	old := e.estimate
+	e.delayEstimate = e.rateController.restartAt(estimate)
+	e.estimate = e.delayEstimate
+	e.activeBound = BoundDelay
+	e.lossEstimate = 0
+	e.observeRateState(now)
+	e.observeEstimate(old, now)
+}
+
+// onSilencePacket updates the silence state for a packet, restarting the
+// delay pipeline after a session gap and reporting resumed SSRCs.
+// Must be called with mu held.
+func (e *BandwidthEstimator) onSilencePacket(pkt PacketInfo) {
+	now := pkt.ArrivalTime
+	s := &e.silence
+
+	// Catch up on idle transitions before resuming
+	if !s.lastArrival.IsZero() && now.Sub(s.lastArrival) >= e.silenceConfig.SessionTimeout {
+		e.checkIdle(now)
+	}
+
+	if s.idle {
+		e.delayEstimator.Reset()
+		e.probeDetector.Reset()
+		e.resetSendRate()
+		e.addSilenceEvent(SilenceSessionResumed, 0, now, now.Sub(s.lastArrival))
+		s.idle = false
+		s.decaying = false
+	}
+	if now.After(s.lastArrival) {
+		s.lastArrival = now
+	}
+
+	if activity, ok := e.ssrcs[pkt.SSRC]; ok && activity.idle {
+		e.addSilenceEvent(SilenceStreamResumed, pkt.SSRC, now, now.Sub(activity.lastPacket))
+	}
+}
+
+// addSilenceEvent queues an event for the callback.
+// Must be called with mu held.
+func (e *BandwidthEstimator) addSilenceEvent(t SilenceEventType, ssrc uint32, now time.Time, gap time.Duration) {
+	if e.silence.callback == nil {
+		return
+	}
+	e.silence.events = append(e.silence.events, SilenceEvent{
+		Type:     t,
+		SSRC:     ssrc,
+		Time:     now,
+		Gap:      gap,
+		Estimate: e.estimate,
+	})
+}
+
+// takeSilenceEvents returns the callback and the pending events, and clears
+// them. Must be called with mu held; deliver the events after unlocking.
+func (e *BandwidthEstimator) takeSilenceEvents() (SilenceCallback, []SilenceEvent) {
+	events := e.silence.events
+	e.silence.events = nil
+	return e.silence.callback, events
+}
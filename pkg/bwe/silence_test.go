@@ -0,0 +1,103 @@
+package bwe
+
+import (
+	"testing"
+	"time"
+
+	"github.com/thesyncim/bwe/pkg/bwe/clock"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// silenceRun feeds 1 Mbps of stable traffic on SSRC 1 between from and to
+// (in ms since start) into e.
+func silenceRun(e *BandwidthEstimator, clock *clock.Virtual, start time.Time, from, to int) {
+	for ms := from; ms < to; ms += 10 {
+		arrival := start.Add(time.Duration(ms) * time.Millisecond)
+		clock.Set(arrival)
+		e.OnPacket(PacketInfo{ArrivalTime: arrival, SendTime: msToAbsSendTime(ms), Size: 1250, SSRC: 1})
+	}
+}
+
+func TestBandwidthEstimator_MuteKeepsEstimate(t *testing.T) {
+	start := time.Unix(0, 0)
+	clock := clock.NewVirtual(start)
+	e := NewBandwidthEstimator(DefaultBandwidthEstimatorConfig(), clock)
+
+	var events []SilenceEvent
+	e.SetSilenceCallback(func(ev SilenceEvent) {
+		events = append(events, ev)
+		e.GetEstimate() // Callbacks run without the lock held
+	})
+
+	silenceRun(e, clock, start, 0, 20_000)
+	before := e.GetEstimate()
+	require.Empty(t, events)
+
+	// 40s of mute: abs-send-time wraps past half its range, so the send
+	// delta across the gap is ambiguous
+	silenceRun(e, clock, start, 60_000, 60_500)
+
+	var types []SilenceEventType
+	for _, ev := range events {
+		types = append(types, ev.Type)
+	}
+	assert.Equal(t, []SilenceEventType{SilenceStreamIdle, SilenceSessionIdle, SilenceSessionResumed, SilenceStreamResumed}, types)
+	assert.Equal(t, uint32(1), events[3].SSRC)
+	assert.InDelta(t, 40*time.Second, events[2].Gap, float64(20*time.Millisecond))
+
+	assert.Equal(t, BwNormal, e.GetCongestionState(), "no bogus delay sample across the gap")
+	assert.GreaterOrEqual(t, e.GetEstimate(), before, "estimate is kept across the gap")
+}
+
+func TestBandwidthEstimator_SilenceDecay(t *testing.T) {
+	start := time.Unix(0, 0)
+	clock := clock.NewVirtual(start)
+	config := DefaultBandwidthEstimatorConfig()
+	config.SilenceConfig.DecayAfter = 5 * time.Second
+	config.SilenceConfig.DecayHalfLife = 10 * time.Second
+	e := NewBandwidthEstimator(config, clock)
+
+	var events []SilenceEvent
+	e.SetSilenceCallback(func(ev SilenceEvent) { events = append(events, ev) })
+
+	silenceRun(e, clock, start, 0, 20_000)
+	held := e.GetEstimate()
+	initial := config.RateControllerConfig.InitialBitrate
+	require.Greater(t, held, initial)
+	last := start.Add(20 * time.Second)
+
+	assert.Equal(t, held, e.CheckIdle(last.Add(time.Second)), "not idle yet")
+	assert.Equal(t, held, e.CheckIdle(last.Add(3*time.Second)), "idle, but decay not started")
+	require.Len(t, events, 2)
+	assert.Equal(t, SilenceSessionIdle, events[1].Type)
+
+	// One half-life after decay starts, halfway to the initial bitrate
+	decayed := e.CheckIdle(last.Add(15 * time.Second))
+	assert.InEpsilon(t, initial+(held-initial)/2, decayed, 0.01)
+	assert.Equal(t, SilenceDecayStarted, events[2].Type)
+	assert.Equal(t, decayed, e.GetDelayBasedEstimate())
+
+	// Traffic resumes from the decayed estimate. SSRC 1 expired after
+	// SSRCConfig.Timeout, so it comes back as a new stream.
+	silenceRun(e, clock, start, 36_000, 36_100)
+	assert.Equal(t, SilenceSessionResumed, events[len(events)-1].Type)
+	assert.LessOrEqual(t, e.GetEstimate(), decayed*2)
+}
+
+func TestBandwidthEstimator_SilenceDisabled(t *testing.T) {
+	start := time.Unix(0, 0)
+	clock := clock.NewVirtual(start)
+	config := DefaultBandwidthEstimatorConfig()
+	config.SilenceConfig = SilenceConfig{Enabled: false, DecayAfter: time.Second}
+	e := NewBandwidthEstimator(config, clock)
+
+	called := false
+	e.SetSilenceCallback(func(SilenceEvent) { called = true })
+
+	silenceRun(e, clock, start, 0, 5_000)
+	held := e.GetEstimate()
+	assert.Equal(t, held, e.CheckIdle(start.Add(60*time.Second)))
+	assert.False(t, called)
+}
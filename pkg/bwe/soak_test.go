@@ -8,7 +8,7 @@ import (
 	"testing"
 	"time"
 
-	"github.com/thesyncim/bwe/pkg/bwe/internal"
+	"github.com/thesyncim/bwe/pkg/bwe/clock"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -19,7 +19,7 @@ import (
 // =============================================================================
 
 // TestSoak24Hour_Accelerated simulates 24 hours of traffic in accelerated time.
-// Uses MockClock to simulate time progression without waiting.
+// Uses a virtual clock to simulate time progression without waiting.
 //
 // This test verifies VALID-04:
 //   - No timestamp-related failures (abs-send-time wraps every 64 seconds)
@@ -48,7 +48,7 @@ func TestSoak24Hour_Accelerated(t *testing.T) {
 	)
 
 	// Initialize estimator with mock clock
-	clock := internal.NewMockClock(time.Now())
+	clock := clock.NewVirtual(time.Now())
 	config := DefaultBandwidthEstimatorConfig()
 	estimator := NewBandwidthEstimator(config, clock)
 
@@ -159,7 +159,7 @@ func TestSoak1Hour_Accelerated(t *testing.T) {
 		absSendTimeUnitsPerMs = 262
 	)
 
-	clock := internal.NewMockClock(time.Now())
+	clock := clock.NewVirtual(time.Now())
 	estimator := NewBandwidthEstimator(DefaultBandwidthEstimatorConfig(), clock)
 
 	sendTime := uint32(0)
@@ -201,7 +201,7 @@ func TestSoak1Hour_Accelerated(t *testing.T) {
 // TestTimestampWraparound_64Seconds tests the estimator behavior across
 // the 64-second abs-send-time boundary (24-bit field wraps at 2^24).
 func TestTimestampWraparound_64Seconds(t *testing.T) {
-	clock := internal.NewMockClock(time.Now())
+	clock := clock.NewVirtual(time.Now())
 	estimator := NewBandwidthEstimator(DefaultBandwidthEstimatorConfig(), clock)
 
 	const (
@@ -272,7 +272,7 @@ func TestTimestampWraparound_64Seconds(t *testing.T) {
 // TestTimestampWraparound_MultipleWraps tests the estimator across multiple
 // wraparound cycles (10 cycles = 640 seconds = ~10.7 minutes).
 func TestTimestampWraparound_MultipleWraps(t *testing.T) {
-	clock := internal.NewMockClock(time.Now())
+	clock := clock.NewVirtual(time.Now())
 	estimator := NewBandwidthEstimator(DefaultBandwidthEstimatorConfig(), clock)
 
 	const (
@@ -345,7 +345,7 @@ func TestTimestampWraparound_MultipleWraps(t *testing.T) {
 // the timestamp wraparound boundary.
 func TestTimestampWraparound_EdgeCases(t *testing.T) {
 	t.Run("ExactMaxValue", func(t *testing.T) {
-		clock := internal.NewMockClock(time.Now())
+		clock := clock.NewVirtual(time.Now())
 		estimator := NewBandwidthEstimator(DefaultBandwidthEstimatorConfig(), clock)
 
 		// Warm up the estimator
@@ -388,7 +388,7 @@ func TestTimestampWraparound_EdgeCases(t *testing.T) {
 	})
 
 	t.Run("LargeGapAcrossWraparound", func(t *testing.T) {
-		clock := internal.NewMockClock(time.Now())
+		clock := clock.NewVirtual(time.Now())
 		estimator := NewBandwidthEstimator(DefaultBandwidthEstimatorConfig(), clock)
 
 		// Warm up
@@ -434,7 +434,7 @@ func TestTimestampWraparound_EdgeCases(t *testing.T) {
 	})
 
 	t.Run("ZeroAfterMax", func(t *testing.T) {
-		clock := internal.NewMockClock(time.Now())
+		clock := clock.NewVirtual(time.Now())
 		estimator := NewBandwidthEstimator(DefaultBandwidthEstimatorConfig(), clock)
 
 		// Process packets leading up to max
@@ -469,7 +469,7 @@ func TestTimestampWraparound_EdgeCases(t *testing.T) {
 // TestTimestampWraparound_ContinuousMonitoring runs a longer test that
 // monitors for any suspicious behavior at wraparound points.
 func TestTimestampWraparound_ContinuousMonitoring(t *testing.T) {
-	clock := internal.NewMockClock(time.Now())
+	clock := clock.NewVirtual(time.Now())
 	estimator := NewBandwidthEstimator(DefaultBandwidthEstimatorConfig(), clock)
 
 	const (
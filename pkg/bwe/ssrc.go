@@ -0,0 +1,114 @@
+package bwe
+
+import (
+	"slices"
+	"time"
+)
+
+// DefaultSSRCTimeout is how long an SSRC may go without packets before the
+// estimator forgets it.
+const DefaultSSRCTimeout = 10 * time.Second
+
+// DefaultMaxSSRCs bounds the SSRCs tracked by the estimator. A REMB packet
+// carries its SSRC count in one byte, so it cannot list more than 255.
+const DefaultMaxSSRCs = 255
+
+// SSRCConfig configures which SSRCs the estimator tracks and lists in REMB.
+type SSRCConfig struct {
+	// Timeout is how long an SSRC may go without packets before it is
+	// removed from the estimator and from REMB.
+	// Default: 10s
+	Timeout time.Duration
+
+	// MaxSSRCs caps the number of tracked SSRCs. A new SSRC beyond the cap
+	// replaces the one that has been silent longest.
+	// Default: 255
+	MaxSSRCs int
+}
+
+// DefaultSSRCConfig returns the default SSRC tracking configuration.
+func DefaultSSRCConfig() SSRCConfig {
+	return SSRCConfig{
+		Timeout:  DefaultSSRCTimeout,
+		MaxSSRCs: DefaultMaxSSRCs,
+	}
+}
+
+// withDefaults replaces zero values with defaults.
+func (c SSRCConfig) withDefaults() SSRCConfig {
+	if c.Timeout <= 0 {
+		c.Timeout = DefaultSSRCTimeout
+	}
+	if c.MaxSSRCs <= 0 {
+		c.MaxSSRCs = DefaultMaxSSRCs
+	}
+	return c
+}
+
+// ssrcActivity tracks when an SSRC last sent a packet and how much it sent.
+type ssrcActivity struct {
+	lastPacket time.Time
+	idle       bool
+	packets    int64
+	bytes      int64
+}
+
+// RemoveSSRC stops tracking an SSRC, e.g. when its stream is unbound. It is
+// no longer listed in REMB and its loss statistics are dropped; a later
+// packet adds it back.
+func (e *BandwidthEstimator) RemoveSSRC(ssrc uint32) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.removeSSRC(ssrc)
+}
+
+// removeSSRC forgets an SSRC. Must be called with mu held.
+func (e *BandwidthEstimator) removeSSRC(ssrc uint32) {
+	delete(e.ssrcs, ssrc)
+	e.lossController.RemoveSSRC(ssrc)
+}
+
+// trackSSRC records a packet of size bytes on its SSRC and marks the SSRC
+// live, evicting the SSRC silent the longest if the cap is reached.
+// Must be called with mu held.
+func (e *BandwidthEstimator) trackSSRC(ssrc uint32, size int, now time.Time) {
+	activity, ok := e.ssrcs[ssrc]
+	if !ok && len(e.ssrcs) >= e.ssrcConfig.MaxSSRCs {
+		var (
+			oldest     uint32
+			oldestTime time.Time
+			found      bool
+		)
+		for s, other := range e.ssrcs {
+			if !found || other.lastPacket.Before(oldestTime) {
+				oldest, oldestTime, found = s, other.lastPacket, true
+			}
+		}
+		e.removeSSRC(oldest)
+	}
+	activity.lastPacket = now
+	activity.idle = false
+	activity.packets++
+	activity.bytes += int64(size)
+	e.ssrcs[ssrc] = activity
+}
+
+// pruneSSRCs removes SSRCs silent for Timeout. Must be called with mu held.
+func (e *BandwidthEstimator) pruneSSRCs(now time.Time) {
+	for ssrc, activity := range e.ssrcs {
+		if now.Sub(activity.lastPacket) >= e.ssrcConfig.Timeout {
+			e.removeSSRC(ssrc)
+		}
+	}
+}
+
+// sortedSSRCs returns the tracked SSRCs in ascending order.
+// Must be called with mu held.
+func (e *BandwidthEstimator) sortedSSRCs() []uint32 {
+	ssrcs := make([]uint32, 0, len(e.ssrcs))
+	for ssrc := range e.ssrcs {
+		ssrcs = append(ssrcs, ssrc)
+	}
+	slices.Sort(ssrcs)
+	return ssrcs
+}
@@ -0,0 +1,62 @@
+package bwe
+
+import (
+	"testing"
+	"time"
+
+	"github.com/thesyncim/bwe/pkg/bwe/clock"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBandwidthEstimator_SSRCExpiry(t *testing.T) {
+	start := time.Unix(0, 0)
+	clock := clock.NewVirtual(start)
+	e := NewBandwidthEstimator(DefaultBandwidthEstimatorConfig(), clock)
+	e.SetREMBScheduler(NewREMBScheduler(DefaultREMBSchedulerConfig()))
+
+	// SSRC 7 sends for 1s, SSRCs 3 and 5 keep going
+	for ms := 0; ms < 15_000; ms += 20 {
+		arrival := start.Add(time.Duration(ms) * time.Millisecond)
+		clock.Set(arrival)
+		for _, ssrc := range []uint32{5, 7, 3} {
+			if ssrc == 7 && ms >= 1000 {
+				continue
+			}
+			e.OnPacket(PacketInfo{ArrivalTime: arrival, SendTime: msToAbsSendTime(ms), Size: 1200, SSRC: ssrc})
+		}
+	}
+	assert.Equal(t, []uint32{3, 5}, e.GetSSRCs(), "silent SSRC expires, live ones are sorted")
+
+	data, sent, err := e.MaybeBuildREMB(clock.Now())
+	require.NoError(t, err)
+	require.True(t, sent)
+	remb, err := ParseREMB(data)
+	require.NoError(t, err)
+	assert.Equal(t, []uint32{3, 5}, remb.SSRCs)
+
+	e.RemoveSSRC(3)
+	assert.Equal(t, []uint32{5}, e.GetSSRCs())
+
+	// Everything silent: no REMB lists stale SSRCs
+	_, sent, _ = e.MaybeBuildREMB(clock.Now().Add(time.Minute))
+	assert.False(t, sent)
+}
+
+func TestBandwidthEstimator_MaxSSRCs(t *testing.T) {
+	start := time.Unix(0, 0)
+	config := DefaultBandwidthEstimatorConfig()
+	config.SSRCConfig.MaxSSRCs = 3
+	e := NewBandwidthEstimator(config, clock.NewVirtual(start))
+
+	for i := 0; i < 10; i++ {
+		e.OnPacket(PacketInfo{
+			ArrivalTime: start.Add(time.Duration(i) * time.Millisecond),
+			SendTime:    msToAbsSendTime(i),
+			Size:        1200,
+			SSRC:        uint32(100 + i),
+		})
+	}
+	assert.Equal(t, []uint32{107, 108, 109}, e.GetSSRCs(), "the SSRCs silent longest are evicted")
+}
@@ -0,0 +1,230 @@
+package bwe
+
+import (
+	"slices"
+	"time"
+)
+
+// rembHistorySize is the number of recent REMBs kept for Stats.
+const rembHistorySize = 10
+
+// Stats is a consistent snapshot of the BandwidthEstimator's state, taken
+// under a single lock. Values that are not yet known are zero.
+type Stats struct {
+	// Time is when the snapshot was taken, from the estimator's clock.
+	Time time.Time
+
+	// Estimate is the final estimate in bps.
+	Estimate int64
+
+	// DelayBasedEstimate is the AIMD estimate in bps, before the loss-based
+	// bound is applied.
+	DelayBasedEstimate int64
+
+	// LossBasedEstimate is the loss-based bound in bps, 0 when no bound is
+	// active.
+	LossBasedEstimate int64
+
+	// ActiveBound is the estimate that limits Estimate.
+	ActiveBound EstimateBound
+
+	// CongestionState is the delay-based usage state.
+	CongestionState BandwidthUsage
+
+	// RateControlState is the AIMD state.
+	RateControlState RateControlState
+
+	// IncomingRate is the measured incoming bitrate in bps, 0 until enough
+	// data has been seen.
+	IncomingRate int64
+
+	// LinkCapacity is the capacity learned at overuse in bps, 0 if none.
+	LinkCapacity int64
+
+	// ApplicationLimited reports whether the sender is application-limited.
+	ApplicationLimited bool
+
+	// LossFraction is the fraction of packets lost over the loss window.
+	LossFraction float64
+
+	// RTT is the round-trip time used by the rate controller.
+	RTT time.Duration
+
+	// LastPacketTime is the arrival time of the last processed packet.
+	LastPacketTime time.Time
+
+	// Delay holds the delay-based detector's internals.
+	Delay DelayStats
+
+	// SSRCs holds the counters of the live SSRCs, in ascending SSRC order.
+	SSRCs []SSRCStats
+
+	// REMB holds the REMBs built by MaybeBuildREMB.
+	REMB REMBStats
+}
+
+// DelayStats is a snapshot of the delay-based detector. With per-stream
+// detection, values come from the stream that determined the combined state
+// and counters are summed over all streams.
+type DelayStats struct {
+	// FilterOutput is the most recent delay filter output in milliseconds.
+	// See DelayEstimator.FilterOutput.
+	FilterOutput float64
+
+	// Threshold is the adaptive overuse threshold in milliseconds.
+	Threshold float64
+
+	// NoiseVariance is the Kalman filter's measurement noise variance in
+	// ms², 0 with the trendline filter.
+	NoiseVariance float64
+
+	// TrendlineSlope is the trendline's fitted slope before scaling, 0 with
+	// the Kalman filters.
+	TrendlineSlope float64
+
+	// KalmanCapacity is the two-state Kalman capacity estimate in bps, 0
+	// unless FilterType is FilterKalmanCapacity.
+	KalmanCapacity int64
+
+	// ClockSkewPPM is the estimated sender/receiver clock skew, 0 until
+	// known or when drift compensation is disabled.
+	ClockSkewPPM float64
+
+	// Groups is the number of packet groups completed.
+	Groups int64
+
+	// InterArrival holds the reordering and clock-jump counters.
+	InterArrival InterArrivalStats
+}
+
+// SSRCStats holds the counters of one live SSRC. Counters restart when an
+// SSRC expires or is removed and later sends again.
+type SSRCStats struct {
+	SSRC uint32
+
+	// Packets and Bytes count the packets processed, excluding those
+	// ignored by the packet policy.
+	Packets int64
+	Bytes   int64
+
+	// LastPacket is the arrival time of the SSRC's last packet.
+	LastPacket time.Time
+
+	// Idle reports whether silence detection marked the SSRC idle.
+	Idle bool
+}
+
+// REMBStats describes the REMBs built by the estimator.
+type REMBStats struct {
+	// Sent is the number of REMBs built.
+	Sent int64
+
+	// LastBitrate and LastTime are the bitrate and time of the last REMB,
+	// zero if none was built.
+	LastBitrate int64
+	LastTime    time.Time
+
+	// History holds the most recent REMBs, oldest first.
+	History []REMBEvent
+}
+
+// Stats returns a snapshot of the estimator's state. All values are read
+// under one lock, so they are consistent with each other.
+func (e *BandwidthEstimator) Stats() Stats {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	now := e.clock.Now()
+	s := Stats{
+		Time:               now,
+		Estimate:           e.estimate,
+		DelayBasedEstimate: e.delayEstimate,
+		LossBasedEstimate:  e.lossEstimate,
+		ActiveBound:        e.activeBound,
+		CongestionState:    e.delayEstimator.State(),
+		RateControlState:   e.rateController.State(),
+		ApplicationLimited: e.alrDetector.InALR(),
+		RTT:                e.rateController.RTT(),
+		LastPacketTime:     e.lastPacketTime,
+		Delay:              e.delayEstimator.Stats(),
+		REMB: REMBStats{
+			Sent:    e.rembSent,
+			History: slices.Clone(e.rembHistory),
+		},
+	}
+	s.IncomingRate, _ = e.rateStats.Rate(now)
+	s.LinkCapacity, _ = e.rateController.LinkCapacity()
+	s.LossFraction, _ = e.lossController.FractionLost(now)
+	if n := len(e.rembHistory); n > 0 {
+		s.REMB.LastBitrate = e.rembHistory[n-1].Bitrate
+		s.REMB.LastTime = e.rembHistory[n-1].Time
+	}
+
+	s.SSRCs = make([]SSRCStats, 0, len(e.ssrcs))
+	for _, ssrc := range e.sortedSSRCs() {
+		activity := e.ssrcs[ssrc]
+		s.SSRCs = append(s.SSRCs, SSRCStats{
+			SSRC:       ssrc,
+			Packets:    activity.packets,
+			Bytes:      activity.bytes,
+			LastPacket: activity.lastPacket,
+			Idle:       activity.idle,
+		})
+	}
+	return s
+}
+
+// recordREMB adds a built REMB to the stats history.
+// Must be called with mu held.
+func (e *BandwidthEstimator) recordREMB(event REMBEvent) {
+	e.rembSent++
+	if len(e.rembHistory) == rembHistorySize {
+		copy(e.rembHistory, e.rembHistory[1:])
+		e.rembHistory = e.rembHistory[:len(e.rembHistory)-1]
+	}
+	e.rembHistory = append(e.rembHistory, event)
+}
+
+// Stats returns a snapshot of the delay-based pipeline.
+func (e *DelayEstimator) Stats() DelayStats {
+	s := DelayStats{
+		FilterOutput: e.FilterOutput(),
+		Threshold:    e.Threshold(),
+		Groups:       e.groups,
+		InterArrival: e.InterArrivalStats(),
+	}
+	s.KalmanCapacity, _ = e.KalmanCapacity()
+	s.ClockSkewPPM, _ = e.ClockSkewPPM()
+
+	if e.parity != nil {
+		s.TrendlineSlope = e.parity.Slope()
+		return s
+	}
+	switch f := e.filter.(type) {
+	case *kalmanAdapter:
+		s.NoiseVariance = f.filter.NoiseVariance()
+	case *capacityKalmanAdapter:
+		s.NoiseVariance = f.filter.NoiseVariance()
+	case *trendlineAdapter:
+		s.TrendlineSlope = f.estimator.Slope()
+	}
+	return s
+}
+
+// Stats returns a snapshot of the stream that determined the combined
+// state, with Groups and InterArrival summed over all streams, including
+// removed ones.
+func (p *PerStreamDelayEstimator) Stats() DelayStats {
+	var s DelayStats
+	if p.dominant != nil {
+		s = p.dominant.Stats()
+	} else {
+		s.Threshold = p.Threshold()
+	}
+	s.Groups = p.removedGroups
+	for _, stream := range p.streams {
+		s.Groups += stream.estimator.groups
+	}
+	s.InterArrival = p.InterArrivalStats()
+	return s
+}
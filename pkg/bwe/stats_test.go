@@ -0,0 +1,137 @@
+package bwe
+
+import (
+	"testing"
+	"time"
+
+	"github.com/thesyncim/bwe/pkg/bwe/clock"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStats_MatchesGetters(t *testing.T) {
+	start := time.Unix(0, 0)
+	clock := clock.NewVirtual(start)
+	e := NewBandwidthEstimator(DefaultBandwidthEstimatorConfig(), clock)
+
+	observerRun(e, clock, start, 2, 0, 1000, 0)
+	observerRun(e, clock, start, 1, 1000, 2000, 50*time.Millisecond)
+
+	s := e.Stats()
+	assert.Equal(t, clock.Now(), s.Time)
+	assert.Equal(t, e.GetEstimate(), s.Estimate)
+	assert.Equal(t, e.GetDelayBasedEstimate(), s.DelayBasedEstimate)
+	assert.Equal(t, e.GetActiveBound(), s.ActiveBound)
+	assert.Equal(t, e.GetCongestionState(), s.CongestionState)
+	assert.Equal(t, e.GetRateControlState(), s.RateControlState)
+	assert.Equal(t, e.GetRTT(), s.RTT)
+	assert.Equal(t, e.GetLastPacketTime(), s.LastPacketTime)
+	rate, ok := e.GetIncomingRate()
+	require.True(t, ok)
+	assert.Equal(t, rate, s.IncomingRate)
+
+	assert.Equal(t, e.GetDelayFilterOutput(), s.Delay.FilterOutput)
+	assert.Equal(t, e.GetThreshold(), s.Delay.Threshold)
+	assert.Equal(t, e.GetInterArrivalStats(), s.Delay.InterArrival)
+	assert.GreaterOrEqual(t, s.Delay.NoiseVariance, 1.0, "Kalman noise variance is floored at 1")
+	assert.Zero(t, s.Delay.TrendlineSlope)
+	assert.Positive(t, s.Delay.Groups)
+
+	// Per-SSRC counters, in SSRC order
+	require.Len(t, s.SSRCs, 2)
+	assert.Equal(t, SSRCStats{SSRC: 1, Packets: 100, Bytes: 125000, LastPacket: s.LastPacketTime}, s.SSRCs[0])
+	assert.Equal(t, uint32(2), s.SSRCs[1].SSRC)
+	assert.Equal(t, int64(100), s.SSRCs[1].Packets)
+
+	assert.Equal(t, REMBStats{}, s.REMB)
+}
+
+func TestStats_FilterInternals(t *testing.T) {
+	tests := []struct {
+		name      string
+		configure func(*DelayEstimatorConfig)
+		noise     bool
+		slope     bool
+		capacity  bool
+	}{
+		{"Trendline", func(c *DelayEstimatorConfig) { c.FilterType = FilterTrendline }, false, true, false},
+		{"TrendlineParity", func(c *DelayEstimatorConfig) {
+			c.FilterType = FilterTrendline
+			c.TrendlineConfig.LibWebRTCParity = true
+		}, false, true, false},
+		{"KalmanCapacity", func(c *DelayEstimatorConfig) { c.FilterType = FilterKalmanCapacity }, true, false, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			start := time.Unix(0, 0)
+			clock := clock.NewVirtual(start)
+			config := DefaultBandwidthEstimatorConfig()
+			tt.configure(&config.DelayConfig)
+			e := NewBandwidthEstimator(config, clock)
+
+			observerRun(e, clock, start, 1, 0, 1000, 0)
+			observerRun(e, clock, start, 1, 1000, 1500, 5*time.Millisecond)
+
+			s := e.Stats().Delay
+			assert.Equal(t, tt.noise, s.NoiseVariance != 0, "noise variance %v", s.NoiseVariance)
+			assert.Equal(t, tt.slope, s.TrendlineSlope > 0, "slope %v", s.TrendlineSlope)
+			capacity, ok := e.GetKalmanCapacity()
+			assert.Equal(t, tt.capacity, ok)
+			assert.Equal(t, capacity, s.KalmanCapacity)
+		})
+	}
+}
+
+func TestStats_PerStreamGroups(t *testing.T) {
+	start := time.Unix(0, 0)
+	clock := clock.NewVirtual(start)
+	config := DefaultBandwidthEstimatorConfig()
+	config.PerStreamConfig.Enabled = true
+	e := NewBandwidthEstimator(config, clock)
+
+	observerRun(e, clock, start, 1, 0, 1000, 0)
+	single := e.Stats().Delay.Groups
+	require.Positive(t, single)
+
+	// A second stream's groups are added; those of a removed stream are kept
+	observerRun(e, clock, start, 2, 1000, 2000, 0)
+	both := e.Stats().Delay.Groups
+	assert.Greater(t, both, single)
+
+	e.Reset()
+	assert.Equal(t, both, e.Stats().Delay.Groups)
+}
+
+func TestStats_REMBHistory(t *testing.T) {
+	start := time.Unix(0, 0)
+	clock := clock.NewVirtual(start)
+	e := NewBandwidthEstimator(DefaultBandwidthEstimatorConfig(), clock)
+	e.SetREMBScheduler(NewREMBScheduler(REMBSchedulerConfig{Interval: 100 * time.Millisecond}))
+
+	observerRun(e, clock, start, 1, 0, 1000, 0)
+	sent := 0
+	for n := 0; n < rembHistorySize+5; n++ {
+		_, ok, err := e.MaybeBuildREMB(clock.Now())
+		require.NoError(t, err)
+		require.True(t, ok)
+		sent++
+		clock.Advance(100 * time.Millisecond)
+	}
+
+	s := e.Stats().REMB
+	assert.Equal(t, int64(sent), s.Sent)
+	require.Len(t, s.History, rembHistorySize)
+	last := s.History[len(s.History)-1]
+	assert.Equal(t, last.Bitrate, s.LastBitrate)
+	assert.Equal(t, last.Time, s.LastTime)
+	assert.Equal(t, clock.Now().Add(-100*time.Millisecond), s.LastTime)
+	assert.Equal(t, []uint32{1}, last.SSRCs)
+	for i := 1; i < len(s.History); i++ {
+		assert.True(t, s.History[i].Time.After(s.History[i-1].Time), "oldest first")
+	}
+
+	// The snapshot is a copy
+	s.History[0].Bitrate = -1
+	assert.NotEqual(t, int64(-1), e.Stats().REMB.History[0].Bitrate)
+}
@@ -21,7 +21,7 @@ import (
 	"testing"
 	"time"
 
-	"github.com/thesyncim/bwe/pkg/bwe/internal"
+	"github.com/thesyncim/bwe/pkg/bwe/clock"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -74,7 +74,7 @@ const (
 // Returns the final bandwidth estimate in bits per second.
 func simulateCongestion(
 	estimator *BandwidthEstimator,
-	clock *internal.MockClock,
+	clock *clock.Virtual,
 	duration time.Duration,
 	availableBandwidth int64,
 	congested bool,
@@ -141,7 +141,7 @@ func simulateCongestion(
 // Returns estimates collected at regular intervals.
 func generateCongestionPackets(
 	estimator *BandwidthEstimator,
-	clock *internal.MockClock,
+	clock *clock.Virtual,
 	numPackets int,
 	packetSize int,
 	baseInterval time.Duration,
@@ -194,7 +194,7 @@ func generateCongestionPackets(
 //   - Phase 2 estimate < 90% of total bandwidth (appropriate backoff)
 //   - Phase 3 estimate > Phase 2 estimate (recovery)
 func TestTCPFairness_ThreePhase(t *testing.T) {
-	clock := internal.NewMockClock(time.Now())
+	clock := clock.NewVirtual(time.Now())
 	config := DefaultBandwidthEstimatorConfig()
 	estimator := NewBandwidthEstimator(config, clock)
 
@@ -303,7 +303,7 @@ func TestTCPFairness_AdaptiveThreshold(t *testing.T) {
 	assert.Less(t, ratio, 60.0, "K_u/K_d ratio should not exceed 60")
 
 	// Test threshold adaptation behavior
-	clock := internal.NewMockClock(time.Now())
+	clock := clock.NewVirtual(time.Now())
 	detector := NewOveruseDetector(config, clock)
 
 	initialThreshold := detector.Threshold()
@@ -357,7 +357,7 @@ func TestTCPFairness_AdaptiveThreshold(t *testing.T) {
 // Some BWE implementations have bugs where prolonged congestion causes the estimate
 // to gradually decrease to zero. The adaptive threshold mechanism should prevent this.
 func TestTCPFairness_SustainedCongestion(t *testing.T) {
-	clock := internal.NewMockClock(time.Now())
+	clock := clock.NewVirtual(time.Now())
 	config := DefaultBandwidthEstimatorConfig()
 	estimator := NewBandwidthEstimator(config, clock)
 
@@ -414,7 +414,7 @@ func TestTCPFairness_SustainedCongestion(t *testing.T) {
 // This simulates scenarios where TCP flows start and stop frequently, testing that
 // the estimator doesn't oscillate wildly.
 func TestTCPFairness_RapidTransitions(t *testing.T) {
-	clock := internal.NewMockClock(time.Now())
+	clock := clock.NewVirtual(time.Now())
 	config := DefaultBandwidthEstimatorConfig()
 	estimator := NewBandwidthEstimator(config, clock)
 
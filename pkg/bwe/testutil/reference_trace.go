@@ -12,7 +12,7 @@ import (
 	"os"
 	"time"
 
-	"github.com/thesyncim/bwe/pkg/bwe/internal"
+	"github.com/thesyncim/bwe/pkg/bwe/clock"
 )
 
 // TracedPacket represents a single packet in a reference trace.
@@ -97,11 +97,11 @@ type PacketProcessor func(arrivalTime time.Time, sendTime uint32, size int, ssrc
 //
 // Parameters:
 //   - processor: A function that processes packets and returns estimates
-//   - clock: A MockClock for deterministic replay
+//   - clock: A virtual clock for deterministic replay
 //
 // Returns a slice of bandwidth estimates, one per packet.
 // The slice has the same length as trace.Packets.
-func (t *ReferenceTrace) Replay(processor PacketProcessor, clock *internal.MockClock) []int64 {
+func (t *ReferenceTrace) Replay(processor PacketProcessor, clock *clock.Virtual) []int64 {
 	estimates := make([]int64, len(t.Packets))
 
 	// Track the start time for calculating arrival deltas
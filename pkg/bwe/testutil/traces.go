@@ -8,7 +8,7 @@ package testutil
 import (
 	"time"
 
-	"github.com/thesyncim/bwe/pkg/bwe/internal"
+	"github.com/thesyncim/bwe/pkg/bwe/clock"
 )
 
 // PacketInfo mirrors bwe.PacketInfo for trace generation without import cycle.
@@ -30,12 +30,12 @@ const (
 // Packets arrive at the same rate they were sent - no queue building or draining.
 //
 // Parameters:
-//   - clock: MockClock for deterministic time control
+//   - clock: Virtual clock for deterministic time control
 //   - count: Number of packets to generate
 //   - intervalMs: Inter-packet interval in milliseconds
 //
 // Returns a slice of PacketInfo simulating a stable network.
-func StableNetworkTrace(clock *internal.MockClock, count int, intervalMs int) []PacketInfo {
+func StableNetworkTrace(clock *clock.Virtual, count int, intervalMs int) []PacketInfo {
 	packets := make([]PacketInfo, count)
 	sendTime := uint32(0)
 
@@ -59,13 +59,13 @@ func StableNetworkTrace(clock *internal.MockClock, count int, intervalMs int) []
 // This produces positive delay variation (congestion signal).
 //
 // Parameters:
-//   - clock: MockClock for deterministic time control
+//   - clock: Virtual clock for deterministic time control
 //   - count: Number of packets to generate
 //   - intervalMs: Nominal inter-packet interval in milliseconds
 //   - delayIncreaseMs: Additional delay per packet (queue growth rate)
 //
 // Returns a slice of PacketInfo simulating congestion.
-func CongestingNetworkTrace(clock *internal.MockClock, count int, intervalMs int, delayIncreaseMs float64) []PacketInfo {
+func CongestingNetworkTrace(clock *clock.Virtual, count int, intervalMs int, delayIncreaseMs float64) []PacketInfo {
 	packets := make([]PacketInfo, count)
 	sendTime := uint32(0)
 
@@ -89,13 +89,13 @@ func CongestingNetworkTrace(clock *internal.MockClock, count int, intervalMs int
 // This produces negative delay variation (underuse signal).
 //
 // Parameters:
-//   - clock: MockClock for deterministic time control
+//   - clock: Virtual clock for deterministic time control
 //   - count: Number of packets to generate
 //   - intervalMs: Nominal inter-packet interval in milliseconds
 //   - delayDecreaseMs: Delay decrease per packet (queue drain rate)
 //
 // Returns a slice of PacketInfo simulating underuse.
-func DrainingNetworkTrace(clock *internal.MockClock, count int, intervalMs int, delayDecreaseMs float64) []PacketInfo {
+func DrainingNetworkTrace(clock *clock.Virtual, count int, intervalMs int, delayDecreaseMs float64) []PacketInfo {
 	packets := make([]PacketInfo, count)
 	sendTime := uint32(0)
 
@@ -122,11 +122,11 @@ func DrainingNetworkTrace(clock *internal.MockClock, count int, intervalMs int,
 // The abs-send-time field wraps every 64 seconds (AbsSendTimeMax = 16777216).
 //
 // Parameters:
-//   - clock: MockClock for deterministic time control
+//   - clock: Virtual clock for deterministic time control
 //   - count: Number of packets to generate
 //
 // Returns packets spanning across the 64-second wraparound boundary.
-func WraparoundTrace(clock *internal.MockClock, count int) []PacketInfo {
+func WraparoundTrace(clock *clock.Virtual, count int) []PacketInfo {
 	// Start near max (64 second mark), generate packets across wrap
 	packets := make([]PacketInfo, count)
 
@@ -151,14 +151,14 @@ func WraparoundTrace(clock *internal.MockClock, count int) []PacketInfo {
 // Useful for testing burst grouping in InterArrivalCalculator.
 //
 // Parameters:
-//   - clock: MockClock for deterministic time control
+//   - clock: Virtual clock for deterministic time control
 //   - burstCount: Number of bursts
 //   - packetsPerBurst: Packets in each burst
 //   - interBurstMs: Gap between bursts in milliseconds
 //   - intraBurstMs: Gap within burst (should be < burst threshold, typically < 5ms)
 //
 // Returns packets organized in distinct bursts.
-func BurstTrace(clock *internal.MockClock, burstCount, packetsPerBurst, interBurstMs, intraBurstMs int) []PacketInfo {
+func BurstTrace(clock *clock.Virtual, burstCount, packetsPerBurst, interBurstMs, intraBurstMs int) []PacketInfo {
 	packets := make([]PacketInfo, burstCount*packetsPerBurst)
 	sendTime := uint32(0)
 	idx := 0
@@ -14,6 +14,18 @@ func AbsSendTimeToDuration(value uint32) time.Duration {
 	return time.Duration(seconds * float64(time.Second))
 }
 
+// DurationToAbsSendTime converts a duration since an arbitrary epoch to a
+// 24-bit abs-send-time value (6.18 fixed point, modulo 64 seconds).
+//
+// This is the inverse of AbsSendTimeToDuration for durations under 64 seconds,
+// and lets send times from a local clock be fed through the same pipeline as
+// values read from the abs-send-time header extension.
+func DurationToAbsSendTime(d time.Duration) uint32 {
+	// Work in microseconds to avoid int64 overflow for long sessions
+	units := d.Microseconds() * (1 << 18) / 1_000_000
+	return uint32(units) & (AbsSendTimeMax - 1)
+}
+
 // UnwrapAbsSendTime computes the signed delta between two abs-send-time values,
 // correctly handling wraparound at the 64-second boundary.
 //
@@ -79,6 +91,15 @@ func AbsCaptureTimeToDuration(value uint64) time.Duration {
 	return time.Duration(seconds)*time.Second + fractionDuration
 }
 
+// AbsCaptureTimeToAbsSendTime truncates a 64-bit abs-capture-time value to
+// the 24-bit abs-send-time format: 6 bits of seconds (modulo 64) and the top
+// 18 bits of the fraction.
+func AbsCaptureTimeToAbsSendTime(value uint64) uint32 {
+	seconds := (value >> 32) & 0x3F     // 6 bits of seconds (mod 64)
+	fraction := (value >> 14) & 0x3FFFF // 18 bits of fraction
+	return uint32((seconds << 18) | fraction)
+}
+
 // UnwrapAbsCaptureTime computes the signed delta between two abs-capture-time values.
 //
 // Unlike abs-send-time, the 64-bit abs-capture-time has a range of ~136 years,
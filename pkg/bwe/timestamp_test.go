@@ -409,3 +409,41 @@ func TestAbsCaptureTimeConstants(t *testing.T) {
 		t.Errorf("AbsCaptureTimeResolution = %e, want %e", AbsCaptureTimeResolution, expectedResolution)
 	}
 }
+
+func TestAbsCaptureTimeToAbsSendTime(t *testing.T) {
+	tests := []struct {
+		name  string
+		value uint64
+		want  uint32
+	}{
+		{
+			name:  "zero",
+			value: 0,
+			want:  0,
+		},
+		{
+			name:  "1 second",
+			value: 1 << 32,
+			want:  1 << 18,
+		},
+		{
+			name:  "half second",
+			value: 1 << 31,
+			want:  1 << 17,
+		},
+		{
+			name:  "seconds wrap at 64",
+			value: 65 << 32,
+			want:  1 << 18,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := AbsCaptureTimeToAbsSendTime(tt.value)
+			if got != tt.want {
+				t.Errorf("AbsCaptureTimeToAbsSendTime(%d) = %d, want %d", tt.value, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,99 @@
+package bwe
+
+import (
+	"time"
+
+	"github.com/pion/rtcp"
+)
+
+// transportCCReferenceTimeUnit is the resolution of the transport-cc
+// reference time field (24 bits, multiples of 64ms).
+const transportCCReferenceTimeUnit = 64 * time.Millisecond
+
+// transportCCReferenceTimeMax is the wrap point of the 24-bit reference time.
+const transportCCReferenceTimeMax = 1 << 24
+
+// transportCCArrivalOrigin is the epoch used for remote arrival times decoded
+// from transport-cc feedback. Only differences between arrival times matter.
+var transportCCArrivalOrigin = time.Unix(0, 0)
+
+// ParseTransportCC decodes the packet status chunks and receive deltas of a
+// transport-cc feedback packet and appends one PacketFeedback per reported
+// packet to dst.
+//
+// Arrival times are relative to refBase, which should account for reference
+// time wraparound across feedback packets (see SendSideEstimator.OnTransportCC).
+// Packets reported as received without a timestamp are skipped.
+func ParseTransportCC(fb *rtcp.TransportLayerCC, refBase time.Time, dst []PacketFeedback) []PacketFeedback {
+	arrival := refBase.Add(time.Duration(fb.ReferenceTime) * transportCCReferenceTimeUnit)
+	seq := fb.BaseSequenceNumber
+	remaining := int(fb.PacketStatusCount)
+	deltaIndex := 0
+
+	// addSymbol consumes one status symbol and, for received packets,
+	// the matching receive delta.
+	addSymbol := func(symbol uint16) {
+		switch symbol {
+		case rtcp.TypeTCCPacketNotReceived:
+			dst = append(dst, PacketFeedback{TransportSequenceNumber: seq})
+		case rtcp.TypeTCCPacketReceivedSmallDelta, rtcp.TypeTCCPacketReceivedLargeDelta:
+			if deltaIndex < len(fb.RecvDeltas) {
+				arrival = arrival.Add(time.Duration(fb.RecvDeltas[deltaIndex].Delta) * time.Microsecond)
+				deltaIndex++
+				dst = append(dst, PacketFeedback{
+					TransportSequenceNumber: seq,
+					Received:                true,
+					ArrivalTime:             arrival,
+				})
+			}
+		}
+		seq++
+		remaining--
+	}
+
+	for _, chunk := range fb.PacketChunks {
+		switch c := chunk.(type) {
+		case *rtcp.RunLengthChunk:
+			for n := uint16(0); n < c.RunLength && remaining > 0; n++ {
+				addSymbol(c.PacketStatusSymbol)
+			}
+		case *rtcp.StatusVectorChunk:
+			for _, symbol := range c.SymbolList {
+				if remaining <= 0 {
+					break
+				}
+				addSymbol(symbol)
+			}
+		}
+	}
+
+	return dst
+}
+
+// OnTransportCC processes a transport-cc feedback packet and returns the new
+// target bitrate in bits per second.
+//
+// The 24-bit reference time is unwrapped across successive feedback packets
+// so arrival times stay on one continuous timeline.
+func (e *SendSideEstimator) OnTransportCC(fb *rtcp.TransportLayerCC, now time.Time) int64 {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	// Unwrap reference time using half-range comparison
+	if e.hasRefTime {
+		diff := int64(fb.ReferenceTime) - int64(e.lastRefTime)
+		if diff < -transportCCReferenceTimeMax/2 {
+			e.refTimeCycles++
+		} else if diff > transportCCReferenceTimeMax/2 {
+			e.refTimeCycles--
+		}
+	}
+	e.lastRefTime = fb.ReferenceTime
+	e.hasRefTime = true
+
+	refBase := transportCCArrivalOrigin.Add(
+		time.Duration(e.refTimeCycles*transportCCReferenceTimeMax) * transportCCReferenceTimeUnit)
+
+	e.feedback = ParseTransportCC(fb, refBase, e.feedback[:0])
+	return e.onFeedbackLocked(e.feedback, now)
+}
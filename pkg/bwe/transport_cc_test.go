@@ -0,0 +1,88 @@
+package bwe
+
+import (
+	"testing"
+	"time"
+
+	"github.com/pion/interceptor/pkg/twcc"
+	"github.com/pion/rtcp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseTransportCC_RoundTrip(t *testing.T) {
+	// Build feedback with Pion's recorder: 10 packets, #3 and #7 lost
+	recorder := twcc.NewRecorder(1)
+	for i := 0; i < 10; i++ {
+		if i == 3 || i == 7 {
+			continue
+		}
+		recorder.Record(0x1234, uint16(100+i), int64(i)*5000) // 5ms apart
+	}
+	pkts := recorder.BuildFeedbackPacket()
+	require.Len(t, pkts, 1)
+	fb, ok := pkts[0].(*rtcp.TransportLayerCC)
+	require.True(t, ok)
+
+	// Marshal round trip to exercise the wire format
+	raw, err := fb.Marshal()
+	require.NoError(t, err)
+	var parsed rtcp.TransportLayerCC
+	require.NoError(t, parsed.Unmarshal(raw))
+
+	base := time.Unix(0, 0)
+	results := ParseTransportCC(&parsed, base, nil)
+	require.Len(t, results, 10)
+
+	var prevArrival time.Time
+	for i, r := range results {
+		assert.Equal(t, uint16(100+i), r.TransportSequenceNumber)
+		if i == 3 || i == 7 {
+			assert.False(t, r.Received, "packet %d should be reported lost", i)
+			continue
+		}
+		require.True(t, r.Received, "packet %d should be reported received", i)
+		if !prevArrival.IsZero() {
+			assert.Greater(t, r.ArrivalTime.Sub(prevArrival), time.Duration(0))
+		}
+		prevArrival = r.ArrivalTime
+	}
+
+	// 5ms spacing is preserved between consecutive received packets
+	assert.Equal(t, 5*time.Millisecond, results[1].ArrivalTime.Sub(results[0].ArrivalTime))
+}
+
+func TestSendSideEstimator_OnTransportCC(t *testing.T) {
+	e := NewSendSideEstimator(DefaultSendSideConfig(), nil)
+	recorder := twcc.NewRecorder(1)
+
+	start := time.Now()
+	seq := uint16(0)
+	for report := 0; report < 30; report++ {
+		for i := 0; i < 10; i++ {
+			sendTime := start.Add(time.Duration(int(seq)*10) * time.Millisecond)
+			e.OnPacketSent(SentPacket{TransportSequenceNumber: seq, SendTime: sendTime, Size: 1200, SSRC: 0x1234})
+			recorder.Record(0x1234, seq, int64(seq)*10_000+20_000)
+			seq++
+		}
+		for _, pkt := range recorder.BuildFeedbackPacket() {
+			if fb, ok := pkt.(*rtcp.TransportLayerCC); ok {
+				e.OnTransportCC(fb, start.Add(time.Duration(report)*100*time.Millisecond))
+			}
+		}
+	}
+
+	acked, ok := e.GetAckedBitrate()
+	require.True(t, ok, "acked bitrate should be computed from transport-cc feedback")
+	assert.InDelta(t, 960_000, acked, 100_000)
+	assert.Equal(t, BwNormal, e.GetCongestionState())
+}
+
+func TestSendSideEstimator_ReferenceTimeWrap(t *testing.T) {
+	e := NewSendSideEstimator(DefaultSendSideConfig(), nil)
+
+	e.OnTransportCC(&rtcp.TransportLayerCC{ReferenceTime: transportCCReferenceTimeMax - 1}, time.Now())
+	e.OnTransportCC(&rtcp.TransportLayerCC{ReferenceTime: 1}, time.Now())
+
+	assert.Equal(t, int64(1), e.refTimeCycles, "reference time wrap should advance the cycle count")
+}
@@ -22,6 +22,12 @@ type TrendlineConfig struct {
 	// Scales the output to match the overuse detector's expected input range.
 	// Default: 4.0
 	ThresholdGain float64
+
+	// LibWebRTCParity replaces the trendline filter and the OveruseDetector
+	// with LibWebRTCTrendlineEstimator, which reproduces libwebrtc's
+	// TrendlineEstimator sample for sample. OveruseConfig is then unused.
+	// Default: false
+	LibWebRTCParity bool
 }
 
 // DefaultTrendlineConfig returns the default configuration for the trendline estimator.
@@ -55,6 +61,7 @@ type TrendlineEstimator struct {
 	smoothedDelay float64   // Running smoothed delay accumulator
 	numDeltas     int       // Total number of samples seen
 	firstArrival  time.Time // Reference time for arrivalTimeMs calculation
+	slope         float64   // Most recent fitted slope
 }
 
 // NewTrendlineEstimator creates a new trendline estimator with the given configuration.
@@ -108,6 +115,7 @@ func (t *TrendlineEstimator) Update(arrivalTime time.Time, delayVariationMs floa
 
 	// Compute slope via linear regression
 	slope := t.linearFitSlope()
+	t.slope = slope
 
 	// Modified trend: min(numDeltas, 60) * slope * gain
 	// The min(60) caps the multiplier to prevent runaway values during startup
@@ -147,6 +155,12 @@ func (t *TrendlineEstimator) linearFitSlope() float64 {
 	return (nf*sumXY - sumX*sumY) / denom
 }
 
+// Slope returns the most recent fitted slope of smoothed delay over arrival
+// time, before the sample count and gain are applied.
+func (t *TrendlineEstimator) Slope() float64 {
+	return t.slope
+}
+
 // Reset clears the estimator state, allowing it to be reused.
 // This should be called when switching streams or after a long pause.
 func (t *TrendlineEstimator) Reset() {
@@ -154,4 +168,5 @@ func (t *TrendlineEstimator) Reset() {
 	t.smoothedDelay = 0
 	t.numDeltas = 0
 	t.firstArrival = time.Time{} // Zero time
+	t.slope = 0
 }
@@ -0,0 +1,287 @@
+package bwe
+
+import (
+	"math"
+	"time"
+)
+
+// libwebrtc trendline constants (from trendline_estimator.cc).
+const (
+	// libwebrtcMaxAdaptOffsetMs is how far the modified trend may exceed the
+	// threshold before the sample is treated as a spike and the threshold is
+	// not adapted (kMaxAdaptOffsetMs).
+	libwebrtcMaxAdaptOffsetMs = 15.0
+
+	// libwebrtcOverusingTimeThresholdMs is how long the trend must stay above
+	// the threshold before overuse is signaled (kOverUsingTimeThreshold).
+	libwebrtcOverusingTimeThresholdMs = 10.0
+
+	// libwebrtcMinNumDeltas caps the sample count multiplier (kMinNumDeltas).
+	libwebrtcMinNumDeltas = 60
+
+	// libwebrtcDeltaCounterMax caps the sample counter (kDeltaCounterMax).
+	libwebrtcDeltaCounterMax = 1000
+
+	// Threshold adaptation gains per millisecond (k_up_, k_down_).
+	libwebrtcThresholdKUp   = 0.0087
+	libwebrtcThresholdKDown = 0.039
+
+	// libwebrtcMaxThresholdTimeDeltaMs caps the time step of one threshold update.
+	libwebrtcMaxThresholdTimeDeltaMs = 100.0
+
+	// Threshold bounds and initial value in ms.
+	libwebrtcInitialThreshold = 12.5
+	libwebrtcMinThreshold     = 6.0
+	libwebrtcMaxThreshold     = 600.0
+)
+
+// paritySample is one entry of the libwebrtc trendline history.
+type paritySample struct {
+	arrivalMs     float64 // Arrival time in ms since the first sample
+	smoothedDelay float64 // Smoothed accumulated delay at this point
+}
+
+// LibWebRTCTrendlineEstimator reproduces libwebrtc's TrendlineEstimator,
+// filter and overuse detector together, sample for sample. It is selected by
+// TrendlineConfig.LibWebRTCParity.
+//
+// It differs from TrendlineEstimator combined with OveruseDetector in that it:
+//   - smooths the accumulated delay rather than the per-sample variation
+//   - keeps arrival times as float ms relative to the first arrival
+//   - holds the previous trend until the window is full
+//   - times overuse in send-time ms, starting at half the first send delta
+//   - adapts its threshold per ms with k_up 0.0087 and k_down 0.039, capped
+//     at 100ms per update, and skips adaptation for spikes more than 15ms
+//     above the threshold
+//   - reports normal until two samples have been seen
+//   - only signals overuse while the trend is still increasing
+type LibWebRTCTrendlineEstimator struct {
+	config  TrendlineConfig
+	history []paritySample
+
+	numDeltas        int
+	firstArrival     time.Time
+	accumulatedDelay float64
+	smoothedDelay    float64
+	prevTrend        float64
+	modifiedTrend    float64
+
+	threshold     float64
+	lastUpdateMs  float64
+	hasLastUpdate bool
+
+	timeOverUsing  float64 // ms; -1 when not over the threshold
+	overuseCounter int
+	hypothesis     BandwidthUsage
+	callback       StateChangeCallback
+}
+
+// NewLibWebRTCTrendlineEstimator creates a new libwebrtc-parity trendline
+// estimator. If WindowSize is less than 2, it defaults to 20; a SmoothingCoef
+// outside (0, 1) defaults to 0.9 and a non-positive ThresholdGain to 4.0.
+func NewLibWebRTCTrendlineEstimator(config TrendlineConfig) *LibWebRTCTrendlineEstimator {
+	defaults := DefaultTrendlineConfig()
+	if config.WindowSize < 2 {
+		config.WindowSize = defaults.WindowSize
+	}
+	if config.SmoothingCoef <= 0 || config.SmoothingCoef >= 1 {
+		config.SmoothingCoef = defaults.SmoothingCoef
+	}
+	if config.ThresholdGain <= 0 {
+		config.ThresholdGain = defaults.ThresholdGain
+	}
+
+	t := &LibWebRTCTrendlineEstimator{
+		config:  config,
+		history: make([]paritySample, 0, config.WindowSize),
+	}
+	t.Reset()
+	return t
+}
+
+// SetCallback registers a callback that will be invoked when the bandwidth
+// usage state changes. Pass nil to disable callbacks.
+func (t *LibWebRTCTrendlineEstimator) SetCallback(cb StateChangeCallback) {
+	t.callback = cb
+}
+
+// Update processes the deltas between two completed packet groups and
+// returns the bandwidth usage state (libwebrtc's UpdateTrendline).
+//
+// Parameters:
+//   - recvDeltaMs: Difference in arrival times of the groups in ms
+//   - sendDeltaMs: Difference in send times of the groups in ms
+//   - arrivalTime: Arrival time of the packet that completed the group
+func (t *LibWebRTCTrendlineEstimator) Update(recvDeltaMs, sendDeltaMs float64, arrivalTime time.Time) BandwidthUsage {
+	deltaMs := recvDeltaMs - sendDeltaMs
+	t.numDeltas = min(t.numDeltas+1, libwebrtcDeltaCounterMax)
+	if t.firstArrival.IsZero() {
+		t.firstArrival = arrivalTime
+	}
+	arrivalMs := float64(arrivalTime.Sub(t.firstArrival)) / float64(time.Millisecond)
+
+	// Exponential backoff filter on the accumulated delay
+	t.accumulatedDelay += deltaMs
+	t.smoothedDelay = t.config.SmoothingCoef*t.smoothedDelay + (1-t.config.SmoothingCoef)*t.accumulatedDelay
+
+	// Maintain the window without reallocating
+	if len(t.history) == t.config.WindowSize {
+		copy(t.history, t.history[1:])
+		t.history = t.history[:len(t.history)-1]
+	}
+	t.history = append(t.history, paritySample{arrivalMs, t.smoothedDelay})
+
+	// The trend is only refit once the window is full
+	trend := t.prevTrend
+	if len(t.history) == t.config.WindowSize {
+		if slope, ok := t.linearFitSlope(); ok {
+			trend = slope
+		}
+	}
+
+	t.detect(trend, sendDeltaMs, arrivalMs)
+	return t.hypothesis
+}
+
+// linearFitSlope computes the least squares slope of smoothed delay over
+// arrival time. Returns false if all arrival times are equal.
+func (t *LibWebRTCTrendlineEstimator) linearFitSlope() (float64, bool) {
+	var sumX, sumY float64
+	for _, s := range t.history {
+		sumX += s.arrivalMs
+		sumY += s.smoothedDelay
+	}
+	n := float64(len(t.history))
+	xAvg := sumX / n
+	yAvg := sumY / n
+
+	var numerator, denominator float64
+	for _, s := range t.history {
+		x := s.arrivalMs - xAvg
+		numerator += x * (s.smoothedDelay - yAvg)
+		denominator += x * x
+	}
+	if denominator == 0 {
+		return 0, false
+	}
+	return numerator / denominator, true
+}
+
+// detect applies libwebrtc's overuse state machine to the trend.
+func (t *LibWebRTCTrendlineEstimator) detect(trend, sendDeltaMs, nowMs float64) {
+	oldHypothesis := t.hypothesis
+
+	if t.numDeltas < 2 {
+		t.hypothesis = BwNormal
+		t.notify(oldHypothesis)
+		return
+	}
+
+	t.modifiedTrend = float64(min(t.numDeltas, libwebrtcMinNumDeltas)) * trend * t.config.ThresholdGain
+
+	switch {
+	case t.modifiedTrend > t.threshold:
+		if t.timeOverUsing == -1 {
+			// Assume overuse began halfway since the previous sample
+			t.timeOverUsing = sendDeltaMs / 2
+		} else {
+			t.timeOverUsing += sendDeltaMs
+		}
+		t.overuseCounter++
+		if t.timeOverUsing > libwebrtcOverusingTimeThresholdMs && t.overuseCounter > 1 {
+			if trend >= t.prevTrend {
+				t.timeOverUsing = 0
+				t.overuseCounter = 0
+				t.hypothesis = BwOverusing
+			}
+		}
+	case t.modifiedTrend < -t.threshold:
+		t.timeOverUsing = -1
+		t.overuseCounter = 0
+		t.hypothesis = BwUnderusing
+	default:
+		t.timeOverUsing = -1
+		t.overuseCounter = 0
+		t.hypothesis = BwNormal
+	}
+
+	t.prevTrend = trend
+	t.updateThreshold(t.modifiedTrend, nowMs)
+	t.notify(oldHypothesis)
+}
+
+// updateThreshold adapts the threshold toward the magnitude of the modified
+// trend, skipping spikes far above it.
+func (t *LibWebRTCTrendlineEstimator) updateThreshold(modifiedTrend, nowMs float64) {
+	if !t.hasLastUpdate {
+		t.lastUpdateMs = nowMs
+		t.hasLastUpdate = true
+	}
+
+	absTrend := math.Abs(modifiedTrend)
+	if absTrend > t.threshold+libwebrtcMaxAdaptOffsetMs {
+		// Avoid adapting to big latency spikes, e.g. a sudden capacity drop
+		t.lastUpdateMs = nowMs
+		return
+	}
+
+	k := libwebrtcThresholdKUp
+	if absTrend < t.threshold {
+		k = libwebrtcThresholdKDown
+	}
+	timeDeltaMs := math.Min(nowMs-t.lastUpdateMs, libwebrtcMaxThresholdTimeDeltaMs)
+	t.threshold += k * (absTrend - t.threshold) * timeDeltaMs
+	t.threshold = math.Min(math.Max(t.threshold, libwebrtcMinThreshold), libwebrtcMaxThreshold)
+	t.lastUpdateMs = nowMs
+}
+
+// notify invokes the callback if the hypothesis changed.
+func (t *LibWebRTCTrendlineEstimator) notify(oldHypothesis BandwidthUsage) {
+	if t.hypothesis != oldHypothesis && t.callback != nil {
+		t.callback(oldHypothesis, t.hypothesis)
+	}
+}
+
+// State returns the current bandwidth usage state.
+func (t *LibWebRTCTrendlineEstimator) State() BandwidthUsage {
+	return t.hypothesis
+}
+
+// ModifiedTrend returns the most recent min(numDeltas, 60) * trend * gain,
+// the value compared against the threshold.
+func (t *LibWebRTCTrendlineEstimator) ModifiedTrend() float64 {
+	return t.modifiedTrend
+}
+
+// Slope returns the most recent fitted trend, before the sample count and
+// gain are applied.
+func (t *LibWebRTCTrendlineEstimator) Slope() float64 {
+	return t.prevTrend
+}
+
+// Threshold returns the current adaptive threshold in milliseconds.
+func (t *LibWebRTCTrendlineEstimator) Threshold() float64 {
+	return t.threshold
+}
+
+// NumDeltas returns the number of samples seen, capped at 1000.
+func (t *LibWebRTCTrendlineEstimator) NumDeltas() int {
+	return t.numDeltas
+}
+
+// Reset clears the estimator state, allowing it to be reused.
+func (t *LibWebRTCTrendlineEstimator) Reset() {
+	t.history = t.history[:0]
+	t.numDeltas = 0
+	t.firstArrival = time.Time{}
+	t.accumulatedDelay = 0
+	t.smoothedDelay = 0
+	t.prevTrend = 0
+	t.modifiedTrend = 0
+	t.threshold = libwebrtcInitialThreshold
+	t.lastUpdateMs = 0
+	t.hasLastUpdate = false
+	t.timeOverUsing = -1
+	t.overuseCounter = 0
+	t.hypothesis = BwNormal
+}
@@ -0,0 +1,240 @@
+package bwe
+
+import (
+	"testing"
+	"time"
+
+	"github.com/thesyncim/bwe/pkg/bwe/clock"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLibWebRTCTrendline_AccumulatedDelayAndFloatArrival(t *testing.T) {
+	config := DefaultTrendlineConfig()
+	config.WindowSize = 3
+	tr := NewLibWebRTCTrendlineEstimator(config)
+	start := time.Unix(0, 0)
+
+	// Each group is 1ms later than the previous one; arrivals 10.5ms apart
+	assert.Equal(t, BwNormal, tr.Update(11, 10, start))
+	assert.Equal(t, 0.0, tr.ModifiedTrend(), "fewer than two samples are not evaluated")
+
+	tr.Update(11, 10, start.Add(10500*time.Microsecond))
+	assert.Equal(t, 0.0, tr.ModifiedTrend(), "the trend is held until the window is full")
+
+	tr.Update(11, 10, start.Add(21*time.Millisecond))
+
+	// Smoothed accumulated delay: 0.1, 0.29, 0.561 at x = 0, 10.5, 21ms
+	// slope = 0.461 / 21; modified trend = 3 * slope * 4
+	assert.InDelta(t, 3*(0.461/21)*4, tr.ModifiedTrend(), 1e-9)
+	assert.Equal(t, 3, tr.NumDeltas())
+}
+
+// feedParity feeds groups sent every 20ms whose arrival spacing is recvMs.
+// Returns the states after each update.
+func feedParity(tr *LibWebRTCTrendlineEstimator, arrival *time.Time, recvMs float64, n int) []BandwidthUsage {
+	states := make([]BandwidthUsage, 0, n)
+	for i := 0; i < n; i++ {
+		*arrival = arrival.Add(time.Duration(recvMs * float64(time.Millisecond)))
+		states = append(states, tr.Update(recvMs, 20, *arrival))
+	}
+	return states
+}
+
+func TestLibWebRTCTrendline_OveruseNeedsTwoSamplesOverThreshold(t *testing.T) {
+	tr := NewLibWebRTCTrendlineEstimator(DefaultTrendlineConfig())
+	arrival := time.Unix(0, 0)
+	feedParity(tr, &arrival, 20, 30)
+	require.Equal(t, BwNormal, tr.State())
+
+	// Queue builds by 5ms per group until the modified trend crosses the threshold
+	crossed := -1
+	for i := 0; i < 100 && crossed < 0; i++ {
+		arrival = arrival.Add(25 * time.Millisecond)
+		state := tr.Update(25, 20, arrival)
+		if tr.ModifiedTrend() > tr.Threshold() {
+			crossed = i
+			// Overuse timer starts at half a send delta (10ms), not above 10ms
+			assert.Equal(t, BwNormal, state, "the first sample over the threshold only starts the timer")
+		}
+	}
+	require.GreaterOrEqual(t, crossed, 0, "queue growth should cross the threshold")
+
+	arrival = arrival.Add(25 * time.Millisecond)
+	assert.Equal(t, BwOverusing, tr.Update(25, 20, arrival),
+		"a second sample over the threshold with 30ms over-using signals overuse")
+}
+
+// TestLibWebRTCTrendline_MatchesReference replays a delay bump that fades:
+// groups sent every 10ms arrive 12ms apart for 8 groups, then 10ms apart
+// again. The expected values were recorded from libwebrtc's TrendlineEstimator
+// (UpdateTrendline, Detect and UpdateThreshold of trendline_estimator.cc,
+// default settings) fed the same deltas with integer ms arrival times. The
+// modified trend crosses the threshold while the trend is already falling,
+// so libwebrtc never signals overuse.
+func TestLibWebRTCTrendline_MatchesReference(t *testing.T) {
+	reference := []struct {
+		recvDeltaMs   float64
+		state         BandwidthUsage
+		modifiedTrend float64
+		threshold     float64
+	}{
+		{10, BwNormal, 0, 12.5},
+		{10, BwNormal, 0, 12.5},
+		{12, BwNormal, 0, 6.65},
+		{12, BwNormal, 0, 6},
+		{12, BwNormal, 0, 6},
+		{12, BwNormal, 0, 6},
+		{12, BwNormal, 0, 6},
+		{12, BwNormal, 0, 6},
+		{12, BwNormal, 0, 6},
+		{12, BwNormal, 0, 6},
+		{10, BwNormal, 0, 6},
+		{10, BwNormal, 0, 6},
+		{10, BwNormal, 0, 6},
+		{10, BwNormal, 0, 6},
+		{10, BwNormal, 0, 6},
+		{10, BwNormal, 0, 6},
+		{10, BwNormal, 0, 6},
+		{10, BwNormal, 0, 6},
+		{10, BwNormal, 0, 6},
+		{10, BwNormal, 5.54939692094, 6},
+		{10, BwNormal, 5.91634475914, 6},
+		{10, BwNormal, 6.18847625102, 6.01639743384},
+		{10, BwNormal, 6.34194774401, 6.04472031082},
+		{10, BwNormal, 6.37539838507, 6.07348930328},
+		{10, BwNormal, 6.29309907731, 6.09259535362},
+		{10, BwNormal, 6.105928159, 6.09375530769},
+		{10, BwNormal, 5.83205391438, 6},
+		{10, BwNormal, 5.4971025706, 6},
+		{10, BwNormal, 5.13360162171, 6},
+		{10, BwNormal, 4.77956013056, 6},
+		{10, BwNormal, 4.44499092142, 6},
+		{10, BwNormal, 4.1295399528, 6},
+		{10, BwNormal, 3.83272926869, 6},
+		{10, BwNormal, 3.55398532188, 6},
+		{10, BwNormal, 3.29266287174, 6},
+	}
+
+	tr := NewLibWebRTCTrendlineEstimator(DefaultTrendlineConfig())
+	arrival := time.Unix(0, 0)
+	for i, want := range reference {
+		arrival = arrival.Add(time.Duration(want.recvDeltaMs) * time.Millisecond)
+		assert.Equal(t, want.state, tr.Update(want.recvDeltaMs, 10, arrival), "sample %d", i)
+		assert.InDelta(t, want.modifiedTrend, tr.ModifiedTrend(), 1e-9, "sample %d", i)
+		assert.InDelta(t, want.threshold, tr.Threshold(), 1e-9, "sample %d", i)
+	}
+}
+
+func TestLibWebRTCTrendline_Underuse(t *testing.T) {
+	tr := NewLibWebRTCTrendlineEstimator(DefaultTrendlineConfig())
+	arrival := time.Unix(0, 0)
+	feedParity(tr, &arrival, 20, 30)
+
+	states := feedParity(tr, &arrival, 12, 40)
+	assert.Contains(t, states, BwUnderusing, "a draining queue should signal underuse")
+}
+
+func TestLibWebRTCTrendline_ThresholdAdaptation(t *testing.T) {
+	tr := NewLibWebRTCTrendlineEstimator(DefaultTrendlineConfig())
+	arrival := time.Unix(0, 0)
+	assert.Equal(t, 12.5, tr.Threshold())
+
+	// Stable: the threshold decays toward |trend| ~ 0 with k_down per ms,
+	// clamped at 6ms
+	feedParity(tr, &arrival, 20, 100)
+	assert.Equal(t, 6.0, tr.Threshold())
+
+	// Moderate growth: adapt with k_up, at most 100ms per update
+	for i := 0; i < 50; i++ {
+		before := tr.Threshold()
+		arrival = arrival.Add(22 * time.Millisecond)
+		tr.Update(22, 20, arrival)
+		m := tr.ModifiedTrend()
+		if m > before+libwebrtcMaxAdaptOffsetMs {
+			assert.Equal(t, before, tr.Threshold(), "spikes above threshold+15ms are not adapted to")
+			continue
+		}
+		k := libwebrtcThresholdKDown
+		if m >= before {
+			k = libwebrtcThresholdKUp
+		}
+		want := min(max(before+k*(m-before)*22, libwebrtcMinThreshold), libwebrtcMaxThreshold)
+		assert.InDelta(t, want, tr.Threshold(), 1e-9)
+	}
+}
+
+func TestLibWebRTCTrendline_SpikeSkip(t *testing.T) {
+	// A short, lightly smoothed window lets the trend jump in one sample
+	config := DefaultTrendlineConfig()
+	config.WindowSize = 2
+	config.SmoothingCoef = 0.1
+	tr := NewLibWebRTCTrendlineEstimator(config)
+	arrival := time.Unix(0, 0)
+	feedParity(tr, &arrival, 20, 30)
+	before := tr.Threshold()
+
+	// A sudden capacity drop: each group arrives 100ms later than the last
+	spikes := 0
+	for i := 0; i < 10; i++ {
+		before = tr.Threshold()
+		arrival = arrival.Add(120 * time.Millisecond)
+		tr.Update(120, 20, arrival)
+		if tr.ModifiedTrend() > before+libwebrtcMaxAdaptOffsetMs {
+			spikes++
+			assert.Equal(t, before, tr.Threshold(), "the threshold must not adapt to spikes")
+		}
+	}
+	assert.Positive(t, spikes, "the delay ramp should produce spikes above threshold+15ms")
+}
+
+func TestLibWebRTCTrendline_NumDeltasCapAndReset(t *testing.T) {
+	tr := NewLibWebRTCTrendlineEstimator(DefaultTrendlineConfig())
+	arrival := time.Unix(0, 0)
+	feedParity(tr, &arrival, 20, 1100)
+	assert.Equal(t, 1000, tr.NumDeltas())
+
+	tr.Reset()
+	assert.Equal(t, 0, tr.NumDeltas())
+	assert.Equal(t, 12.5, tr.Threshold())
+	assert.Equal(t, BwNormal, tr.State())
+}
+
+func TestDelayEstimator_LibWebRTCParity(t *testing.T) {
+	clock := clock.NewVirtual(time.Time{})
+	config := DefaultDelayEstimatorConfig()
+	config.FilterType = FilterTrendline
+	config.TrendlineConfig.LibWebRTCParity = true
+	e := NewDelayEstimator(config, clock)
+	require.NotNil(t, e.parity)
+
+	var changes []BandwidthUsage
+	e.SetCallback(func(_, new BandwidthUsage) { changes = append(changes, new) })
+
+	// Stable, then each group arrives 5ms later than sent
+	sendTime := uint32(0)
+	extra := time.Duration(0)
+	for i := 0; i < 200; i++ {
+		if i >= 50 {
+			extra += 5 * time.Millisecond
+		}
+		e.OnPacket(PacketInfo{ArrivalTime: clock.Now().Add(extra), SendTime: sendTime, Size: 1200, SSRC: 0x1234})
+		sendTime = (sendTime + DurationToAbsSendTime(20*time.Millisecond)) % AbsSendTimeMax
+		clock.Advance(20 * time.Millisecond)
+	}
+
+	assert.Contains(t, changes, BwOverusing)
+	assert.Equal(t, e.parity.Threshold(), e.Threshold())
+	assert.Equal(t, e.parity.ModifiedTrend(), e.FilterOutput())
+
+	e.Reset()
+	assert.Equal(t, 0, e.parity.NumDeltas())
+}
+
+func TestNewLibWebRTCTrendlineEstimator_AppliesDefaults(t *testing.T) {
+	tr := NewLibWebRTCTrendlineEstimator(TrendlineConfig{})
+	assert.Equal(t, DefaultTrendlineConfig().WindowSize, tr.config.WindowSize)
+	assert.Equal(t, DefaultTrendlineConfig().SmoothingCoef, tr.config.SmoothingCoef)
+	assert.Equal(t, DefaultTrendlineConfig().ThresholdGain, tr.config.ThresholdGain)
+}
@@ -31,6 +31,33 @@ func (b BandwidthUsage) String() string {
 	}
 }
 
+// SendTimeSource identifies the header extension a packet's send time
+// was taken from.
+type SendTimeSource int
+
+const (
+	// SendTimeAbsSendTime means the send time is the 24-bit abs-send-time
+	// in PacketInfo.SendTime. This is the zero value.
+	SendTimeAbsSendTime SendTimeSource = iota
+
+	// SendTimeAbsCaptureTime means the send time is the 64-bit
+	// abs-capture-time in PacketInfo.CaptureTime. Delay measurements use
+	// its full precision and range, without the 64-second wrap.
+	SendTimeAbsCaptureTime
+)
+
+// String returns a string representation of the SendTimeSource.
+func (s SendTimeSource) String() string {
+	switch s {
+	case SendTimeAbsSendTime:
+		return "AbsSendTime"
+	case SendTimeAbsCaptureTime:
+		return "AbsCaptureTime"
+	default:
+		return "Unknown"
+	}
+}
+
 // PacketInfo contains information about a received RTP packet used for
 // bandwidth estimation. This is the primary input to the delay-based detector.
 type PacketInfo struct {
@@ -40,13 +67,78 @@ type PacketInfo struct {
 
 	// SendTime is the 24-bit abs-send-time value from the RTP header extension.
 	// This is a 6.18 fixed-point representation of NTP time modulo 64 seconds.
+	// With SendTimeAbsCaptureTime it should hold the capture time truncated
+	// by AbsCaptureTimeToAbsSendTime, for components that only use 24 bits.
 	SendTime uint32
 
-	// Size is the payload size of the packet in bytes.
+	// SendTimeSource tells whether the send time is SendTime or CaptureTime.
+	SendTimeSource SendTimeSource
+
+	// CaptureTime is the 64-bit abs-capture-time value (UQ32.32 NTP time).
+	// Only used with SendTimeAbsCaptureTime.
+	CaptureTime uint64
+
+	// Size is the size of the whole packet in bytes: RTP header, payload and
+	// padding. This is what the incoming rate counts.
 	Size int
 
+	// HeaderSize is the size of the RTP header in bytes, including CSRCs
+	// and header extensions. Zero if unknown.
+	HeaderSize int
+
+	// PaddingSize is the number of RTP padding bytes at the end of the
+	// packet. Zero if the padding bit is not set or the size is unknown.
+	PaddingSize int
+
 	// SSRC is the synchronization source identifier for the media stream.
 	SSRC uint32
+
+	// SequenceNumber is the RTP sequence number of the packet.
+	// Used by the loss-based controller to detect sequence-number gaps.
+	SequenceNumber uint16
+
+	// TransportSequenceNumber is the transport-wide sequence number from
+	// the transport-cc header extension. Only valid if
+	// HasTransportSequenceNumber is true.
+	TransportSequenceNumber    uint16
+	HasTransportSequenceNumber bool
+
+	// PayloadType is the RTP payload type of the packet.
+	PayloadType uint8
+
+	// RTPTimestamp is the RTP timestamp of the packet. All packets of a
+	// video frame share it. Used by GroupByFrame.
+	RTPTimestamp uint32
+
+	// Marker reports whether the RTP marker bit is set. For video it marks
+	// the last packet of a frame. Used by GroupByFrame.
+	Marker bool
+
+	// Padding reports whether the RTP padding bit is set. Senders probe
+	// with padding-only packets, so those are treated as probes; media
+	// packets that only carry alignment padding are not.
+	Padding bool
+
+	// ProbeClusterID identifies the probe cluster the packet belongs to,
+	// when the application knows it (e.g. from a sender-side pacer).
+	// Zero means the packet is not part of an explicit probe cluster.
+	ProbeClusterID int
+
+	// Class is the kind of packet (media, padding, RTX or FEC). It selects
+	// the PacketPolicy the estimator applies.
+	Class PacketClass
+}
+
+// PayloadSize returns the media payload size in bytes: Size without the
+// RTP header and padding.
+func (p PacketInfo) PayloadSize() int {
+	return max(p.Size-p.HeaderSize-p.PaddingSize, 0)
+}
+
+// PaddingOnly reports whether the packet has the padding bit set and no
+// media payload.
+func (p PacketInfo) PaddingOnly() bool {
+	return p.Padding && p.PayloadSize() == 0
 }
 
 // Constants for abs-send-time (AST) header extension parsing.
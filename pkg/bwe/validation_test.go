@@ -11,7 +11,7 @@ import (
 	"testing"
 	"time"
 
-	"github.com/thesyncim/bwe/pkg/bwe/internal"
+	"github.com/thesyncim/bwe/pkg/bwe/clock"
 	"github.com/thesyncim/bwe/pkg/bwe/testutil"
 )
 
@@ -55,7 +55,7 @@ func TestEstimateDivergence_ReferenceComparison(t *testing.T) {
 		strings.Contains(strings.ToLower(trace.Description), "placeholder")
 
 	// Create estimator with default config
-	clock := internal.NewMockClock(trace.Packets[0].ArrivalTime())
+	clock := clock.NewVirtual(trace.Packets[0].ArrivalTime())
 	config := DefaultBandwidthEstimatorConfig()
 	estimator := NewBandwidthEstimator(config, clock)
 
@@ -123,7 +123,7 @@ func TestEstimateDivergence_GeneratedTrace(t *testing.T) {
 	t.Logf("Description: %s", trace.Description)
 
 	// Create estimator
-	clock := internal.NewMockClock(trace.Packets[0].ArrivalTime())
+	clock := clock.NewVirtual(trace.Packets[0].ArrivalTime())
 	config := DefaultBandwidthEstimatorConfig()
 	estimator := NewBandwidthEstimator(config, clock)
 